@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
+	"go-playground/pkg/ratelimit"
 	"go-playground/pkg/runner"
 	"go-playground/pkg/sandbox"
 )
@@ -20,8 +24,10 @@ type RunRequest struct {
 }
 
 type RunResponse struct {
-	Output string `json:"output"`
-	Error  string `json:"error,omitempty"`
+	Output    string `json:"output"`
+	Error     string `json:"error,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"` // Go toolchain that produced the output
+	Duration  int64  `json:"duration"`            // Execution time in milliseconds
 }
 
 type FormatRequest struct {
@@ -34,11 +40,27 @@ type FormatResponse struct {
 }
 
 func main() {
+	if err := loadRequestLimits(); err != nil {
+		log.Fatal(err)
+	}
+
 	r := mux.NewRouter()
 
+	// Per-client rate limiting, keyed by the client IP forwarded by nginx or share-service
+	trustedProxies := splitList(os.Getenv("TRUSTED_PROXIES"))
+	if len(trustedProxies) == 0 {
+		trustedProxies = []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	}
+	limiter, err := ratelimit.New(ratelimit.NewStore(), trustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	executeLimit := loadLimit("RATE_LIMIT_EXECUTE", "60/m")
+	readLimit := loadLimit("RATE_LIMIT_READ", "300/m")
+
 	// API routes
-	r.HandleFunc("/api/run", handleRun).Methods("POST")
-	r.HandleFunc("/api/format", handleFormat).Methods("POST")
+	r.Handle("/api/run", limiter.Middleware("execute", executeLimit, http.HandlerFunc(handleRun))).Methods("POST")
+	r.Handle("/api/format", limiter.Middleware("read", readLimit, http.HandlerFunc(handleFormat))).Methods("POST")
 	r.HandleFunc("/api/health", handleHealth).Methods("GET")
 
 	// Create a CORS middleware
@@ -46,6 +68,7 @@ func main() {
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3002", "http://localhost:3003"},
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 	})
 
@@ -58,20 +81,42 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), handler))
 }
 
+// loadLimit reads a rate limit such as "60/m" from the environment, falling back to def.
+// Rate limiting is disabled entirely when RATE_LIMIT_ENABLED=false.
+func loadLimit(name, def string) ratelimit.Limit {
+	if os.Getenv("RATE_LIMIT_ENABLED") == "false" {
+		return ratelimit.Limit{}
+	}
+	spec := os.Getenv(name)
+	if spec == "" {
+		spec = def
+	}
+	limit, err := ratelimit.ParseLimit(spec)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return limit
+}
+
+// splitList splits a comma or space separated list
+func splitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
 func handleRun(w http.ResponseWriter, r *http.Request) {
 	var req RunRequest
 	var resp RunResponse
 
 	// Parse the JSON request
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate the request
-	if req.Code == "" {
-		http.Error(w, "Code cannot be empty", http.StatusBadRequest)
+	if errs := validateCode(req.Code); len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
 
@@ -96,7 +141,10 @@ func handleRun(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Run the code in the sandbox
+	start := time.Now()
 	output, err := runner.Run(ctx, s, req.Code, req.Version)
+	resp.Duration = time.Since(start).Milliseconds()
+	resp.GoVersion = runtime.Version()
 	if err != nil {
 		resp.Error = err.Error()
 		// Even if there's an error, include any output that was produced
@@ -120,15 +168,13 @@ func handleFormat(w http.ResponseWriter, r *http.Request) {
 	var resp FormatResponse
 
 	// Parse the JSON request
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate the request
-	if req.Code == "" {
-		http.Error(w, "Code cannot be empty", http.StatusBadRequest)
+	if errs := validateCode(req.Code); len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
 
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxBodyBytes = 1 << 20  // 1MB
+	defaultMaxCodeBytes = 64 << 10 // 64KB, same default as share-service
+)
+
+// Request limits, configurable through REQUEST_MAX_BODY_BYTES and MAX_CODE_BYTES
+var (
+	maxBodyBytes int64 = defaultMaxBodyBytes
+	maxCodeBytes       = defaultMaxCodeBytes
+)
+
+// FieldError describes a validation problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// loadRequestLimits reads the request limits from the environment
+func loadRequestLimits() error {
+	if v := os.Getenv("REQUEST_MAX_BODY_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid REQUEST_MAX_BODY_BYTES %q", v)
+		}
+		maxBodyBytes = n
+	}
+	if v := os.Getenv("MAX_CODE_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid MAX_CODE_BYTES %q", v)
+		}
+		maxCodeBytes = n
+	}
+	return nil
+}
+
+// decodeJSON reads a size-limited JSON body into v. On failure it writes the
+// error response and returns false: 413 for oversized bodies, 400 for
+// malformed JSON and 422 for bodies that are not valid UTF-8.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.ContentLength > maxBodyBytes {
+		writeTooLarge(w, maxBodyBytes)
+		return false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeTooLarge(w, tooLarge.Limit)
+			return false
+		}
+		writeJSONError(w, http.StatusBadRequest, map[string]interface{}{"error": "Failed to read request body"})
+		return false
+	}
+	if !utf8.Valid(body) {
+		writeValidationError(w, []FieldError{{Field: "body", Message: "must be valid UTF-8"}})
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, map[string]interface{}{"error": "Invalid request format: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// validateCode checks that code is present and within the size limit
+func validateCode(code string) []FieldError {
+	if code == "" {
+		return []FieldError{{Field: "code", Message: "is required"}}
+	}
+	if len(code) > maxCodeBytes {
+		return []FieldError{{Field: "code", Message: fmt.Sprintf("must be at most %d bytes (got %d)", maxCodeBytes, len(code))}}
+	}
+	return nil
+}
+
+func writeValidationError(w http.ResponseWriter, fields []FieldError) {
+	writeJSONError(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  "validation failed",
+		"fields": fields,
+	})
+}
+
+func writeTooLarge(w http.ResponseWriter, limit int64) {
+	writeJSONError(w, http.StatusRequestEntityTooLarge, map[string]interface{}{
+		"error": "request body too large",
+		"limit": limit,
+	})
+}
+
+func writeJSONError(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket holding up to Burst tokens, refilled at Rate tokens per second
+type Limit struct {
+	Rate   float64
+	Burst  int
+	Window time.Duration // Configured window, only used for the RateLimit-Policy header
+}
+
+// Enabled reports whether the limit should be enforced
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit parses a "count/window" limit such as "30/m", "5/s", "1000/h" or "100/10m".
+// The bucket holds count tokens which refill evenly over the window; "off" or "0" disables the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	countStr, windowStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected format like 30/m", s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit count %q", countStr)
+	}
+
+	var window time.Duration
+	switch windowStr {
+	case "s":
+		window = time.Second
+	case "m":
+		window = time.Minute
+	case "h":
+		window = time.Hour
+	default:
+		window, err = time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit window %q", windowStr)
+		}
+	}
+	if count == 0 {
+		return Limit{}, nil
+	}
+	return Limit{Rate: float64(count) / window.Seconds(), Burst: count, Window: window}, nil
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket capacity
+	Remaining  int           // Tokens left in the bucket
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token is available when rejected
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time // After this time the bucket is full and can be dropped
+}
+
+// sweepInterval controls how often idle buckets are removed
+const sweepInterval = time.Minute
+
+// Store keeps token buckets in memory; all route classes share one store
+type Store struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewStore creates an in-memory bucket store
+func NewStore() *Store {
+	return &Store{buckets: make(map[string]*bucket)}
+}
+
+// Take removes one token from the bucket identified by key
+func (s *Store) Take(key string, limit Limit, now time.Time) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop buckets that have refilled so memory does not grow with the number of clients
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.lastSweep = now
+		for k, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	b.fullAt = now.Add(res.Reset)
+	return res
+}
+
+// Limiter rate limits requests per client IP
+type Limiter struct {
+	store   *Store
+	trusted []*net.IPNet
+}
+
+// New creates a limiter. X-Forwarded-For is only honoured when the request
+// comes from one of the trusted proxy networks (e.g. nginx or share-service).
+func New(store *Store, trustedProxies []string) (*Limiter, error) {
+	l := &Limiter{store: store}
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			if strings.Contains(p, ":") {
+				p += "/128"
+			} else {
+				p += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		l.trusted = append(l.trusted, network)
+	}
+	return l, nil
+}
+
+// Middleware wraps next with the given limit; class separates buckets of different route classes
+func (l *Limiter) Middleware(class string, limit Limit, next http.Handler) http.Handler {
+	if !limit.Enabled() {
+		return next
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Window.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := l.store.Take(class+"|"+l.ClientIP(r), limit, time.Now())
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", policy)
+
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			h.Set("Retry-After", strconv.Itoa(retryAfter))
+			h.Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":       "rate limit exceeded",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the client address, walking X-Forwarded-For from the right
+// and skipping trusted proxies so that clients cannot spoof their address
+func (l *Limiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !l.isTrusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(ip) == nil {
+			break
+		}
+		if !l.isTrusted(ip) {
+			return ip
+		}
+		host = ip
+	}
+	return host
+}
+
+func (l *Limiter) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
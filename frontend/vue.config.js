@@ -7,6 +7,39 @@ module.exports = {
         changeOrigin: true,
         ws: true
       },
+      '/api/collection': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/api/auth': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/api/admin': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/api/me/': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/api/sessions': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true,
+        ws: true
+      },
+      '/api/webhooks': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/api/oembed': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
+      '/embed': {
+        target: 'http://share-service-dev:3002',
+        changeOrigin: true
+      },
       '/api/execute': {
         target: 'http://share-service-dev:3002',
         changeOrigin: true,
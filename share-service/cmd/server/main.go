@@ -6,12 +6,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/playground/share-service/pkg/api"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/collab"
+	"github.com/playground/share-service/pkg/idgen"
+	"github.com/playground/share-service/pkg/importer"
+	"github.com/playground/share-service/pkg/oidc"
+	"github.com/playground/share-service/pkg/ratelimit"
 	"github.com/playground/share-service/pkg/storage/mongo"
+	"github.com/playground/share-service/pkg/trending"
+	"github.com/playground/share-service/pkg/webhook"
 )
 
 func main() {
@@ -42,22 +52,294 @@ func main() {
 	}
 	defer storage.Close(ctx)
 
+	// 应用结构迁移，数据库结构版本比程序新时拒绝启动
+	// MIGRATE_ON_START=false 时不自动迁移，由 share-admin migrate up 手动执行，存在未应用的迁移时拒绝启动
+	migrations := storage.Migrations()
+	if os.Getenv("MIGRATE_ON_START") == "false" {
+		if err := migrations.Check(ctx); err != nil {
+			log.Fatalf("Storage schema check failed: %v", err)
+		}
+	} else {
+		applied, err := migrations.Up(ctx, 0)
+		if err != nil {
+			log.Fatalf("Failed to migrate storage: %v", err)
+		}
+		for _, step := range applied {
+			log.Printf("Applied migration %d: %s", step.Version, step.Description)
+		}
+	}
+
+	// 定期重新计算热门分享，TRENDING_INTERVAL=0 时不在本实例运行
+	trendingInterval := trending.DefaultInterval
+	if v := os.Getenv("TRENDING_INTERVAL"); v != "" {
+		trendingInterval, err = time.ParseDuration(v)
+		if err != nil || trendingInterval < 0 {
+			log.Fatalf("Invalid TRENDING_INTERVAL: %q", v)
+		}
+	}
+	if trendingInterval > 0 {
+		go trending.NewJob(storage, trending.WithInterval(trendingInterval)).Run(ctx)
+	}
+
+	// Webhook 事件分发，WEBHOOK_WORKER=false 时本实例只保存事件，由其他实例投递
+	// 默认只投递到公网地址，WEBHOOK_ALLOW_PRIVATE=true 用于本地联调
+	dispatcherOpts := []webhook.Option{webhook.WithPublicURL(os.Getenv("PUBLIC_URL"))}
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true" {
+		dispatcherOpts = append(dispatcherOpts, webhook.WithPrivateAddresses())
+	}
+	dispatcher := webhook.NewDispatcher(storage, dispatcherOpts...)
+	if os.Getenv("WEBHOOK_WORKER") != "false" {
+		workerOpts := []webhook.WorkerOption{}
+		if os.Getenv("WEBHOOK_SAVE_RESPONSES") == "true" {
+			workerOpts = append(workerOpts, webhook.WithResponseBodies())
+		}
+		if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				log.Fatalf("Invalid WEBHOOK_MAX_ATTEMPTS: %q", v)
+			}
+			workerOpts = append(workerOpts, webhook.WithMaxAttempts(n))
+		}
+		if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				log.Fatalf("Invalid WEBHOOK_TIMEOUT: %q", v)
+			}
+			workerOpts = append(workerOpts, webhook.WithTimeout(d))
+		}
+		if v := os.Getenv("WEBHOOK_POLL_INTERVAL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				log.Fatalf("Invalid WEBHOOK_POLL_INTERVAL: %q", v)
+			}
+			workerOpts = append(workerOpts, webhook.WithPollInterval(d))
+		}
+		go webhook.NewWorker(storage, dispatcher, workerOpts...).Run(ctx)
+	}
+
+	// 初始化分享 ID 生成器
+	idLength := 0
+	if v := os.Getenv("SHARE_ID_LENGTH"); v != "" {
+		idLength, err = strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("Invalid SHARE_ID_LENGTH: %v", err)
+		}
+	}
+	ids, err := idgen.New(idLength, os.Getenv("SHARE_ID_ALPHABET"))
+	if err != nil {
+		log.Fatalf("Invalid share id configuration: %v", err)
+	}
+
+	// 登录会话有效期
+	sessionTTL := time.Duration(0)
+	if v := os.Getenv("AUTH_SESSION_TTL"); v != "" {
+		sessionTTL, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid AUTH_SESSION_TTL: %v", err)
+		}
+	}
+	allowRegistration := os.Getenv("AUTH_ALLOW_REGISTRATION") != "false"
+
 	// 创建 Gin 路由
 	router := gin.Default()
 
+	// 只信任来自反向代理的 X-Forwarded-For，避免客户端伪造 IP 绕过限流
+	trustedProxies := splitList(os.Getenv("TRUSTED_PROXIES"))
+	if len(trustedProxies) == 0 {
+		trustedProxies = []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// 添加中间件
 	router.Use(gin.Recovery())
 	router.Use(gin.Logger())
 
+	// 限制请求体和代码大小
+	maxBodyBytes := int64(api.DefaultMaxBodyBytes)
+	if v := os.Getenv("REQUEST_MAX_BODY_BYTES"); v != "" {
+		maxBodyBytes, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || maxBodyBytes <= 0 {
+			log.Fatalf("Invalid REQUEST_MAX_BODY_BYTES: %q", v)
+		}
+	}
+	router.Use(api.BodyLimit(maxBodyBytes))
+	maxCodeBytes := api.DefaultMaxCodeBytes
+	if v := os.Getenv("SHARE_MAX_CODE_BYTES"); v != "" {
+		maxCodeBytes, err = strconv.Atoi(v)
+		if err != nil || maxCodeBytes <= 0 {
+			log.Fatalf("Invalid SHARE_MAX_CODE_BYTES: %q", v)
+		}
+	}
+
+	// 识别请求携带的会话令牌或 API Key，匿名请求照常处理
+	// ADMIN_USER_IDS 中的用户和 ADMIN_GROUPS 中用户组的成员拥有审核权限，
+	// 用户名可以被任何人注册，不再用于识别管理员
+	if os.Getenv("ADMIN_USERS") != "" {
+		log.Fatalf("ADMIN_USERS is no longer supported, use ADMIN_USER_IDS or \"share-admin admin <username>\"")
+	}
+	authenticator := auth.New(storage, auth.WithAdmins(
+		splitList(os.Getenv("ADMIN_USER_IDS")),
+		splitList(os.Getenv("ADMIN_GROUPS")),
+	))
+	router.Use(authenticator.Middleware())
+	requireUser := auth.RequireUser()
+
+	// 按客户端限流，各路由类别的限制可通过 RATE_LIMIT_CREATE 等环境变量调整
+	limits := make(map[ratelimit.Class]ratelimit.Limit)
+	if os.Getenv("RATE_LIMIT_ENABLED") != "false" {
+		for class, def := range ratelimit.DefaultLimits {
+			spec := os.Getenv("RATE_LIMIT_" + strings.ToUpper(string(class)))
+			if spec == "" {
+				spec = def
+			}
+			limits[class], err = ratelimit.ParseLimit(spec)
+			if err != nil {
+				log.Fatalf("Invalid rate limit for %s: %v", class, err)
+			}
+		}
+	}
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), limits)
+	create := limiter.Middleware(ratelimit.ClassCreate)
+	read := limiter.Middleware(ratelimit.ClassRead)
+	execute := limiter.Middleware(ratelimit.ClassExecute)
+
+	// 协作编辑会话保存在本实例内存中
+	sessionOpts := []collab.Option{collab.WithMaxBytes(maxCodeBytes)}
+	if v := os.Getenv("SESSION_MAX"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid SESSION_MAX: %q", v)
+		}
+		sessionOpts = append(sessionOpts, collab.WithMaxSessions(n))
+	}
+	if v := os.Getenv("SESSION_IDLE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid SESSION_IDLE_TIMEOUT: %q", v)
+		}
+		sessionOpts = append(sessionOpts, collab.WithIdleTimeout(d))
+	}
+
 	// 创建 API 处理器
-	handler := api.NewHandler(storage)
+	opts := []api.Option{
+		api.WithIDGenerator(ids),
+		api.WithAccessSecret([]byte(os.Getenv("SHARE_ACCESS_SECRET"))),
+		api.WithRegistration(allowRegistration),
+		api.WithMaxCodeBytes(maxCodeBytes),
+		api.WithPublicURL(os.Getenv("PUBLIC_URL")),
+		api.WithWebhooks(dispatcher),
+		api.WithSessions(collab.NewManager(sessionOpts...)),
+		api.WithImporter(importer.New(
+			importer.WithFetcher(importer.KindGist, &importer.Gist{
+				BaseURL: os.Getenv("GIST_API_URL"),
+				Token:   os.Getenv("GITHUB_TOKEN"),
+			}),
+			importer.WithFetcher(importer.KindPlayground, &importer.Playground{
+				BaseURL: os.Getenv("PLAYGROUND_URL"),
+			}),
+		)),
+	}
+	if sessionTTL > 0 {
+		opts = append(opts, api.WithSessionTTL(sessionTTL))
+	}
+
+	// OpenID Connect 单点登录，配置了 OIDC_ISSUER 时启用
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		provider, err := oidc.New(oidc.Config{
+			Issuer:        issuer,
+			ClientID:      os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:   os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:        splitList(os.Getenv("OIDC_SCOPES")),
+			UsernameClaim: os.Getenv("OIDC_USERNAME_CLAIM"),
+			GroupsClaim:   os.Getenv("OIDC_GROUPS_CLAIM"),
+		})
+		if err != nil {
+			log.Fatalf("Invalid OIDC configuration: %v", err)
+		}
+		opts = append(opts, api.WithOIDC(provider, splitList(os.Getenv("OIDC_ALLOWED_GROUPS"))))
+		log.Printf("OIDC login enabled with issuer %s", provider.Issuer())
+	}
+	handler := api.NewHandler(storage, opts...)
 
 	// 注册路由
 	router.GET("/health", handler.HealthCheck)
-	router.POST("/api/share", handler.CreateShare)
-	router.GET("/api/share/:id", handler.GetShare)
-	router.POST("/api/share/:id/view", handler.IncrementViews)
-	router.POST("/api/execute", handler.ExecuteCode)
+	router.POST("/api/share", create, handler.CreateShare)
+	router.POST("/api/share/import", create, handler.ImportShare)
+	router.GET("/api/shares", read, handler.ListShares)
+	router.GET("/api/shares/trending", read, handler.TrendingShares)
+	router.GET("/api/share/:id", read, handler.GetShare)
+	router.POST("/api/share/:id/view", read, handler.IncrementViews)
+	router.GET("/api/share/:id/diff", read, handler.DiffShares)
+	router.POST("/api/share/:id/rerun", execute, handler.RerunShare)
+	router.POST("/api/share/:id/unlock", create, handler.UnlockShare)
+	router.GET("/api/share/:id/stats", read, handler.GetShareStats)
+	router.PUT("/api/share/:id/tags", create, handler.UpdateShareTags)
+	router.POST("/api/share/:id/report", create, handler.ReportShare)
+	router.GET("/api/share/:id/comments", read, handler.ListComments)
+	router.POST("/api/share/:id/comments", create, handler.CreateComment)
+	router.PUT("/api/share/:id/comments/:commentId", create, handler.UpdateComment)
+	router.DELETE("/api/share/:id/comments/:commentId", create, handler.DeleteComment)
+	router.PUT("/api/share/:id/star", requireUser, create, handler.StarShare)
+	router.DELETE("/api/share/:id/star", requireUser, create, handler.UnstarShare)
+	router.POST("/api/collection", create, handler.CreateCollection)
+	router.GET("/api/collection/:id", read, handler.GetCollection)
+	router.PUT("/api/collection/:id", create, handler.UpdateCollection)
+	router.DELETE("/api/collection/:id", create, handler.DeleteCollection)
+	router.POST("/api/execute", execute, handler.ExecuteCode)
+
+	// 分享代码下载，便于 curl 和 IDE 直接获取；链接预览使用的落地页和预览图
+	router.GET("/share/:id", read, handler.ShareFile)
+	router.GET("/share/:id/raw", read, handler.RawShare)
+	router.GET("/share/:id/og.png", read, handler.ShareImage)
+
+	// 嵌入页面和 oEmbed 发现接口
+	router.GET("/embed/:id", read, handler.EmbedShare)
+	router.GET("/api/oembed", read, handler.OEmbed)
+
+	// 账号和 API Key，登录和注册按写操作限流以防止暴力破解
+	router.GET("/api/auth/config", read, handler.AuthConfig)
+	router.POST("/api/auth/register", create, handler.Register)
+	router.POST("/api/auth/login", create, handler.Login)
+	router.GET("/api/auth/oidc/login", create, handler.OIDCLogin)
+	router.GET("/api/auth/oidc/callback", create, handler.OIDCCallback)
+	router.POST("/api/auth/logout", requireUser, handler.Logout)
+	router.GET("/api/auth/me", requireUser, read, handler.Me)
+	router.GET("/api/auth/keys", requireUser, read, handler.ListAPIKeys)
+	router.POST("/api/auth/keys", requireUser, create, handler.CreateAPIKey)
+	router.DELETE("/api/auth/keys/:id", requireUser, create, handler.DeleteAPIKey)
+	router.GET("/api/me/shares", requireUser, read, handler.MyShares)
+	router.POST("/api/sessions", create, handler.CreateSession)
+	router.GET("/api/sessions/:id", read, handler.GetSession)
+	router.GET("/api/sessions/:id/ws", read, handler.JoinSession)
+	router.POST("/api/sessions/:id/snapshot", create, handler.SnapshotSession)
+	router.GET("/api/webhooks", requireUser, read, handler.ListWebhooks)
+	router.POST("/api/webhooks", requireUser, create, handler.CreateWebhook)
+	router.GET("/api/webhooks/:id", requireUser, read, handler.GetWebhook)
+	router.PUT("/api/webhooks/:id", requireUser, create, handler.UpdateWebhook)
+	router.DELETE("/api/webhooks/:id", requireUser, create, handler.DeleteWebhook)
+	router.POST("/api/webhooks/:id/ping", requireUser, create, handler.PingWebhook)
+	router.GET("/api/webhooks/:id/deliveries", requireUser, read, handler.ListWebhookDeliveries)
+	router.POST("/api/webhooks/:id/deliveries/:deliveryId/redeliver", requireUser, create, handler.RedeliverWebhook)
+
+	// 审核和运维接口，仅管理员可用，与其他接口一样位于 /api 下，由前端 nginx 的 /api/admin 规则代理；
+	// 批量操作也可以使用 share-admin 命令直接操作存储
+	admin := router.Group("/api/admin", requireUser, auth.RequireAdmin())
+	admin.GET("/reports", handler.ListReportedShares)
+	admin.GET("/shares", handler.AdminListShares)
+	admin.GET("/shares/:id", handler.GetModeratedShare)
+	admin.GET("/shares/:id/stats", handler.AdminShareStats)
+	admin.PUT("/shares/:id/expiry", handler.SetShareExpiry)
+	admin.POST("/shares/:id/hide", handler.HideShare)
+	admin.POST("/shares/:id/unhide", handler.UnhideShare)
+	admin.POST("/shares/:id/dismiss", handler.DismissReports)
+	admin.DELETE("/shares/:id", handler.AdminDeleteShare)
+	admin.DELETE("/authors/:author/shares", handler.PurgeAuthor)
+	admin.GET("/bans", handler.ListBans)
+	admin.POST("/bans", handler.CreateBan)
+	admin.DELETE("/bans/:id", handler.DeleteBan)
 
 	// 启动服务器
 	srv := &http.Server{
@@ -88,3 +370,10 @@ func main() {
 
 	log.Println("Server exiting")
 }
+
+// splitList 解析以逗号或空格分隔的列表
+func splitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
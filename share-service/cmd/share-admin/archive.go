@@ -0,0 +1,190 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/playground/share-service/pkg/archive"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+var exportCmd = &command{
+	usage:   "[-format ndjson|tar] [-o file] [-author name] [-tag tag] [-since date] [-until date] [-include-expired]",
+	summary: "export shares as newline-delimited JSON or a tar of txtar files",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("export", flag.ExitOnError)
+		formatName := fs.String("format", "", "ndjson or tar (default from the output file name, otherwise ndjson)")
+		output := fs.String("o", "-", "output file, - for stdout; a .gz suffix compresses the output")
+		author := fs.String("author", "", "only shares by this author")
+		tag := fs.String("tag", "", "only shares with this tag")
+		since := fs.String("since", "", "only shares created at or after this date (RFC3339 or 2006-01-02)")
+		until := fs.String("until", "", "only shares created before this date")
+		includeExpired := fs.Bool("include-expired", false, "also export shares that have already expired")
+		fs.Parse(args)
+
+		format, err := outputFormat(*formatName, *output)
+		if err != nil {
+			return err
+		}
+		query := &models.ShareQuery{Author: *author, Tag: *tag, All: true}
+		if query.From, err = parseDate(*since); err != nil {
+			return fmt.Errorf("invalid -since: %v", err)
+		}
+		if query.To, err = parseDate(*until); err != nil {
+			return fmt.Errorf("invalid -until: %v", err)
+		}
+
+		// closers 按顺序关闭，先刷新压缩流再关闭文件
+		var out io.Writer = os.Stdout
+		var closers []io.Closer
+		if *output != "-" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+			if strings.HasSuffix(*output, ".gz") || strings.HasSuffix(*output, ".tgz") {
+				zw := gzip.NewWriter(f)
+				out = zw
+				closers = append(closers, zw)
+			}
+			closers = append(closers, f)
+		}
+
+		w, err := archive.NewWriter(out, format)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		exported, skipped := 0, 0
+		err = store.ExportShares(ctx, query, func(share *models.Share) error {
+			if !*includeExpired && share.ExpiresAt != nil && share.ExpiresAt.Before(now) {
+				skipped++
+				return nil
+			}
+			exported++
+			return w.Write(share)
+		})
+		if err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		for _, c := range closers {
+			if err := c.Close(); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "exported %d share(s)", exported)
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, ", skipped %d expired", skipped)
+		}
+		fmt.Fprintln(os.Stderr)
+		return nil
+	},
+}
+
+var importCmd = &command{
+	usage:   "[-format ndjson|tar] [-replace] [-dry-run] <file|->",
+	summary: "import shares from an export, keeping their IDs, timestamps and view counts",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("import", flag.ExitOnError)
+		formatName := fs.String("format", "", "ndjson or tar (default: detect from the content)")
+		replace := fs.Bool("replace", false, "overwrite shares that already exist instead of skipping them")
+		dryRun := fs.Bool("dry-run", false, "only read and validate the archive")
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			return errors.New("expected exactly one input file, or - for stdin")
+		}
+
+		var format archive.Format
+		if *formatName != "" {
+			var err error
+			if format, err = archive.ParseFormat(*formatName); err != nil {
+				return err
+			}
+		}
+
+		var in io.Reader = os.Stdin
+		if name := fs.Arg(0); name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		r, err := archive.NewReader(in, format)
+		if err != nil {
+			return err
+		}
+		imported, skipped := 0, 0
+		for {
+			share, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("after %d imported, %d skipped: %v", imported, skipped, err)
+			}
+			if *dryRun {
+				imported++
+				continue
+			}
+
+			written, err := store.ImportShare(ctx, share, *replace)
+			if err != nil {
+				return fmt.Errorf("%s: %v", share.ShareID, err)
+			}
+			if written {
+				imported++
+			} else {
+				skipped++
+			}
+		}
+
+		if *dryRun {
+			fmt.Fprintf(os.Stderr, "archive is valid, %d share(s) would be imported\n", imported)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "imported %d share(s), skipped %d already existing\n", imported, skipped)
+		return nil
+	},
+}
+
+// outputFormat 确定导出格式，未指定时根据输出文件名推断
+func outputFormat(name, output string) (archive.Format, error) {
+	if name != "" {
+		return archive.ParseFormat(name)
+	}
+	base := strings.TrimSuffix(output, ".gz")
+	if strings.HasSuffix(base, ".tar") || strings.HasSuffix(output, ".tgz") {
+		return archive.FormatTar, nil
+	}
+	return archive.FormatNDJSON, nil
+}
+
+// parseDate 解析 RFC3339 时间或 2006-01-02 格式的日期
+func parseDate(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
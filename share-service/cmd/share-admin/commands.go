@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const timeLayout = "2006-01-02 15:04"
+
+var listCmd = &command{
+	usage:   "[-author name] [-tag tag] [-sort recent|views|trending] [-limit n]",
+	summary: "list shares, including private, hidden and expired ones",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		author := fs.String("author", "", "only shares by this author")
+		tag := fs.String("tag", "", "only shares with this tag")
+		sort := fs.String("sort", string(models.SortRecent), "recent, views or trending")
+		limit := fs.Int("limit", 50, "maximum number of shares, 0 for all")
+		fs.Parse(args)
+
+		query := &models.ShareQuery{
+			Author: *author,
+			Tag:    *tag,
+			Sort:   models.ShareSort(*sort),
+			All:    true,
+		}
+		switch query.Sort {
+		case models.SortRecent, models.SortViews, models.SortTrending:
+		default:
+			return errors.New("sort must be recent, views or trending")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tAUTHOR\tVISIBILITY\tVIEWS\tCREATED\tEXPIRES\tFLAGS")
+		n := 0
+		err := eachShare(ctx, store, query, func(share *models.Share) bool {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				share.ShareID, share.Author, share.EffectiveVisibility(), share.Views,
+				share.CreatedAt.Local().Format(timeLayout), formatExpiry(share.ExpiresAt), shareFlags(share))
+			n++
+			return *limit == 0 || n < *limit
+		})
+		w.Flush()
+		return err
+	},
+}
+
+var showCmd = &command{
+	usage:   "<share-id>",
+	summary: "print a share's metadata as JSON",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expected exactly one share id")
+		}
+		share, err := getShare(ctx, store, args[0])
+		if err != nil {
+			return err
+		}
+		reports, err := store.ListReports(ctx, share.ShareID)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(&models.ModerationShareResponse{
+			Share:   models.NewModerationShareView(share),
+			Reports: reports,
+		})
+	},
+}
+
+var viewsCmd = &command{
+	usage:   "[-days n] <share-id>",
+	summary: "show total and daily view counts of a share",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("views", flag.ExitOnError)
+		days := fs.Int("days", 30, "number of days to show")
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			return errors.New("expected exactly one share id")
+		}
+		if *days < 1 {
+			return errors.New("days must be positive")
+		}
+
+		share, err := getShare(ctx, store, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		to := time.Now().UTC()
+		from := to.AddDate(0, 0, -(*days - 1))
+		daily, err := store.GetDailyViews(ctx, share.ShareID, from, to)
+		if err != nil {
+			return err
+		}
+		stats := models.NewShareStatsResponse(share, daily, from, to)
+
+		fmt.Printf("share %s: %d views in total", share.ShareID, share.Views)
+		if share.MaxViews > 0 {
+			fmt.Printf(" (limit %d)", share.MaxViews)
+		}
+		fmt.Printf("\n%s to %s: %d views, %d unique\n\n", stats.From, stats.To, stats.Total, stats.Unique)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tTOTAL\tUNIQUE\tEMBEDDED\tDIRECT")
+		for _, d := range stats.Series {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", d.Date, d.Total, d.Unique, d.Embedded, d.Direct)
+		}
+		w.Flush()
+
+		if len(stats.Referrers) > 0 {
+			fmt.Println("\nreferrers:")
+			for host, n := range stats.Referrers {
+				fmt.Printf("  %s\t%d\n", host, n)
+			}
+		}
+		return nil
+	},
+}
+
+var expiryCmd = &command{
+	usage:   "(-extend 72h | -at 2006-01-02T15:04:05Z | -never) <share-id>...",
+	summary: "extend, set or remove the expiry of shares",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("expiry", flag.ExitOnError)
+		extend := fs.Duration("extend", 0, "extend the current expiry by this duration")
+		at := fs.String("at", "", "set the expiry to this RFC3339 time")
+		never := fs.Bool("never", false, "make the shares never expire")
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return errors.New("expected at least one share id")
+		}
+
+		req := models.SetExpiryRequest{Never: *never}
+		if *extend != 0 {
+			req.ExtendBy = extend.String()
+		}
+		if *at != "" {
+			t, err := time.Parse(time.RFC3339, *at)
+			if err != nil {
+				return fmt.Errorf("invalid -at: %v", err)
+			}
+			req.ExpiresAt = &t
+		}
+
+		for _, id := range fs.Args() {
+			share, err := getShare(ctx, store, id)
+			if err != nil {
+				return err
+			}
+			expiresAt, err := req.Resolve(share.ExpiresAt, time.Now())
+			if err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+			if err := store.SetShareExpiry(ctx, id, expiresAt); err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+			fmt.Printf("%s: expiry %s -> %s\n", id, formatExpiry(share.ExpiresAt), formatExpiry(expiresAt))
+		}
+		return nil
+	},
+}
+
+var deleteCmd = &command{
+	usage:   "[-yes] <share-id>...",
+	summary: "permanently delete shares with their view statistics and reports",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("delete", flag.ExitOnError)
+		yes := fs.Bool("yes", false, "do not ask for confirmation")
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return errors.New("expected at least one share id")
+		}
+
+		if !*yes && !confirm(fmt.Sprintf("permanently delete %d share(s)?", fs.NArg())) {
+			return errors.New("aborted")
+		}
+		for _, id := range fs.Args() {
+			if err := store.DeleteShare(ctx, id); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return fmt.Errorf("%s: share not found", id)
+				}
+				return fmt.Errorf("%s: %v", id, err)
+			}
+			fmt.Printf("%s: deleted\n", id)
+		}
+		return nil
+	},
+}
+
+var purgeAuthorCmd = &command{
+	usage:   "[-yes] [-dry-run] <author>",
+	summary: "delete every share by an author",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("purge-author", flag.ExitOnError)
+		yes := fs.Bool("yes", false, "do not ask for confirmation")
+		dryRun := fs.Bool("dry-run", false, "only count the shares that would be deleted")
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			return errors.New("expected exactly one author")
+		}
+		author := fs.Arg(0)
+
+		n := 0
+		err := eachShare(ctx, store, &models.ShareQuery{Author: author, Sort: models.SortRecent, All: true}, func(*models.Share) bool {
+			n++
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Printf("no shares by %q\n", author)
+			return nil
+		}
+		if *dryRun {
+			fmt.Printf("would delete %d share(s) by %q\n", n, author)
+			return nil
+		}
+		if !*yes && !confirm(fmt.Sprintf("permanently delete %d share(s) by %q?", n, author)) {
+			return errors.New("aborted")
+		}
+
+		deleted, err := store.DeleteSharesByAuthor(ctx, author)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d share(s) by %q\n", deleted, author)
+		return nil
+	},
+}
+
+// eachShare 分页遍历符合条件的分享，fn 返回 false 时停止
+func eachShare(ctx context.Context, store storage.Storage, query *models.ShareQuery, fn func(*models.Share) bool) error {
+	query.Limit = 100
+	for {
+		shares, next, err := store.ListShares(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, share := range shares {
+			if !fn(share) {
+				return nil
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		query.Cursor = next
+	}
+}
+
+func getShare(ctx context.Context, store storage.Storage, id string) (*models.Share, error) {
+	share, err := store.GetShare(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil {
+		return nil, fmt.Errorf("%s: share not found", id)
+	}
+	return share, nil
+}
+
+func formatExpiry(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Local().Format(timeLayout)
+}
+
+func shareFlags(share *models.Share) string {
+	var flags []string
+	if share.Hidden {
+		flags = append(flags, "hidden")
+	}
+	if share.Exhausted {
+		flags = append(flags, "exhausted")
+	}
+	if share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()) {
+		flags = append(flags, "expired")
+	}
+	return strings.Join(flags, ",")
+}
+
+// confirm 在终端询问是否继续
+func confirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,127 @@
+// share-admin 是 share-service 的运维命令行工具。
+// 它直接通过 Storage 接口操作存储，不经过 HTTP 服务，适合批量清理、修改过期时间、
+// 查看访问统计等操作，可用于任何已注册的存储驱动。
+//
+// 用法：
+//
+//	share-admin [-driver mongo] [-dsn mongodb://localhost:27017/playground] <command> [flags] [args]
+//
+// 未指定 -dsn 时依次使用 STORAGE_DSN 环境变量，以及与服务相同的 MONGO_URI 和 MONGO_DB。
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/playground/share-service/pkg/storage"
+	"github.com/playground/share-service/pkg/storage/migrate"
+	"github.com/playground/share-service/pkg/storage/mongo"
+)
+
+// command 代表一个子命令
+type command struct {
+	usage   string // 参数说明
+	summary string // 一行简介
+	run     func(ctx context.Context, store storage.Storage, args []string) error
+}
+
+var commands = map[string]*command{
+	"list":         listCmd,
+	"show":         showCmd,
+	"views":        viewsCmd,
+	"expiry":       expiryCmd,
+	"delete":       deleteCmd,
+	"purge-author": purgeAuthorCmd,
+	"export":       exportCmd,
+	"import":       importCmd,
+	"migrate":      migrateCmd,
+	"trending":     trendingCmd,
+	"admin":        adminCmd,
+}
+
+func main() {
+	flag.Usage = usage
+	driver := flag.String("driver", envOr("STORAGE_DRIVER", "mongo"), "storage driver")
+	dsn := flag.String("dsn", defaultDSN(), "storage connection string")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "share-admin: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	store, err := storage.Open(ctx, *driver, *dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "share-admin: failed to open storage: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close(context.Background())
+
+	// 除迁移命令外，要求数据库结构与程序一致，避免在缺少索引或更新的结构上操作
+	if m, ok := store.(storage.Migratable); ok && name != "migrate" {
+		if err := m.Migrations().Check(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "share-admin: %v\n", err)
+			if errors.Is(err, migrate.ErrPending) {
+				fmt.Fprintf(os.Stderr, "run \"share-admin migrate up\" first\n")
+			}
+			store.Close(context.Background())
+			os.Exit(1)
+		}
+	}
+
+	if err := cmd.run(ctx, store, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "share-admin %s: %v\n", name, err)
+		store.Close(context.Background())
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: share-admin [flags] <command> [args]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n  %-13s   %s %s\n", name, cmd.summary, "", name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+// defaultDSN 返回默认连接串，兼容服务使用的 MONGO_URI 和 MONGO_DB
+func defaultDSN() string {
+	if dsn := os.Getenv("STORAGE_DSN"); dsn != "" {
+		return dsn
+	}
+	return mongo.DSN(envOr("MONGO_URI", "mongodb://localhost:27017"), envOr("MONGO_DB", mongo.DefaultDatabase))
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/playground/share-service/pkg/storage"
+	"github.com/playground/share-service/pkg/storage/migrate"
+)
+
+var migrateCmd = &command{
+	usage:   "status | up [-to version] | down -to version",
+	summary: "show, apply or revert storage schema migrations",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		m, ok := store.(storage.Migratable)
+		if !ok {
+			return errors.New("storage driver does not support migrations")
+		}
+		runner := m.Migrations()
+		if len(args) == 0 {
+			return errors.New("expected status, up or down")
+		}
+
+		fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+		to := fs.Int("to", -1, "target version")
+		fs.Parse(args[1:])
+
+		switch args[0] {
+		case "status":
+			return printMigrationStatus(ctx, runner)
+		case "up":
+			target := *to
+			if target < 0 {
+				target = 0
+			}
+			applied, err := runner.Up(ctx, target)
+			for _, step := range applied {
+				fmt.Printf("applied %d: %s\n", step.Version, step.Description)
+			}
+			if err == nil && len(applied) == 0 {
+				fmt.Println("schema is up to date")
+			}
+			return err
+		case "down":
+			// 回滚可能删除索引，必须明确指定目标版本
+			if *to < 0 {
+				return errors.New("down requires -to, e.g. -to 0 to revert every migration")
+			}
+			reverted, err := runner.Down(ctx, *to)
+			for _, step := range reverted {
+				fmt.Printf("reverted %d: %s\n", step.Version, step.Description)
+			}
+			if err == nil && len(reverted) == 0 {
+				fmt.Println("nothing to revert")
+			}
+			return err
+		}
+		return fmt.Errorf("unknown migrate command %q, expected status, up or down", args[0])
+	},
+}
+
+func printMigrationStatus(ctx context.Context, runner *migrate.Runner) error {
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := runner.Current(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version %d, latest known %d\n\n", current, runner.Latest())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED\tDESCRIPTION")
+	for _, st := range statuses {
+		state, appliedAt := "pending", ""
+		if st.Applied {
+			state = "applied"
+			appliedAt = st.AppliedAt.Local().Format(timeLayout)
+		}
+		if st.Unknown {
+			state = "unknown"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, state, appliedAt, st.Description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"github.com/playground/share-service/pkg/trending"
+)
+
+var trendingCmd = &command{
+	usage:   "[-refresh] [-limit n]",
+	summary: "show the trending feed, optionally recomputing scores first",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("trending", flag.ExitOnError)
+		refresh := fs.Bool("refresh", false, "recompute trending scores before listing")
+		limit := fs.Int("limit", 20, "maximum number of shares, 0 for all")
+		fs.Parse(args)
+
+		if *refresh {
+			n, err := trending.NewJob(store).Refresh(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("recomputed trending scores for %d share(s)\n", n)
+		}
+
+		// 与 /api/shares/trending 相同，只列出可公开列出的分享
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tAUTHOR\tSCORE\tSTARS\tVIEWS\tTITLE")
+		n := 0
+		err := eachShare(ctx, store, &models.ShareQuery{Sort: models.SortTrending}, func(share *models.Share) bool {
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%d\t%d\t%s\n",
+				share.ShareID, share.Author, share.Trending, share.Stars, share.Views, share.Title)
+			n++
+			return *limit == 0 || n < *limit
+		})
+		w.Flush()
+		return err
+	},
+}
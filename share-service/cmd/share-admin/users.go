@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+var adminCmd = &command{
+	usage:   "[-revoke] <username>",
+	summary: "grant or revoke a user's admin privileges",
+	run: func(ctx context.Context, store storage.Storage, args []string) error {
+		fs := flag.NewFlagSet("admin", flag.ExitOnError)
+		revoke := fs.Bool("revoke", false, "revoke admin privileges instead of granting them")
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			return errors.New("expected exactly one username")
+		}
+
+		username, err := auth.NormalizeUsername(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		user, err := store.GetUserByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("%s: user not found", username)
+		}
+		if err := store.SetUserAdmin(ctx, user.UserID, !*revoke); err != nil {
+			return err
+		}
+
+		// 输出 userId 和外部身份，便于确认修改的是预期的账号
+		identity := "local account"
+		if user.Identity != nil {
+			identity = fmt.Sprintf("issuer %s, subject %s", user.Identity.Issuer, user.Identity.Subject)
+		}
+		action := "granted admin privileges to"
+		if *revoke {
+			action = "revoked admin privileges from"
+		}
+		fmt.Printf("%s %s (userId %s, %s)\n", action, user.Username, user.UserID, identity)
+		return nil
+	},
+}
@@ -0,0 +1,78 @@
+// webhook-dev 是用于本地开发和联调的 Webhook 接收方。
+// 它校验每个请求的签名并打印事件内容，WEBHOOK_DEV_STATUS 可以指定返回的状态码，用于观察失败重试。
+// 仅用于本地调试，切勿在生产环境中使用。
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/playground/share-service/pkg/webhook"
+)
+
+// 请求体的最大字节数
+const maxBodyBytes = 1 << 20
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3005"
+	}
+	// 创建订阅时返回的签名密钥，留空时不校验签名
+	secret := os.Getenv("WEBHOOK_SECRET")
+	status := http.StatusNoContent
+	if v := os.Getenv("WEBHOOK_DEV_STATUS"); v != "" {
+		var err error
+		status, err = strconv.Atoi(v)
+		if err != nil || status < 200 || status > 599 {
+			log.Fatalf("Invalid WEBHOOK_DEV_STATUS: %q", v)
+		}
+	}
+	if secret == "" {
+		log.Printf("WEBHOOK_SECRET is not set, signatures will not be verified")
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		event := r.Header.Get(webhook.HeaderEvent)
+		delivery := r.Header.Get(webhook.HeaderDelivery)
+		if secret != "" {
+			err := webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), body, webhook.DefaultTolerance, time.Now())
+			if err != nil {
+				log.Printf("rejected %s delivery %s: %v", event, delivery, err)
+				code := http.StatusUnauthorized
+				if errors.Is(err, webhook.ErrSignatureExpired) {
+					code = http.StatusBadRequest
+				}
+				http.Error(w, err.Error(), code)
+				return
+			}
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("received %s delivery %s, responding %d\n%s", event, delivery, status, pretty.String())
+		w.WriteHeader(status)
+	})
+
+	log.Printf("Webhook dev receiver listening on :%s", port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
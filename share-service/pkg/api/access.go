@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// 访问密码分享时携带密码的请求头
+	passwordHeader = "X-Share-Password"
+	// 所有者携带密钥的请求头
+	ownerKeyHeader = "X-Owner-Key"
+	// 密码验证通过后访问 Cookie 的有效期
+	accessCookieTTL = time.Hour
+)
+
+// newOwnerKey 生成所有者密钥，返回明文和用于存储的哈希
+func newOwnerKey() (string, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key := hex.EncodeToString(buf)
+	return key, hashOwnerKey(key), nil
+}
+
+func hashOwnerKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// isOwner 判断请求者是否为分享的所有者：携带了所有者密钥，或是创建该分享的登录用户
+func isOwner(c *gin.Context, share *models.Share) bool {
+	return ownerKeyMatches(c, share.OwnerKeyHash) || isOwnerUser(c, share.OwnerID)
+}
+
+// isOwnerUser 判断当前登录用户是否为指定的所有者
+func isOwnerUser(c *gin.Context, ownerId string) bool {
+	user := auth.CurrentUser(c)
+	return user != nil && ownerId != "" && user.UserID == ownerId
+}
+
+// ownerKeyMatches 判断请求头中的所有者密钥是否与保存的哈希一致
+func ownerKeyMatches(c *gin.Context, keyHash string) bool {
+	key := c.GetHeader(ownerKeyHeader)
+	if key == "" || keyHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashOwnerKey(key)), []byte(keyHash)) == 1
+}
+
+// checkAccess 根据分享的可见性校验访问权限，无权访问时写入错误响应并返回 false
+func (h *Handler) checkAccess(c *gin.Context, share *models.Share) bool {
+	switch share.EffectiveVisibility() {
+	case models.VisibilityPublic, models.VisibilityUnlisted:
+		return true
+
+	case models.VisibilityPrivate:
+		if isOwner(c, share) {
+			return true
+		}
+		// 不暴露私有分享是否存在
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return false
+
+	case models.VisibilityPassword:
+		if isOwner(c, share) || h.hasAccessCookie(c, share) {
+			return true
+		}
+		if password := c.GetHeader(passwordHeader); password != "" {
+			if checkPassword(share, password) {
+				h.setAccessCookie(c, share)
+				return true
+			}
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid password"})
+			return false
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "password required",
+			"password_required": true,
+		})
+		return false
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+	return false
+}
+
+// UnlockShare 校验分享密码，成功后下发短期访问 Cookie
+func (h *Handler) UnlockShare(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	share, err := h.storage.GetShare(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return
+	}
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+	// 与 loadShare 相同，不可用的分享不校验密码，也不下发访问 Cookie
+	if !checkAvailable(c, share) {
+		return
+	}
+	if share.EffectiveVisibility() != models.VisibilityPassword {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+
+	if !checkPassword(share, req.Password) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid password"})
+		return
+	}
+
+	h.setAccessCookie(c, share)
+	c.JSON(http.StatusOK, gin.H{"shareId": share.ShareID, "expires_in": int(accessCookieTTL.Seconds())})
+}
+
+func checkPassword(share *models.Share, password string) bool {
+	return auth.CheckPassword(share.PasswordHash, password)
+}
+
+func accessCookieName(shareId string) string {
+	return fmt.Sprintf("access_%s", shareId)
+}
+
+// accessSignature 计算访问 Cookie 的签名，密码变更后旧 Cookie 自动失效
+func (h *Handler) accessSignature(share *models.Share, expires int64) string {
+	mac := hmac.New(sha256.New, h.accessSecret)
+	fmt.Fprintf(mac, "%s|%d|%s", share.ShareID, expires, share.PasswordHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Handler) setAccessCookie(c *gin.Context, share *models.Share) {
+	expires := time.Now().Add(accessCookieTTL).Unix()
+	value := fmt.Sprintf("%d.%s", expires, h.accessSignature(share, expires))
+	c.SetCookie(
+		accessCookieName(share.ShareID), // Cookie名称
+		value,                           // Cookie值
+		int(accessCookieTTL.Seconds()),  // 过期时间（秒）
+		"/",                             // 路径
+		"",                              // 域名
+		auth.IsSecureRequest(c),         // 仅HTTPS
+		true,                            // HTTP Only
+	)
+}
+
+func (h *Handler) hasAccessCookie(c *gin.Context, share *models.Share) bool {
+	value, err := c.Cookie(accessCookieName(share.ShareID))
+	if err != nil {
+		return false
+	}
+
+	expiresStr, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(h.accessSignature(share, expires)))
+}
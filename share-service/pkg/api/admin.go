@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// AdminListShares 列出全部分享，包括非公开、隐藏和已过期的分享，参数同 /api/shares
+// GET /api/admin/shares
+func (h *Handler) AdminListShares(c *gin.Context) {
+	query, err := parseShareQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.All = true
+
+	h.listShares(c, query)
+}
+
+// AdminShareStats 返回任意分享的访问统计，不受可见性和隐藏状态限制
+// GET /api/admin/shares/:id/stats[?days=30]
+func (h *Handler) AdminShareStats(c *gin.Context) {
+	days, ok := parseStatsDays(c)
+	if !ok {
+		return
+	}
+
+	share, err := h.storage.GetShare(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return
+	}
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+
+	h.respondStats(c, share, days, true)
+}
+
+// SetShareExpiry 修改分享的过期时间，可以指定新时间、在原有基础上延长或改为永不过期
+// PUT /api/admin/shares/:id/expiry
+func (h *Handler) SetShareExpiry(c *gin.Context) {
+	var req models.SetExpiryRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	share, err := h.storage.GetShare(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return
+	}
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+
+	expiresAt, err := req.Resolve(share.ExpiresAt, time.Now())
+	if err != nil {
+		respondInvalid(c, fieldErrors{{Field: "body", Message: err.Error()}})
+		return
+	}
+
+	err = h.storage.SetShareExpiry(ctx, share.ShareID, expiresAt)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update share"})
+		return
+	}
+	share.ExpiresAt = expiresAt
+	h.webhooks.Publish(models.EventShareUpdated, share)
+
+	c.JSON(http.StatusOK, gin.H{"shareId": share.ShareID, "expires_at": expiresAt})
+}
+
+// PurgeAuthor 删除指定作者的全部分享
+// DELETE /api/admin/authors/:author/shares
+func (h *Handler) PurgeAuthor(c *gin.Context) {
+	author := c.Param("author")
+	deleted, err := h.storage.DeleteSharesByAuthor(c.Request.Context(), author)
+	if err != nil {
+		fmt.Printf("failed to purge shares of %s: %v\n", author, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to purge shares"})
+		return
+	}
+	c.JSON(http.StatusOK, &models.PurgeResponse{Deleted: deleted})
+}
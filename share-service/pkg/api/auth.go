@@ -0,0 +1,228 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// 登录会话令牌的默认有效期
+const defaultSessionTTL = 30 * 24 * time.Hour
+
+// Register 处理注册本地账号请求，成功后直接返回会话令牌
+// POST /api/auth/register
+func (h *Handler) Register(c *gin.Context) {
+	if !h.allowRegistration {
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration is disabled"})
+		return
+	}
+
+	var req models.CredentialsRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	username, err := auth.NormalizeUsername(req.Username)
+	if err != nil {
+		errs.add("username", "%v", err)
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		errs.add("password", "%v", err)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	user, err := auth.NewUser(username, req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+	if err := h.storage.CreateUser(c.Request.Context(), user); err != nil {
+		if errors.Is(err, storage.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "username already taken"})
+			return
+		}
+		fmt.Printf("failed to create user: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+
+	h.respondWithSession(c, http.StatusCreated, user)
+}
+
+// Login 处理账号密码登录请求
+// POST /api/auth/login
+func (h *Handler) Login(c *gin.Context) {
+	var req models.CredentialsRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	username, err := auth.NormalizeUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
+		return
+	}
+
+	user, err := h.storage.GetUserByUsername(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+	if user == nil || !auth.CheckPassword(user.PasswordHash, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
+		return
+	}
+
+	h.respondWithSession(c, http.StatusOK, user)
+}
+
+// respondWithSession 为用户签发会话令牌并写入响应
+func (h *Handler) respondWithSession(c *gin.Context, status int, user *models.User) {
+	plain, token, err := h.issueSession(c, user)
+	if err != nil {
+		fmt.Printf("failed to create session: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
+	c.JSON(status, &models.LoginResponse{
+		Token:     plain,
+		ExpiresAt: token.ExpiresAt,
+		User:      user,
+	})
+}
+
+// issueSession 签发并保存会话令牌，同时记录登录时间
+func (h *Handler) issueSession(c *gin.Context, user *models.User) (string, *models.Token, error) {
+	plain, token, err := auth.NewToken(user.UserID, models.TokenSession, "", h.sessionTTL)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := h.storage.CreateToken(c.Request.Context(), token); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	if err := h.storage.TouchUserLogin(c.Request.Context(), user.UserID, now); err != nil {
+		fmt.Printf("failed to record login time: %v\n", err)
+	}
+	user.LastLoginAt = &now
+	return plain, token, nil
+}
+
+// Logout 注销当前会话令牌
+// POST /api/auth/logout
+func (h *Handler) Logout(c *gin.Context) {
+	token := auth.CurrentToken(c)
+	if token == nil || token.Kind != models.TokenSession {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "not logged in with a session token"})
+		return
+	}
+
+	if err := h.storage.DeleteToken(c.Request.Context(), token.UserID, token.TokenID, token.Kind); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+	auth.ClearSessionCookie(c)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Me 返回当前登录用户
+// GET /api/auth/me
+func (h *Handler) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.CurrentUser(c))
+}
+
+// ListAPIKeys 列出当前用户的 API Key
+// GET /api/auth/keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	tokens, err := h.storage.ListTokens(c.Request.Context(), user.UserID, models.TokenAPIKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+	if tokens == nil {
+		tokens = []*models.Token{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": tokens})
+}
+
+// CreateAPIKey 为当前用户创建长期 API Key，明文只在响应中返回一次
+// POST /api/auth/keys
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	errs.text("name", req.Name, maxNameLength, false)
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			errs.add("expires_in", "must be a positive duration such as 720h")
+		}
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	user := auth.CurrentUser(c)
+	plain, token, err := auth.NewToken(user.UserID, models.TokenAPIKey, req.Name, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+	if err := h.storage.CreateToken(c.Request.Context(), token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, &models.CreateAPIKeyResponse{Key: plain, Token: token})
+}
+
+// DeleteAPIKey 吊销当前用户的 API Key
+// DELETE /api/auth/keys/:id
+func (h *Handler) DeleteAPIKey(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	// 只删除 API Key，登录会话令牌不能通过该接口删除
+	if err := h.storage.DeleteToken(c.Request.Context(), user.UserID, c.Param("id"), models.TokenAPIKey); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete api key"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MyShares 列出当前用户的全部分享，包括非公开分享
+// GET /api/me/shares
+func (h *Handler) MyShares(c *gin.Context) {
+	query, err := parseShareQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.OwnerID = auth.CurrentUser(c).UserID
+
+	h.listShares(c, query)
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+)
+
+// 调用后端格式化服务的超时时间
+const backendFormatTimeout = 10 * time.Second
+
+var (
+	// errBackendUnavailable 表示无法连接后端执行服务
+	errBackendUnavailable = errors.New("backend unavailable")
+	// errBackendStatus 表示后端执行服务返回了非200状态码
+	errBackendStatus = errors.New("backend returned non-200 status")
+)
+
+// backendRateLimitError 表示后端执行服务拒绝了过于频繁的请求
+type backendRateLimitError struct {
+	RetryAfter string // 后端返回的 Retry-After 响应头
+}
+
+func (e *backendRateLimitError) Error() string {
+	return "backend rate limit exceeded"
+}
+
+type clientIPKey struct{}
+
+// backendContext 返回调用后端服务使用的上下文，其中携带发起请求的客户端 IP，
+// 后端据此按真实客户端限流，而不是把所有请求都算在 share-service 名下
+func backendContext(c *gin.Context) context.Context {
+	return context.WithValue(c.Request.Context(), clientIPKey{}, c.ClientIP())
+}
+
+// setForwardedFor 将上下文中的客户端 IP 写入转发给后端的请求
+func setForwardedFor(ctx context.Context, req *http.Request) {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok && ip != "" {
+		req.Header.Set("X-Forwarded-For", ip)
+	}
+}
+
+// backendEndpoint 返回指定版本后端服务的 API 地址
+func backendEndpoint(normalizedVersion, path string) string {
+	// 根据环境变量决定使用开发环境还是生产环境的后端服务
+	host := "backend-" + strings.ReplaceAll(normalizedVersion, ".", "")
+	if os.Getenv("GO_ENV") == "development" {
+		host += "-dev"
+	}
+	return fmt.Sprintf("http://%s:3001/api/%s", host, path)
+}
+
+// runOnBackend 将代码转发到对应版本的后端服务执行并转换为运行结果
+// 后端返回非200状态码时，返回描述该错误的结果以及 errBackendStatus
+func runOnBackend(ctx context.Context, code, normalizedVersion string) (*models.RunResult, error) {
+	backendURL := backendEndpoint(normalizedVersion, "run")
+	fmt.Printf("环境: %s, 使用后端服务: %s\n", os.Getenv("GO_ENV"), backendURL)
+
+	// 准备发送到后端的请求
+	backendReq, err := json.Marshal(map[string]interface{}{
+		"code":     code,
+		"version":  normalizedVersion,
+		"language": "go",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare backend request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, backendURL, bytes.NewBuffer(backendReq))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setForwardedFor(ctx, req)
+
+	// 调用后端执行服务
+	fmt.Printf("转发代码执行请求到后端服务: %s，版本: %s\n", backendURL, normalizedVersion)
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("调用后端服务失败: %v\n", err)
+		return nil, fmt.Errorf("%w: %v", errBackendUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	// 记录状态码
+	fmt.Printf("后端服务响应状态码: %d\n", resp.StatusCode)
+
+	// 读取响应内容（不管状态码如何）
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("读取后端服务响应失败: %v\n", err)
+		return nil, fmt.Errorf("failed to read backend response: %w", err)
+	}
+	fmt.Printf("后端服务响应内容: %s\n", string(respBody))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &backendRateLimitError{RetryAfter: resp.Header.Get("Retry-After")}
+	}
+
+	// 检查响应状态码，非200状态码视为错误
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("后端服务返回非200状态码: %d，响应内容: %s\n", resp.StatusCode, string(respBody))
+		result := &models.RunResult{
+			Output:    "",
+			Error:     fmt.Sprintf("后端服务错误: 状态码 %d, 响应: %s", resp.StatusCode, string(respBody)),
+			ExitCode:  1,
+			Duration:  0,
+			Memory:    0,
+			CreatedAt: time.Now().Unix(),
+		}
+		return result, errBackendStatus
+	}
+
+	// 解析后端响应
+	var backendResp map[string]interface{}
+	if err := json.Unmarshal(respBody, &backendResp); err != nil {
+		fmt.Printf("解析后端响应失败: %v, 响应内容: %s\n", err, string(respBody))
+		return nil, fmt.Errorf("failed to decode backend response: %w", err)
+	}
+
+	// 转换为我们的运行结果格式
+	output := ""
+	if out, ok := backendResp["output"].(string); ok {
+		output = out
+	}
+
+	errMsg := ""
+	if errOutput, ok := backendResp["error"].(string); ok {
+		errMsg = errOutput
+	}
+
+	exitCode := 0
+	if code, ok := backendResp["exitCode"].(float64); ok {
+		exitCode = int(code)
+	}
+
+	// 后端未返回运行时长时，使用本次请求的耗时
+	duration := time.Since(start).Milliseconds()
+	if dur, ok := backendResp["duration"].(float64); ok {
+		duration = int64(dur)
+	}
+
+	memory := int64(1024 * 1024)
+	if mem, ok := backendResp["memory"].(float64); ok {
+		memory = int64(mem)
+	}
+
+	goVersion := normalizedVersion
+	if v, ok := backendResp["goVersion"].(string); ok && v != "" {
+		goVersion = v
+	}
+
+	// 创建结果对象
+	result := &models.RunResult{
+		Output:    output,
+		Error:     errMsg,
+		ExitCode:  exitCode,
+		Duration:  duration,
+		Memory:    memory,
+		GoVersion: goVersion,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	fmt.Printf("代码执行结果: 退出码=%d, 输出长度=%d, 错误长度=%d\n",
+		exitCode, len(output), len(errMsg))
+
+	return result, nil
+}
+
+// formatCode 调用后端的 /api/format 对代码进行 gofmt 格式化
+func formatCode(ctx context.Context, code, version string) (string, error) {
+	normalizedVersion, ok := models.NormalizeVersion(version)
+	if !ok {
+		// 格式化结果与 Go 版本无关，未知版本时使用默认后端
+		normalizedVersion = "go1.24"
+	}
+
+	body, err := json.Marshal(map[string]string{"code": code})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backendFormatTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, backendEndpoint(normalizedVersion, "format"), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setForwardedFor(ctx, req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("backend unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("backend returned status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	var formatResp struct {
+		FormattedCode string `json:"formattedCode"`
+		Error         string `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &formatResp); err != nil {
+		return "", err
+	}
+	if formatResp.Error != "" {
+		return "", fmt.Errorf("%s", formatResp.Error)
+	}
+	return formatResp.FormattedCode, nil
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// 每个合集最多包含的分享数
+const maxCollectionShares = 200
+
+// CreateCollection 处理创建合集请求
+// POST /api/collection
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var req models.CreateCollectionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	errs.text("title", strings.TrimSpace(req.Title), maxTitleLength, false)
+	errs.text("description", req.Description, maxDescriptionLength, true)
+	errs.text("author", req.Author, maxAuthorLength, false)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	// 登录用户创建的合集归属该用户，作者名使用已验证的用户名
+	author, ownerID := req.Author, ""
+	if user := auth.CurrentUser(c); user != nil {
+		author, ownerID = user.Username, user.UserID
+	}
+	if h.checkBanned(c, author) {
+		return
+	}
+
+	shareIds, ok := h.validateCollectionShares(c, req.ShareIDs)
+	if !ok {
+		return
+	}
+
+	ownerKey, ownerKeyHash, err := newOwnerKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create collection"})
+		return
+	}
+
+	now := time.Now()
+	collection := &models.Collection{
+		Title:        strings.TrimSpace(req.Title),
+		Description:  req.Description,
+		Author:       author,
+		OwnerID:      ownerID,
+		ShareIDs:     shareIds,
+		OwnerKeyHash: ownerKeyHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := h.insertCollection(c.Request.Context(), collection); err != nil {
+		fmt.Printf("failed to create collection: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, &models.CreateCollectionResponse{
+		CollectionID: collection.CollectionID,
+		URL:          fmt.Sprintf("/collection/%s", collection.CollectionID),
+		OwnerKey:     ownerKey,
+	})
+}
+
+// GetCollection 处理获取合集请求，返回其中当前可访问的分享摘要
+// GET /api/collection/:id
+func (h *Handler) GetCollection(c *gin.Context) {
+	collection, ok := h.loadCollection(c)
+	if !ok {
+		return
+	}
+
+	shares, err := h.storage.GetSharesByIDs(c.Request.Context(), collection.ShareIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get collection"})
+		return
+	}
+
+	byID := make(map[string]*models.Share, len(shares))
+	for _, share := range shares {
+		byID[share.ShareID] = share
+	}
+
+	resp := &models.CollectionResponse{
+		CollectionID: collection.CollectionID,
+		URL:          fmt.Sprintf("/collection/%s", collection.CollectionID),
+		Title:        collection.Title,
+		Description:  collection.Description,
+		Author:       collection.Author,
+		Shares:       make([]models.ShareSummary, 0, len(collection.ShareIDs)),
+		CreatedAt:    collection.CreatedAt,
+		UpdatedAt:    collection.UpdatedAt,
+	}
+
+	// 按合集中的顺序返回，跳过已删除、已过期或非公开的分享
+	now := time.Now()
+	for _, id := range collection.ShareIDs {
+		share, ok := byID[id]
+		if !ok || !listableInCollection(share, now) {
+			continue
+		}
+		resp.Shares = append(resp.Shares, models.NewShareSummary(share))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateCollection 处理更新合集请求，仅所有者可操作
+// PUT /api/collection/:id
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	var req models.UpdateCollectionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			errs.add("title", "must not be empty")
+		} else {
+			errs.text("title", strings.TrimSpace(*req.Title), maxTitleLength, false)
+		}
+	}
+	if req.Description != nil {
+		errs.text("description", *req.Description, maxDescriptionLength, true)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	collection, ok := h.loadCollection(c)
+	if !ok {
+		return
+	}
+	if !ownsCollection(c, collection) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can update this collection"})
+		return
+	}
+
+	if req.Title != nil {
+		collection.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.ShareIDs != nil {
+		shareIds, ok := h.validateCollectionShares(c, *req.ShareIDs)
+		if !ok {
+			return
+		}
+		collection.ShareIDs = shareIds
+	}
+
+	if err := h.storage.UpdateCollection(c.Request.Context(), collection); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection 处理删除合集请求，仅所有者可操作，不会删除其中的分享
+// DELETE /api/collection/:id
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	collection, ok := h.loadCollection(c)
+	if !ok {
+		return
+	}
+	if !ownsCollection(c, collection) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can delete this collection"})
+		return
+	}
+
+	if err := h.storage.DeleteCollection(c.Request.Context(), collection.CollectionID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete collection"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownsCollection 判断请求者是否为合集的所有者
+func ownsCollection(c *gin.Context, collection *models.Collection) bool {
+	return ownerKeyMatches(c, collection.OwnerKeyHash) || isOwnerUser(c, collection.OwnerID)
+}
+
+// loadCollection 获取合集，失败时直接写入错误响应并返回 false
+func (h *Handler) loadCollection(c *gin.Context) (*models.Collection, bool) {
+	collection, err := h.storage.GetCollection(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get collection"})
+		return nil, false
+	}
+	if collection == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		return nil, false
+	}
+	return collection, true
+}
+
+// validateCollectionShares 去重并校验合集中的分享均存在且调用者可以在合集中展示，失败时写入错误响应
+func (h *Handler) validateCollectionShares(c *gin.Context, ids []string) ([]string, bool) {
+	seen := make(map[string]bool, len(ids))
+	shareIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		shareIds = append(shareIds, id)
+	}
+	if len(shareIds) > maxCollectionShares {
+		respondInvalid(c, fieldErrors{{Field: "shares", Message: fmt.Sprintf("must contain at most %d shares", maxCollectionShares)}})
+		return nil, false
+	}
+
+	shares, err := h.storage.GetSharesByIDs(c.Request.Context(), shareIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate shares"})
+		return nil, false
+	}
+	// 调用者无法在合集中看到的分享与不存在的分享返回相同的错误，避免借此探测私有分享是否存在
+	now := time.Now()
+	found := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		if listableInCollection(share, now) || isOwner(c, share) {
+			found[share.ShareID] = true
+		}
+	}
+	var missing []string
+	for _, id := range shareIds {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "validation failed",
+			"fields":  fieldErrors{{Field: "shares", Message: "shares not found: " + strings.Join(missing, ", ")}},
+			"missing": missing,
+		})
+		return nil, false
+	}
+
+	return shareIds, true
+}
+
+// insertCollection 为合集分配随机 ID 并保存，ID 冲突时自动重试
+func (h *Handler) insertCollection(ctx context.Context, collection *models.Collection) error {
+	var err error
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		collection.CollectionID, err = h.ids.Generate()
+		if err != nil {
+			return err
+		}
+		err = h.storage.CreateCollection(ctx, collection)
+		if !errors.Is(err, storage.ErrDuplicateID) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to allocate unique collection id after %d attempts", maxCreateAttempts)
+}
+
+// listableInCollection 判断分享是否可以在合集中展示
+// 私有、密码保护、限制访问次数以及被审核隐藏的分享不在合集中展示摘要
+func listableInCollection(share *models.Share, now time.Time) bool {
+	if share.Hidden {
+		return false
+	}
+	switch share.EffectiveVisibility() {
+	case models.VisibilityPublic, models.VisibilityUnlisted:
+	default:
+		return false
+	}
+	if share.Exhausted || share.MaxViews > 0 {
+		return false
+	}
+	return share.ExpiresAt == nil || share.ExpiresAt.After(now)
+}
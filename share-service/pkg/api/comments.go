@@ -0,0 +1,285 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	// 评论内容的最大字符数
+	maxCommentLength = 5000
+	// 每个分享最多保存的评论数，包括回复
+	maxCommentsPerShare = 500
+)
+
+// ListComments 返回分享的评论线程
+// GET /api/share/:id/comments
+func (h *Handler) ListComments(c *gin.Context) {
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	threads, count, err := h.commentThreads(c, share)
+	if err != nil {
+		fmt.Printf("failed to list comments: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list comments"})
+		return
+	}
+	noSharedCache(c, share)
+	c.JSON(http.StatusOK, &models.CommentsResponse{
+		ShareID: share.ShareID,
+		Count:   count,
+		Threads: threads,
+	})
+}
+
+// commentThreads 读取分享的评论并组织为线程，同时返回评论总数
+func (h *Handler) commentThreads(c *gin.Context, share *models.Share) ([]*models.CommentThread, int, error) {
+	comments, err := h.storage.ListComments(c.Request.Context(), share.ShareID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, comment := range comments {
+		comment.Verified = comment.OwnerID != ""
+	}
+	return models.NewCommentThreads(comments), len(comments), nil
+}
+
+// CreateComment 在分享下发表评论，可以针对代码行范围，也可以回复已有的讨论线程
+// 匿名评论返回评论者密钥，编辑和删除时通过 X-Owner-Key 请求头携带
+// POST /api/share/:id/comments
+func (h *Handler) CreateComment(c *gin.Context) {
+	var req models.CreateCommentRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	var errs fieldErrors
+	if body == "" {
+		errs.add("body", "must not be empty")
+	} else {
+		errs.text("body", body, maxCommentLength, true)
+	}
+	errs.text("author", req.Author, maxAuthorLength, false)
+	if req.ParentID != "" && req.Anchor != nil {
+		errs.add("anchor", "replies inherit the anchor of their thread")
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if req.Anchor != nil {
+		validateAnchor(&errs, share, req.Anchor)
+	}
+	if req.ParentID != "" {
+		parent, err := h.storage.GetComment(c.Request.Context(), req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create comment"})
+			return
+		}
+		if parent == nil || parent.ShareID != share.ShareID || parent.ParentID != "" {
+			errs.add("parent_id", "must be a top-level comment on this share")
+		}
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	author := req.Author
+	if user := auth.CurrentUser(c); user != nil {
+		author = user.Username
+	}
+	if h.checkBanned(c, author) {
+		return
+	}
+
+	count, err := h.storage.CountComments(c.Request.Context(), share.ShareID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create comment"})
+		return
+	}
+	if count >= maxCommentsPerShare {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("share already has %d comments", maxCommentsPerShare)})
+		return
+	}
+
+	comment := &models.Comment{
+		CommentID: uuid.New().String(),
+		ShareID:   share.ShareID,
+		ParentID:  req.ParentID,
+		Body:      body,
+		Author:    author,
+		Anchor:    req.Anchor,
+		CreatorIP: c.ClientIP(),
+		CreatedAt: time.Now(),
+	}
+
+	// 登录用户通过用户身份管理评论，匿名评论者使用密钥
+	resp := &models.CreateCommentResponse{Comment: comment}
+	if user := auth.CurrentUser(c); user != nil {
+		comment.OwnerID = user.UserID
+		comment.Verified = true
+	} else {
+		ownerKey, ownerKeyHash, err := newOwnerKey()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create comment"})
+			return
+		}
+		comment.OwnerKeyHash = ownerKeyHash
+		resp.OwnerKey = ownerKey
+	}
+
+	if err := h.storage.CreateComment(c.Request.Context(), comment); err != nil {
+		fmt.Printf("failed to create comment: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create comment"})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// validateAnchor 校验评论针对的文件和行号是否存在于分享代码中
+func validateAnchor(errs *fieldErrors, share *models.Share, anchor *models.LineAnchor) {
+	files := shareFiles(share)
+	if len(files) == 0 {
+		errs.add("anchor", "share has no code to comment on")
+		return
+	}
+
+	file := files[0]
+	if anchor.File != "" {
+		found := false
+		for _, f := range files {
+			if f.Name == anchor.File {
+				file, found = f, true
+				break
+			}
+		}
+		if !found {
+			errs.add("anchor.file", "must be one of the files in this share")
+			return
+		}
+	}
+
+	lines := strings.Count(strings.TrimSuffix(string(file.Data), "\n"), "\n") + 1
+	if anchor.Start < 1 || anchor.Start > lines {
+		errs.add("anchor.start", "must be between 1 and %d", lines)
+		return
+	}
+	// 省略结束行表示只针对单行
+	if anchor.End == 0 {
+		anchor.End = anchor.Start
+	}
+	if anchor.End < anchor.Start || anchor.End > lines {
+		errs.add("anchor.end", "must be between start and %d", lines)
+	}
+}
+
+// UpdateComment 编辑评论内容，仅评论者本人可操作
+// PUT /api/share/:id/comments/:commentId
+func (h *Handler) UpdateComment(c *gin.Context) {
+	var req models.UpdateCommentRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	var errs fieldErrors
+	if body == "" {
+		errs.add("body", "must not be empty")
+	} else {
+		errs.text("body", body, maxCommentLength, true)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	_, comment, ok := h.loadComment(c)
+	if !ok {
+		return
+	}
+	if !ownsComment(c, comment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit this comment"})
+		return
+	}
+
+	now := time.Now()
+	if err := h.storage.UpdateComment(c.Request.Context(), comment.CommentID, body, now); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update comment"})
+		return
+	}
+
+	comment.Body = body
+	comment.UpdatedAt = &now
+	comment.Verified = comment.OwnerID != ""
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment 删除评论，删除顶层评论时一并删除其回复
+// 评论者本人、分享所有者和管理员可以删除
+// DELETE /api/share/:id/comments/:commentId
+func (h *Handler) DeleteComment(c *gin.Context) {
+	share, comment, ok := h.loadComment(c)
+	if !ok {
+		return
+	}
+	if !ownsComment(c, comment) && !isOwner(c, share) && !auth.IsAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author or the share owner can delete this comment"})
+		return
+	}
+
+	if err := h.storage.DeleteComment(c.Request.Context(), comment.CommentID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete comment"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadComment 获取可访问的分享及其下的评论，失败时直接写入错误响应并返回 false
+func (h *Handler) loadComment(c *gin.Context) (*models.Share, *models.Comment, bool) {
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return nil, nil, false
+	}
+
+	comment, err := h.storage.GetComment(c.Request.Context(), c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get comment"})
+		return nil, nil, false
+	}
+	if comment == nil || comment.ShareID != share.ShareID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return nil, nil, false
+	}
+	return share, comment, true
+}
+
+// ownsComment 判断请求者是否为评论者本人：携带了评论者密钥，或是发表该评论的登录用户
+func ownsComment(c *gin.Context, comment *models.Comment) bool {
+	return ownerKeyMatches(c, comment.OwnerKeyHash) || isOwnerUser(c, comment.OwnerID)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/diff"
+	"github.com/playground/share-service/pkg/models"
+)
+
+// DiffShares 处理两个分享之间的差异比较请求
+// GET /api/share/:id/diff?against=:other[&format=true][&context=3]
+func (h *Handler) DiffShares(c *gin.Context) {
+	shareId := c.Param("id")
+	againstId := c.Query("against")
+	if againstId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "against is required"})
+		return
+	}
+
+	contextLines := diff.DefaultContext
+	if v := c.Query("context"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid context"})
+			return
+		}
+		contextLines = n
+	}
+	normalize, _ := strconv.ParseBool(c.Query("format"))
+
+	// against 作为旧版本，:id 作为新版本
+	from, ok := h.loadShare(c, againstId)
+	if !ok {
+		return
+	}
+	to, ok := h.loadShare(c, shareId)
+	if !ok {
+		return
+	}
+
+	fromSide := models.DiffSide{ShareID: from.ShareID, Version: from.Version, Title: from.Title}
+	toSide := models.DiffSide{ShareID: to.ShareID, Version: to.Version, Title: to.Title}
+	fromCode, toCode := from.Code, to.Code
+
+	if normalize {
+		fromCode = normalizeSide(backendContext(c), &fromSide, fromCode)
+		toCode = normalizeSide(backendContext(c), &toSide, toCode)
+	}
+
+	result, err := diff.Compute(fromCode, toCode, contextLines)
+	if err != nil {
+		// 目前只会返回 ErrTooComplex
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 比较会暴露代码内容，限制访问次数的分享同样需要消耗访问次数。
+	// 两个分享都读取成功并且比较完成后才计数，分享不存在或无法比较时不会消耗另一个分享的访问次数
+	if !h.consumeView(c, from) || (to.ShareID != from.ShareID && !h.consumeView(c, to)) {
+		return
+	}
+
+	resp := &models.DiffResponse{
+		From:       fromSide,
+		To:         toSide,
+		Identical:  result.Equal(),
+		Unified:    result.Unified(fmt.Sprintf("a/%s", from.ShareID), fmt.Sprintf("b/%s", to.ShareID)),
+		Hunks:      result.Hunks,
+		Insertions: result.Insertions,
+		Deletions:  result.Deletions,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// normalizeSide 使用 gofmt 规范化一侧的代码，失败时保留原始代码并记录原因
+func normalizeSide(ctx context.Context, side *models.DiffSide, code string) string {
+	formatted, err := formatCode(ctx, code, side.Version)
+	if err != nil {
+		fmt.Printf("格式化分享 %s 失败: %v\n", side.ShareID, err)
+		side.FormatError = err.Error()
+		return code
+	}
+	side.Formatted = true
+	return formatted
+}
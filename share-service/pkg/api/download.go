@@ -0,0 +1,164 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/txtar"
+)
+
+// progFile 是单文件分享以及多文件分享开头部分使用的文件名，与官方 Playground 一致
+const progFile = "prog.go"
+
+// RawShare 以纯文本返回分享代码
+// GET /share/:id/raw
+func (h *Handler) RawShare(c *gin.Context) {
+	share, ok := h.readShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	noSharedCache(c, share)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(share.Code))
+}
+
+// ShareFile 按扩展名返回分享的下载文件，gin 无法在同一路径段中匹配参数和后缀，因此在这里分发
+// 不带扩展名时返回链接预览使用的落地页
+// GET /share/:id、/share/:id.go、/share/:id.txtar、/share/:id.zip
+func (h *Handler) ShareFile(c *gin.Context) {
+	name := c.Param("id")
+	ext := path.Ext(name)
+	shareId := strings.TrimSuffix(name, ext)
+
+	var serve func(*gin.Context, *models.Share)
+	switch ext {
+	case ".go":
+		serve = serveGoFile
+	case ".txtar":
+		serve = serveTxtar
+	case ".zip":
+		serve = serveZip
+	case "":
+		h.shareLanding(c, shareId)
+		return
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "unsupported format, use .go, .txtar, .zip or /raw"})
+		return
+	}
+
+	share, ok := h.loadShare(c, shareId)
+	if !ok {
+		return
+	}
+	// 格式不可用时在计入访问之前拒绝，避免无效请求消耗限制访问次数的分享
+	if ext == ".go" && !isSingleGoFile(share) {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "share contains multiple files, download .txtar or .zip instead"})
+		return
+	}
+	if !h.countRead(c, share) {
+		return
+	}
+	noSharedCache(c, share)
+	serve(c, share)
+}
+
+// isSingleGoFile 判断分享是否只包含一个 Go 文件，只有这样的分享可以下载为 .go
+func isSingleGoFile(share *models.Share) bool {
+	files := shareFiles(share)
+	return len(files) == 1 && path.Ext(files[0].Name) == ".go"
+}
+
+func serveGoFile(c *gin.Context, share *models.Share) {
+	files := shareFiles(share)
+	setDisposition(c, "inline", share.ShareID+".go")
+	c.Data(http.StatusOK, "text/x-go; charset=utf-8", files[0].Data)
+}
+
+func serveTxtar(c *gin.Context, share *models.Share) {
+	setDisposition(c, "inline", share.ShareID+".txtar")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", txtar.Format(&txtar.Archive{Files: shareFiles(share)}))
+}
+
+// serveZip 返回包含分享全部文件的 zip，缺少 go.mod 时自动生成，解压后可以直接 go run .
+func serveZip(c *gin.Context, share *models.Share) {
+	files := shareFiles(share)
+	if !hasFile(files, "go.mod") {
+		files = append(files, txtar.File{Name: "go.mod", Data: goMod(share)})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     path.Join(share.ShareID, f.Name),
+			Method:   zip.Deflate,
+			Modified: share.CreatedAt,
+		})
+		if err == nil {
+			_, err = w.Write(f.Data)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build archive"})
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build archive"})
+		return
+	}
+
+	setDisposition(c, "attachment", share.ShareID+".zip")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// shareFiles 将分享代码拆分为文件：txtar 格式的多文件分享按文件展开，
+// 开头的非空内容与官方 Playground 一样视为 prog.go；其余分享整体作为 prog.go
+func shareFiles(share *models.Share) []txtar.File {
+	code := []byte(share.Code)
+	if !txtar.HasFiles(code) {
+		return []txtar.File{{Name: progFile, Data: code}}
+	}
+
+	a := txtar.Parse(code)
+	var files []txtar.File
+	if len(bytes.TrimSpace(a.Comment)) > 0 && !hasFile(a.Files, progFile) {
+		files = append(files, txtar.File{Name: progFile, Data: a.Comment})
+	}
+	for _, f := range a.Files {
+		// 忽略可能逃出目录的文件名
+		name := path.Clean(f.Name)
+		if path.IsAbs(name) || name == "." || strings.HasPrefix(name, "../") || name == ".." {
+			continue
+		}
+		files = append(files, txtar.File{Name: name, Data: f.Data})
+	}
+	return files
+}
+
+func hasFile(files []txtar.File, name string) bool {
+	for _, f := range files {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// goMod 生成与分享 Go 版本一致的 go.mod
+func goMod(share *models.Share) []byte {
+	mod := "module play\n"
+	if version, ok := models.NormalizeVersion(share.Version); ok {
+		mod += "\ngo " + strings.TrimPrefix(version, "go") + "\n"
+	}
+	return []byte(mod)
+}
+
+func setDisposition(c *gin.Context, disposition, filename string) {
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, filename))
+}
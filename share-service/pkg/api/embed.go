@@ -0,0 +1,357 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/highlight"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// 嵌入 iframe 的默认宽度和高度范围
+	defaultEmbedWidth = 640
+	minEmbedHeight    = 120
+	maxEmbedHeight    = 600
+	// 估算 iframe 高度时每行代码和页面框架占用的像素
+	embedLineHeight   = 20
+	embedChromeHeight = 96
+)
+
+// embedURLPattern 匹配 oEmbed 请求中可嵌入的分享页面地址
+var embedURLPattern = regexp.MustCompile(`^/(?:share|embed)/([^/.]+)/?$`)
+
+// embedFile 代表嵌入页面中的一个着色文件
+type embedFile struct {
+	Name  string
+	Lines []highlight.Line
+}
+
+// embedPage 是嵌入页面模板的数据
+type embedPage struct {
+	Share     *models.Share
+	Title     string
+	Files     []embedFile
+	ShareURL  string
+	OEmbedURL string
+	Theme     string
+	Run       bool
+	Version   string // 规范化后的 Go 版本，无法运行时为空
+	Nonce     string
+	Message   string // 无法嵌入时显示的错误信息
+}
+
+// embeddable 判断分享是否允许被嵌入：私有、密码保护和限制访问次数的分享不能嵌入
+func embeddable(share *models.Share) bool {
+	switch share.EffectiveVisibility() {
+	case models.VisibilityPublic, models.VisibilityUnlisted:
+		return share.MaxViews == 0
+	}
+	return false
+}
+
+// EmbedShare 返回可以放入 iframe 的自包含 HTML 页面，代码在服务端完成语法着色
+// GET /embed/:id[?run=1&theme=dark]
+func (h *Handler) EmbedShare(c *gin.Context) {
+	nonce, err := newNonce()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "internal error")
+		return
+	}
+	c.Header("Content-Security-Policy", fmt.Sprintf(
+		"default-src 'none'; style-src 'unsafe-inline'; script-src 'nonce-%s'; connect-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors *",
+		nonce,
+	))
+	c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
+
+	page := &embedPage{Nonce: nonce, Theme: "light"}
+	if c.Query("theme") == "dark" {
+		page.Theme = "dark"
+	}
+
+	share, status, message := h.embeddableShare(c, c.Param("id"))
+	if share == nil {
+		page.Message = message
+		renderEmbed(c, status, page)
+		return
+	}
+	h.countView(c, share, true)
+
+	base := h.baseURL(c)
+	page.Share = share
+	page.Title = share.Title
+	if page.Title == "" {
+		page.Title = share.ShareID
+	}
+	page.ShareURL = base + "/share/" + url.PathEscape(share.ShareID)
+	page.OEmbedURL = base + "/api/oembed?format=json&url=" + url.QueryEscape(page.ShareURL)
+	for _, f := range shareFiles(share) {
+		page.Files = append(page.Files, embedFile{Name: f.Name, Lines: highlight.Lines(string(f.Data))})
+	}
+	if version, ok := models.NormalizeVersion(share.Version); ok {
+		page.Version = version
+		page.Run, _ = strconv.ParseBool(c.Query("run"))
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	renderEmbed(c, http.StatusOK, page)
+}
+
+// embeddableShare 获取可嵌入的分享，失败时返回 nil 以及状态码和错误信息
+// 嵌入页面运行在第三方站点的 iframe 中，不能使用登录会话、访问 Cookie 或所有者密钥
+func (h *Handler) embeddableShare(c *gin.Context, shareId string) (*models.Share, int, string) {
+	share, err := h.storage.GetShare(c.Request.Context(), shareId)
+	if err != nil {
+		fmt.Printf("failed to get share for embed: %v\n", err)
+		return nil, http.StatusInternalServerError, "failed to get share"
+	}
+	switch {
+	case share == nil, share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()):
+		return nil, http.StatusNotFound, "share not found"
+	case share.Exhausted:
+		return nil, http.StatusGone, "share is no longer available"
+	case share.Hidden:
+		return nil, http.StatusUnavailableForLegalReasons, "share has been hidden by moderators"
+	case share.EffectiveVisibility() == models.VisibilityPrivate:
+		// 不暴露私有分享是否存在
+		return nil, http.StatusNotFound, "share not found"
+	case !embeddable(share):
+		return nil, http.StatusForbidden, "share cannot be embedded"
+	}
+	return share, 0, ""
+}
+
+func renderEmbed(c *gin.Context, status int, page *embedPage) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := embedTemplate.Execute(c.Writer, page); err != nil {
+		fmt.Printf("failed to render embed page: %v\n", err)
+	}
+}
+
+// OEmbed 实现 oEmbed 发现接口，返回嵌入分享所需的 iframe 代码
+// GET /api/oembed?url=...[&maxwidth=&maxheight=&format=json]
+func (h *Handler) OEmbed(c *gin.Context) {
+	if format := c.Query("format"); format != "" && format != "json" {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "only json format is supported"})
+		return
+	}
+
+	u, err := url.Parse(c.Query("url"))
+	if err != nil || c.Query("url") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		return
+	}
+	m := embedURLPattern.FindStringSubmatch(u.Path)
+	if m == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "url is not a share"})
+		return
+	}
+
+	maxWidth, ok := parseDimension(c, "maxwidth")
+	if !ok {
+		return
+	}
+	maxHeight, ok := parseDimension(c, "maxheight")
+	if !ok {
+		return
+	}
+
+	share, status, message := h.embeddableShare(c, m[1])
+	if share == nil {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	lines := 0
+	for _, f := range shareFiles(share) {
+		lines += strings.Count(string(f.Data), "\n") + 2
+	}
+	width := defaultEmbedWidth
+	if maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	height := embedChromeHeight + lines*embedLineHeight
+	if height < minEmbedHeight {
+		height = minEmbedHeight
+	}
+	if height > maxEmbedHeight {
+		height = maxEmbedHeight
+	}
+	if maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+
+	title := share.Title
+	if title == "" {
+		title = share.ShareID
+	}
+	base := h.baseURL(c)
+	src := base + "/embed/" + url.PathEscape(share.ShareID)
+	resp := &models.OEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        title,
+		AuthorName:   share.Author,
+		ProviderName: "Go Playground",
+		ProviderURL:  base + "/",
+		CacheAge:     3600,
+		Width:        width,
+		Height:       height,
+		HTML: fmt.Sprintf(
+			`<iframe src="%s" width="%d" height="%d" title="%s" style="border:0" loading="lazy" sandbox="allow-scripts allow-same-origin allow-popups"></iframe>`,
+			template.HTMLEscapeString(src), width, height, template.HTMLEscapeString(title),
+		),
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseDimension 解析 oEmbed 的尺寸参数，未设置时返回 0，无效时写入 400 响应并返回 false
+func parseDimension(c *gin.Context, name string) (int, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a positive integer"})
+		return 0, false
+	}
+	return n, true
+}
+
+// baseURL 返回服务对外的根地址，未配置时根据请求推断
+func (h *Handler) baseURL(c *gin.Context) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+var embedTemplate = template.Must(template.New("embed").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en" class="{{.Theme}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Share}}{{.Title}} - {{end}}Go Playground</title>
+{{- if .Share}}
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Title}}">
+{{- end}}
+<style>
+:root{--bg:#fff;--fg:#24292f;--muted:#6e7781;--border:#d0d7de;--bar:#f6f8fa;--kw:#cf222e;--str:#0a3069;--num:#0550ae;--com:#6e7781;--bi:#8250df;--btn:#00add8}
+.dark{--bg:#0d1117;--fg:#c9d1d9;--muted:#8b949e;--border:#30363d;--bar:#161b22;--kw:#ff7b72;--str:#a5d6ff;--num:#79c0ff;--com:#8b949e;--bi:#d2a8ff}
+*{box-sizing:border-box}
+html,body{margin:0;height:100%;background:var(--bg);color:var(--fg);font:14px/1.45 -apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif}
+body{display:flex;flex-direction:column;border:1px solid var(--border);border-radius:6px;overflow:hidden}
+header,footer{display:flex;align-items:center;gap:8px;padding:6px 12px;background:var(--bar);border-bottom:1px solid var(--border)}
+footer{border-bottom:0;border-top:1px solid var(--border)}
+header .title{font-weight:600;overflow:hidden;text-overflow:ellipsis;white-space:nowrap}
+.meta,.file{color:var(--muted);font-size:12px}
+.spacer{flex:1}
+a{color:inherit}
+main{flex:1;overflow:auto}
+.file{padding:4px 12px;border-bottom:1px solid var(--border);font-family:ui-monospace,SFMono-Regular,Menlo,Consolas,monospace}
+table{border-collapse:collapse;font:13px/20px ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;margin:6px 0}
+td{padding:0 12px;white-space:pre;vertical-align:top}
+td.ln{color:var(--muted);text-align:right;user-select:none;padding-right:8px}
+td.ln::before{content:attr(data-n)}
+.kw{color:var(--kw)}.str{color:var(--str)}.num{color:var(--num)}.com{color:var(--com);font-style:italic}.bi{color:var(--bi)}
+button{border:0;border-radius:4px;padding:4px 12px;background:var(--btn);color:#fff;font-weight:600;cursor:pointer}
+button:disabled{opacity:.6;cursor:default}
+#output{margin:0;padding:8px 12px;max-height:40%;overflow:auto;border-top:1px solid var(--border);font:13px/1.45 ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;white-space:pre-wrap}
+#output.error{color:var(--kw)}
+.message{margin:auto;padding:24px;color:var(--muted);text-align:center}
+</style>
+</head>
+<body>
+{{- if .Share}}
+<header>
+<span class="title">{{.Title}}</span>
+{{- with .Share.Author}}<span class="meta">by {{.}}</span>{{end}}
+<span class="spacer"></span>
+{{- with .Share.Version}}<span class="meta">{{.}}</span>{{end}}
+</header>
+<main>
+{{- $multi := gt (len .Files) 1}}
+{{- range .Files}}
+{{- if $multi}}<div class="file">{{.Name}}</div>{{end}}
+<table><tbody>
+{{- range $i, $line := .Lines}}
+<tr><td class="ln" data-n="{{$i | inc}}"></td><td>{{range $line}}{{with .Kind.Class}}<span class="{{.}}">{{end}}{{.Text}}{{if .Kind.Class}}</span>{{end}}{{end}}</td></tr>
+{{- end}}
+</tbody></table>
+{{- end}}
+</main>
+<pre id="output" hidden></pre>
+<footer>
+{{- if .Run}}<button id="run" type="button">Run</button>{{end}}
+<span class="spacer"></span>
+<a href="{{.ShareURL}}" target="_blank" rel="noopener">Open in Go Playground</a>
+</footer>
+{{- if .Run}}
+<script nonce="{{.Nonce}}">
+(function () {
+  var code = {{.Share.Code}}, version = {{.Version}};
+  var button = document.getElementById('run'), output = document.getElementById('output');
+  button.addEventListener('click', function () {
+    button.disabled = true;
+    output.hidden = false;
+    output.className = '';
+    output.textContent = 'Running...';
+    fetch('/api/execute', {
+      method: 'POST',
+      headers: {'Content-Type': 'application/json'},
+      body: JSON.stringify({code: code, version: version})
+    }).then(function (resp) {
+      return resp.json().then(function (data) {
+        var result = data.result;
+        if (!result) {
+          throw new Error(data.error || ('HTTP ' + resp.status));
+        }
+        var text = result.output || '';
+        if (result.error) {
+          text += (text ? '\n' : '') + result.error;
+          output.className = 'error';
+        }
+        output.textContent = text || 'Program exited.';
+      });
+    }).catch(function (err) {
+      output.className = 'error';
+      output.textContent = err.message;
+    }).then(function () {
+      button.disabled = false;
+    });
+  });
+})();
+</script>
+{{- end}}
+{{- else}}
+<p class="message">{{.Message}}</p>
+{{- end}}
+</body>
+</html>
+`))
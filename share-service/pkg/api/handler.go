@@ -1,33 +1,153 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
-	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
+	"crypto/rand"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/collab"
+	"github.com/playground/share-service/pkg/idgen"
+	"github.com/playground/share-service/pkg/importer"
 	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/ogimage"
+	"github.com/playground/share-service/pkg/oidc"
 	"github.com/playground/share-service/pkg/storage"
+	"github.com/playground/share-service/pkg/webhook"
 )
 
+// 随机 ID 冲突时的最大重试次数
+const maxCreateAttempts = 5
+
 type Handler struct {
-	storage storage.Storage
+	storage           storage.Storage
+	ids               *idgen.Generator
+	accessSecret      []byte         // 签名访问 Cookie 的密钥
+	sessionTTL        time.Duration  // 登录会话令牌的有效期
+	allowRegistration bool           // 是否允许注册本地账号
+	oidc              *oidc.Provider // 单点登录身份提供方，未配置时为 nil
+	oidcGroups        []string       // 允许通过单点登录的用户组，为空表示不限制
+	maxCodeBytes      int            // 代码的最大字节数
+	publicURL         string         // 服务对外的根地址，用于生成嵌入代码中的链接
+	previews          *ogimage.Cache // 按内容哈希缓存的链接预览图
+	importer          *importer.Importer
+	webhooks          *webhook.Dispatcher // 分享生命周期事件的 Webhook 分发
+	sessions          *collab.Manager     // 本实例中的协作编辑会话
+}
+
+// Option 用于配置 Handler
+type Option func(*Handler)
+
+// WithIDGenerator 设置分享 ID 生成器
+func WithIDGenerator(g *idgen.Generator) Option {
+	return func(h *Handler) {
+		h.ids = g
+	}
+}
+
+// WithAccessSecret 设置签名访问 Cookie 的密钥
+func WithAccessSecret(secret []byte) Option {
+	return func(h *Handler) {
+		h.accessSecret = secret
+	}
+}
+
+// WithSessionTTL 设置登录会话令牌的有效期
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.sessionTTL = ttl
+	}
+}
+
+// WithRegistration 设置是否允许注册本地账号
+func WithRegistration(allow bool) Option {
+	return func(h *Handler) {
+		h.allowRegistration = allow
+	}
+}
+
+// WithMaxCodeBytes 设置分享和运行代码的最大字节数
+func WithMaxCodeBytes(n int) Option {
+	return func(h *Handler) {
+		h.maxCodeBytes = n
+	}
+}
+
+// WithPublicURL 设置服务对外的根地址，未设置时根据请求的协议和 Host 推断
+func WithPublicURL(u string) Option {
+	return func(h *Handler) {
+		h.publicURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithImporter 设置从外部来源导入代码使用的 Importer
+func WithImporter(im *importer.Importer) Option {
+	return func(h *Handler) {
+		h.importer = im
+	}
+}
+
+// WithWebhooks 设置分发分享生命周期事件的 Dispatcher
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(h *Handler) {
+		h.webhooks = d
+	}
+}
+
+// WithSessions 设置管理协作编辑会话的 Manager
+func WithSessions(m *collab.Manager) Option {
+	return func(h *Handler) {
+		h.sessions = m
+	}
 }
 
-func NewHandler(storage storage.Storage) *Handler {
-	return &Handler{
-		storage: storage,
+// WithOIDC 启用 OpenID Connect 单点登录，groups 非空时只允许其中用户组的成员登录
+func WithOIDC(provider *oidc.Provider, groups []string) Option {
+	return func(h *Handler) {
+		h.oidc = provider
+		h.oidcGroups = groups
 	}
 }
 
+func NewHandler(storage storage.Storage, opts ...Option) *Handler {
+	h := &Handler{
+		storage:           storage,
+		sessionTTL:        defaultSessionTTL,
+		allowRegistration: true,
+		maxCodeBytes:      DefaultMaxCodeBytes,
+		previews:          ogimage.NewCache(defaultPreviewCacheSize),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.ids == nil {
+		h.ids, _ = idgen.New(idgen.DefaultLength, idgen.AlphabetBase62)
+	}
+	if h.importer == nil {
+		h.importer = importer.New()
+	}
+	if h.sessions == nil {
+		h.sessions = collab.NewManager(collab.WithMaxBytes(h.maxCodeBytes))
+	}
+	if h.webhooks == nil {
+		h.webhooks = webhook.NewDispatcher(storage, webhook.WithPublicURL(h.publicURL))
+	}
+	if len(h.accessSecret) == 0 {
+		// 未配置时使用随机密钥，重启后已下发的访问 Cookie 失效
+		h.accessSecret = make([]byte, 32)
+		rand.Read(h.accessSecret)
+	}
+	return h
+}
+
 // HealthCheck 处理健康检查请求
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -39,129 +159,411 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 // CreateShare 处理创建分享请求
 func (h *Handler) CreateShare(c *gin.Context) {
 	var req models.CreateShareRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !h.bindJSON(c, &req) {
 		return
 	}
+	h.createShare(c, &req, nil)
+}
+
+// createShare 校验请求并创建分享，origin 不为空时记录导入来源
+func (h *Handler) createShare(c *gin.Context, req *models.CreateShareRequest, origin *models.ShareOrigin) {
+	var errs fieldErrors
+	errs.code("code", req.Code, h.maxCodeBytes)
+	errs.text("version", req.Version, maxVersionLength, false)
+	errs.text("title", req.Title, maxTitleLength, false)
+	errs.text("description", req.Description, maxDescriptionLength, true)
+	errs.text("author", req.Author, maxAuthorLength, false)
+
+	// 校验自定义 ID
+	if req.Slug != "" {
+		if err := idgen.ValidateSlug(req.Slug); err != nil {
+			errs.add("slug", "%v", err)
+		}
+	}
 
-	// 生成唯一ID
-	shareId := uuid.New().String()[:8]
+	// 校验可见性
+	visibility := models.VisibilityPublic
+	if req.Visibility != "" {
+		visibility = models.Visibility(req.Visibility)
+		if !visibility.Valid() {
+			errs.add("visibility", "must be one of public, unlisted, private or password")
+		}
+	}
+	if visibility == models.VisibilityPassword && req.Password == "" {
+		errs.add("password", "is required for password-protected shares")
+	}
+	if len(req.Password) > auth.MaxPasswordLength {
+		errs.add("password", "must be at most %d bytes", auth.MaxPasswordLength)
+	}
+	if req.MaxViews < 0 {
+		errs.add("max_views", "must not be negative")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		errs.add("tags", "%v", err)
+	}
+
+	// 处理过期时间
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		expiresIn, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			errs.add("expires_in", "must be a positive duration such as 24h")
+		}
+	}
+
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	// 登录用户创建的分享归属该用户，作者名使用已验证的用户名
+	author, ownerID := req.Author, ""
+	if user := auth.CurrentUser(c); user != nil {
+		author, ownerID = user.Username, user.UserID
+	}
+	if h.checkBanned(c, author) {
+		return
+	}
 
 	// 创建分享对象
 	share := &models.Share{
-		ShareID:     shareId,
 		Code:        req.Code,
 		Language:    "go", // 目前只支持 Go
 		Version:     req.Version,
 		Title:       req.Title,
 		Description: req.Description,
-		Author:      req.Author,
+		Author:      author,
+		OwnerID:     ownerID,
 		CreatedAt:   time.Now(),
 		Views:       0,
+		ContentHash: models.ContentHash(req.Code, req.Version, "go"),
+		Visibility:  visibility,
+		MaxViews:    req.MaxViews,
+		Tags:        tags,
+		CreatorIP:   c.ClientIP(),
+		Origin:      origin,
 	}
 
-	// 处理过期时间
-	if req.ExpiresIn != "" {
-		duration, err := time.ParseDuration(req.ExpiresIn)
+	// 统一版本写法，便于按版本过滤
+	if normalized, ok := models.NormalizeVersion(share.Version); ok {
+		share.Version = normalized
+	}
+
+	if visibility == models.VisibilityPassword {
+		hash, err := auth.HashPassword(req.Password)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_in format"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create share"})
 			return
 		}
-		expiresAt := time.Now().Add(duration)
+		share.PasswordHash = hash
+	}
+
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
 		share.ExpiresAt = &expiresAt
 	}
 
+	// 匿名且永不过期的分享，内容相同时直接返回已有分享
+	if canDedupe(req, share) {
+		existing, err := h.storage.FindShareByHash(c.Request.Context(), share.ContentHash)
+		if err != nil {
+			fmt.Printf("failed to look up share by hash: %v\n", err)
+		} else if existing != nil && existing.Title == share.Title && existing.Description == share.Description {
+			c.JSON(http.StatusOK, &models.CreateShareResponse{
+				ShareID:  existing.ShareID,
+				URL:      fmt.Sprintf("/share/%s", existing.ShareID),
+				Existing: true,
+			})
+			return
+		}
+	}
+
+	// 生成所有者密钥，用于访问私有分享及后续管理操作
+	ownerKey, ownerKeyHash, err := newOwnerKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create share"})
+		return
+	}
+	share.OwnerKeyHash = ownerKeyHash
+
+	// 运行代码并保存结果快照，失败时不影响分享创建
+	if req.Run {
+		share.LastRun = snapshotRun(backendContext(c), share.Code, share.Version)
+	}
+
 	// 保存到存储
-	if err := h.storage.CreateShare(c.Request.Context(), share); err != nil {
+	if err := h.insertShare(c.Request.Context(), share, req.Slug); err != nil {
+		if errors.Is(err, storage.ErrDuplicateID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "slug is already taken"})
+			return
+		}
+		fmt.Printf("failed to create share: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create share"})
 		return
 	}
+	shareId := share.ShareID
+	h.webhooks.Publish(models.EventShareCreated, share)
 
 	// 构建响应
 	resp := &models.CreateShareResponse{
 		ShareID:   shareId,
 		URL:       fmt.Sprintf("/share/%s", shareId),
 		ExpiresAt: share.ExpiresAt,
+		OwnerKey:  ownerKey,
 	}
 
 	c.JSON(http.StatusCreated, resp)
 }
 
+// canDedupe 判断该分享是否允许复用已有的相同内容分享
+func canDedupe(req *models.CreateShareRequest, share *models.Share) bool {
+	return !req.NoDedupe && req.Slug == "" && share.Author == "" && share.ExpiresAt == nil &&
+		share.Visibility == models.VisibilityPublic && share.MaxViews == 0 && len(share.Tags) == 0 &&
+		share.Origin == nil
+}
+
+// insertShare 为分享分配 ID 并保存，随机 ID 冲突时自动重试
+func (h *Handler) insertShare(ctx context.Context, share *models.Share, slug string) error {
+	// 自定义 ID 不重试，冲突直接返回
+	if slug != "" {
+		share.ShareID = slug
+		return h.storage.CreateShare(ctx, share)
+	}
+
+	var err error
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		share.ShareID, err = h.ids.Generate()
+		if err != nil {
+			return err
+		}
+		err = h.storage.CreateShare(ctx, share)
+		if !errors.Is(err, storage.ErrDuplicateID) {
+			return err
+		}
+		fmt.Printf("分享 ID %s 冲突，重新生成 (第 %d 次)\n", share.ShareID, attempt+1)
+	}
+	return fmt.Errorf("failed to allocate unique share id after %d attempts", maxCreateAttempts)
+}
+
 // GetShare 处理获取分享请求
 func (h *Handler) GetShare(c *gin.Context) {
-	shareId := c.Param("id")
+	share, ok := h.readShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 构建响应
+	resp := &models.GetShareResponse{
+		Code:        share.Code,
+		Version:     share.Version,
+		Title:       share.Title,
+		Description: share.Description,
+		Author:      share.Author,
+		CreatedAt:   share.CreatedAt,
+		ExpiresAt:   share.ExpiresAt,
+		Views:       share.Views,
+		Stars:       share.Stars,
+		Starred:     h.hasStarred(c, share),
+		LastRun:     share.LastRun,
+		Visibility:  share.EffectiveVisibility(),
+		MaxViews:    share.MaxViews,
+		Tags:        share.Tags,
+		Verified:    share.OwnerID != "",
+		Origin:      share.Origin,
+	}
+
+	// 评论读取失败不影响获取分享内容
+	if threads, _, err := h.commentThreads(c, share); err != nil {
+		fmt.Printf("failed to list comments: %v\n", err)
+	} else {
+		resp.Comments = threads
+	}
+
+	noSharedCache(c, share)
+	c.JSON(http.StatusOK, resp)
+}
+
+// readShare 获取分享内容并计入访问，失败时直接写入错误响应并返回 false
+func (h *Handler) readShare(c *gin.Context, shareId string) (*models.Share, bool) {
+	share, ok := h.loadShare(c, shareId)
+	if !ok || !h.countRead(c, share) {
+		return nil, false
+	}
+	return share, true
+}
+
+// countRead 将一次内容读取计入访问，限制访问次数的分享次数已用尽时写入错误响应并返回 false
+func (h *Handler) countRead(c *gin.Context, share *models.Share) bool {
+	// 限制访问次数的分享每次读取都需要消耗一次访问
+	if share.MaxViews > 0 {
+		if !h.consumeView(c, share) {
+			return false
+		}
+		h.recordView(c, share, false)
+	} else {
+		// 同一访客当天只计数一次
+		h.countView(c, share, false)
+	}
+	return true
+}
 
-	// 从数据库获取分享
+// noSharedCache 禁止共享缓存保存受保护的分享
+func noSharedCache(c *gin.Context, share *models.Share) {
+	switch share.EffectiveVisibility() {
+	case models.VisibilityPrivate, models.VisibilityPassword:
+		c.Header("Cache-Control", "private, no-store")
+		return
+	}
+	if share.MaxViews > 0 {
+		c.Header("Cache-Control", "private, no-store")
+	}
+}
+
+// loadShare 获取可访问的分享，失败时直接写入错误响应并返回 false
+func (h *Handler) loadShare(c *gin.Context, shareId string) (*models.Share, bool) {
 	share, err := h.storage.GetShare(c.Request.Context(), shareId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
-		return
+		return nil, false
 	}
 	if share == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
-		return
+		return nil, false
+	}
+	if !checkAvailable(c, share) {
+		return nil, false
+	}
+
+	// 检查可见性
+	if !h.checkAccess(c, share) {
+		return nil, false
 	}
 
+	return share, true
+}
+
+// checkAvailable 检查分享是否已过期、访问次数已用尽或被审核隐藏，不可用时写入错误响应并返回 false
+func checkAvailable(c *gin.Context, share *models.Share) bool {
 	// 检查是否过期
 	if share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "share has expired"})
-		return
+		return false
 	}
 
-	// 检查请求头中的Cookie，看是否已经访问过
-	viewedCookie, err := c.Cookie(fmt.Sprintf("viewed_%s", shareId))
-	alreadyViewed := err == nil && viewedCookie == "true"
+	// 检查访问次数是否已用尽
+	if share.Exhausted {
+		c.JSON(http.StatusGone, gin.H{"error": "share is no longer available"})
+		return false
+	}
 
-	// 仅当未被此客户端查看时才增加计数
-	if !alreadyViewed {
-		updatedViews, err := h.storage.IncrementViews(c.Request.Context(), shareId)
-		if err != nil {
-			fmt.Printf("failed to increment views: %v\n", err)
-			// 继续处理请求，即使计数失败也不影响获取分享内容
-		} else {
-			// 增加计数成功，更新计数
-			share.Views = updatedViews
-
-			// 设置Cookie标记已访问，有效期24小时
-			c.SetCookie(
-				fmt.Sprintf("viewed_%s", shareId), // Cookie名称
-				"true",                            // Cookie值
-				86400,                             // 过期时间（秒）
-				"/",                               // 路径
-				"",                                // 域名
-				false,                             // 仅HTTPS
-				true,                              // HTTP Only
-			)
-		}
+	// 被审核隐藏的分享只有管理员可以查看
+	if share.Hidden && !auth.IsAdmin(c) {
+		c.JSON(http.StatusUnavailableForLegalReasons, gin.H{"error": "share has been hidden by moderators"})
+		return false
 	}
 
-	// 构建响应
-	resp := &models.GetShareResponse{
-		Code:        share.Code,
-		Version:     share.Version,
-		Title:       share.Title,
-		Description: share.Description,
-		Author:      share.Author,
-		CreatedAt:   share.CreatedAt,
-		ExpiresAt:   share.ExpiresAt,
-		Views:       share.Views,
+	return true
+}
+
+// consumeView 为限制访问次数的分享消耗一次访问，次数已用尽时写入 410 响应并返回 false
+// 所有者访问不消耗次数
+func (h *Handler) consumeView(c *gin.Context, share *models.Share) bool {
+	if share.MaxViews == 0 || isOwner(c, share) {
+		return true
 	}
 
-	c.JSON(http.StatusOK, resp)
+	views, err := h.storage.IncrementViews(c.Request.Context(), share.ShareID)
+	switch {
+	case errors.Is(err, storage.ErrViewLimitReached):
+		c.JSON(http.StatusGone, gin.H{"error": "share is no longer available"})
+		return false
+	case errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return false
+	}
+
+	share.Views = views
+	h.webhooks.Publish(models.EventShareViewed, share)
+	return true
 }
 
 // IncrementViews 处理手动增加访问次数请求
+// 与 GetShare 使用相同的访客去重，同一访客当天重复调用不会增加计数
 func (h *Handler) IncrementViews(c *gin.Context) {
-	shareId := c.Param("id")
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 限制访问次数的分享仅在读取内容时计数，避免额外的计数请求消耗访问次数
+	if share.MaxViews == 0 {
+		h.countView(c, share, false)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"views": share.Views})
+}
+
+// RerunShare 使用当前后端重新运行分享的代码并刷新运行结果快照
+// 运行结果对所有访客可见，只有所有者可以刷新
+func (h *Handler) RerunShare(c *gin.Context) {
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !isOwner(c, share) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can rerun the share"})
+		return
+	}
+
+	normalizedVersion, ok := models.NormalizeVersion(share.Version)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported version"})
+		return
+	}
 
-	// 增加存储中的访问次数
-	updatedViews, err := h.storage.IncrementViews(c.Request.Context(), shareId)
+	result, err := runOnBackend(backendContext(c), share.Code, normalizedVersion)
+	if rateLimited(c, err) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to increment views"})
+		fmt.Printf("重新运行分享 %s 失败: %v\n", share.ShareID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "backend service error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"views": updatedViews})
+	if err := h.storage.UpdateRunResult(c.Request.Context(), share.ShareID, result); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save run result"})
+		return
+	}
+	share.LastRun = result
+	h.webhooks.Publish(models.EventShareUpdated, share)
+
+	c.JSON(http.StatusOK, gin.H{
+		"shareId":  share.ShareID,
+		"last_run": result,
+	})
+}
+
+// snapshotRun 运行代码并返回结果快照，无法运行时返回 nil
+func snapshotRun(ctx context.Context, code, version string) *models.RunResult {
+	normalizedVersion, ok := models.NormalizeVersion(version)
+	if !ok {
+		return nil
+	}
+	result, err := runOnBackend(ctx, code, normalizedVersion)
+	if err != nil {
+		fmt.Printf("保存运行结果快照失败: %v\n", err)
+		return nil
+	}
+	return result
 }
 
 // ExecuteCode 处理代码执行请求
@@ -170,8 +572,14 @@ func (h *Handler) ExecuteCode(c *gin.Context) {
 		Code    string `json:"code" binding:"required"`
 		Version string `json:"version" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	errs.code("code", req.Code, h.maxCodeBytes)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
 		return
 	}
 
@@ -181,17 +589,8 @@ func (h *Handler) ExecuteCode(c *gin.Context) {
 	taskID := uuid.New().String()
 
 	// 验证版本格式，确保版本格式正确
-	var normalizedVersion string
-	switch req.Version {
-	case "go1.25", "1.25", "go1.25.0", "1.25.0":
-		normalizedVersion = "go1.25"
-	case "go1.22", "1.22", "go1.22.0", "1.22.0":
-		normalizedVersion = "go1.22"
-	case "go1.23", "1.23", "go1.23.0", "1.23.0":
-		normalizedVersion = "go1.23"
-	case "go1.24", "1.24", "go1.24.0", "1.24.0":
-		normalizedVersion = "go1.24"
-	default:
+	normalizedVersion, ok := models.NormalizeVersion(req.Version)
+	if !ok {
 		fmt.Printf("不支持的 Go 版本: %s\n", req.Version)
 
 		// 返回错误响应
@@ -212,56 +611,13 @@ func (h *Handler) ExecuteCode(c *gin.Context) {
 		return
 	}
 
-	// 构建请求转发到后端执行服务
-	var backendURL string
-	// 根据环境变量决定使用开发环境还是生产环境的后端服务
-	isDevEnv := os.Getenv("GO_ENV") == "development"
-
-	switch normalizedVersion {
-	case "go1.25":
-		if isDevEnv {
-			backendURL = "http://backend-go125-dev:3001/api/run"
-		} else {
-			backendURL = "http://backend-go125:3001/api/run"
-		}
-	case "go1.22":
-		if isDevEnv {
-			backendURL = "http://backend-go122-dev:3001/api/run"
-		} else {
-			backendURL = "http://backend-go122:3001/api/run"
-		}
-	case "go1.23":
-		if isDevEnv {
-			backendURL = "http://backend-go123-dev:3001/api/run"
-		} else {
-			backendURL = "http://backend-go123:3001/api/run"
-		}
-	case "go1.24":
-		if isDevEnv {
-			backendURL = "http://backend-go124-dev:3001/api/run"
-		} else {
-			backendURL = "http://backend-go124:3001/api/run"
-		}
-	}
-
-	fmt.Printf("环境: %s, 使用后端服务: %s\n", os.Getenv("GO_ENV"), backendURL)
-
-	// 准备发送到后端的请求
-	backendReq, err := json.Marshal(map[string]interface{}{
-		"code":     req.Code,
-		"version":  normalizedVersion,
-		"language": "go",
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to prepare backend request"})
+	// 转发到后端执行服务
+	result, err := runOnBackend(backendContext(c), req.Code, normalizedVersion)
+	if rateLimited(c, err) {
 		return
 	}
-
-	// 调用后端执行服务
-	fmt.Printf("转发代码执行请求到后端服务: %s，版本: %s\n", backendURL, normalizedVersion)
-	resp, err := http.Post(backendURL, "application/json", bytes.NewBuffer(backendReq))
-	if err != nil {
-		fmt.Printf("调用后端服务失败: %v\n", err)
+	switch {
+	case errors.Is(err, errBackendUnavailable):
 		// 后端服务不可用，返回模拟结果以便测试
 		mockResult := &models.RunResult{
 			Output:    "Hello, World! (mock result - backend service unavailable)",
@@ -278,97 +634,33 @@ func (h *Handler) ExecuteCode(c *gin.Context) {
 			"mocked":  true,
 		})
 		return
-	}
-	defer resp.Body.Close()
-
-	// 记录状态码
-	fmt.Printf("后端服务响应状态码: %d\n", resp.StatusCode)
-
-	// 读取响应内容（不管状态码如何）
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("读取后端服务响应失败: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read backend response"})
-		return
-	}
-	fmt.Printf("后端服务响应内容: %s\n", string(respBody))
-
-	// 检查响应状态码，非200状态码视为错误
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("后端服务返回非200状态码: %d，响应内容: %s\n", resp.StatusCode, string(respBody))
-		result := &models.RunResult{
-			Output:    "",
-			Error:     fmt.Sprintf("后端服务错误: 状态码 %d, 响应: %s", resp.StatusCode, string(respBody)),
-			ExitCode:  1,
-			Duration:  0,
-			Memory:    0,
-			CreatedAt: time.Now().Unix(),
-		}
-
+	case errors.Is(err, errBackendStatus):
 		c.JSON(http.StatusOK, gin.H{
 			"task_id": taskID,
 			"result":  result,
 			"error":   "Backend service error",
 		})
 		return
-	}
-
-	// 解析后端响应
-	var backendResp map[string]interface{}
-	if err := json.Unmarshal(respBody, &backendResp); err != nil {
-		fmt.Printf("解析后端响应失败: %v, 响应内容: %s\n", err, string(respBody))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode backend response"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process backend response"})
 		return
 	}
 
-	// 转换为我们的运行结果格式
-	output := ""
-	if out, ok := backendResp["output"].(string); ok {
-		output = out
-	}
-
-	errMsg := ""
-	if errOutput, ok := backendResp["error"].(string); ok {
-		errMsg = errOutput
-	}
-
-	exitCode := 0
-	if code, ok := backendResp["exitCode"].(float64); ok {
-		exitCode = int(code)
-	}
-
-	duration := int64(100)
-	if dur, ok := backendResp["duration"].(float64); ok {
-		duration = int64(dur)
-	}
-
-	memory := int64(1024 * 1024)
-	if mem, ok := backendResp["memory"].(float64); ok {
-		memory = int64(mem)
-	}
-
-	// 创建结果对象
-	result := &models.RunResult{
-		Output:    output,
-		Error:     errMsg,
-		ExitCode:  exitCode,
-		Duration:  duration,
-		Memory:    memory,
-		CreatedAt: time.Now().Unix(),
-	}
-
-	fmt.Printf("代码执行结果: 退出码=%d, 输出长度=%d, 错误长度=%d\n",
-		exitCode, len(output), len(errMsg))
-
 	c.JSON(http.StatusOK, gin.H{
 		"task_id": taskID,
 		"result":  result,
 	})
 }
 
-// 计算代码的哈希值，用于缓存键
-func calculateHash(code string) string {
-	h := sha256.New()
-	h.Write([]byte(code))
-	return hex.EncodeToString(h.Sum(nil))
+// rateLimited 在后端执行服务限流时返回 429 并透传 Retry-After
+func rateLimited(c *gin.Context, err error) bool {
+	var rl *backendRateLimitError
+	if !errors.As(err, &rl) {
+		return false
+	}
+	if rl.RetryAfter != "" {
+		c.Header("Retry-After", rl.RetryAfter)
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+	return true
 }
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/importer"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// 导入地址的最大字符数
+	maxSourceLength = 512
+	// 从外部来源获取代码片段的超时时间
+	importTimeout = 15 * time.Second
+)
+
+// ImportShare 从 GitHub Gist 或官方 Playground 导入代码并创建分享，分享记录导入来源
+// POST /api/share/import
+func (h *Handler) ImportShare(c *gin.Context) {
+	var req models.ImportShareRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	errs.text("source", req.Source, maxSourceLength, false)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), importTimeout)
+	defer cancel()
+	ref, snippet, err := h.importer.Import(ctx, req.Source)
+	switch {
+	case err == nil:
+	case errors.Is(err, importer.ErrUnsupportedSource), errors.Is(err, importer.ErrEmpty):
+		errs.add("source", "%v", err)
+		respondInvalid(c, errs)
+		return
+	case errors.Is(err, importer.ErrTooLarge):
+		// 导入时的限制与分享代码的限制不同，获取后的代码仍按 maxCodeBytes 校验
+		errs.add("source", "snippet must be at most %d bytes", importer.MaxSnippetBytes)
+		respondInvalid(c, errs)
+		return
+	case errors.Is(err, importer.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "snippet not found"})
+		return
+	default:
+		fmt.Printf("failed to import %s: %v\n", req.Source, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch snippet"})
+		return
+	}
+
+	errs.code("source", snippet.Code, h.maxCodeBytes)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	create := req.CreateRequest(snippet.Code)
+	if create.Description == "" {
+		create.Description = truncateRunes(snippet.Description, maxDescriptionLength)
+	}
+	origin := &models.ShareOrigin{
+		Kind:       string(ref.Kind),
+		ID:         ref.ID,
+		URL:        snippet.URL,
+		Revision:   snippet.Revision,
+		Owner:      snippet.Owner,
+		ImportedAt: time.Now(),
+	}
+	h.createShare(c, create, origin)
+}
+
+// truncateRunes 截断超过 max 个字符的文本
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+	maxSearchLength  = 200
+)
+
+// ListShares 处理分享列表和搜索请求
+// GET /api/shares?author=&tag=&version=&from=&to=&q=&sort=recent|views|trending&cursor=&limit=
+func (h *Handler) ListShares(c *gin.Context) {
+	query, err := parseShareQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.listShares(c, query)
+}
+
+// TrendingShares 返回热门分享，按后台任务根据近期收藏和访问计算的热度排序，等同于 sort=trending
+// GET /api/shares/trending?author=&tag=&version=&cursor=&limit=
+func (h *Handler) TrendingShares(c *gin.Context) {
+	query, err := parseShareQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Sort = models.SortTrending
+
+	h.listShares(c, query)
+}
+
+// listShares 执行列表查询并写入响应
+func (h *Handler) listShares(c *gin.Context, query *models.ShareQuery) {
+	shares, next, err := h.storage.ListShares(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list shares"})
+		return
+	}
+
+	resp := &models.ListSharesResponse{
+		Shares:     make([]models.ShareSummary, 0, len(shares)),
+		NextCursor: next,
+	}
+	for _, share := range shares {
+		resp.Shares = append(resp.Shares, models.NewShareSummary(share))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseShareQuery 解析并校验列表查询参数
+func parseShareQuery(c *gin.Context) (*models.ShareQuery, error) {
+	query := &models.ShareQuery{
+		Author: c.Query("author"),
+		Tag:    c.Query("tag"),
+		Search: c.Query("q"),
+		Sort:   models.ShareSort(c.DefaultQuery("sort", string(models.SortRecent))),
+		Cursor: c.Query("cursor"),
+		Limit:  defaultListLimit,
+	}
+
+	switch query.Sort {
+	case models.SortRecent, models.SortViews, models.SortTrending:
+	default:
+		return nil, errors.New("sort must be recent, views or trending")
+	}
+	if len(query.Search) > maxSearchLength {
+		return nil, errors.New("search query is too long")
+	}
+
+	if v := c.Query("version"); v != "" {
+		normalized, ok := models.NormalizeVersion(v)
+		if !ok {
+			return nil, errors.New("unsupported version")
+		}
+		query.Version = normalized
+	}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return nil, errors.New("limit must be between 1 and 100")
+		}
+		query.Limit = n
+	}
+
+	var err error
+	if query.From, err = parseDateParam(c.Query("from")); err != nil {
+		return nil, errors.New("invalid from date")
+	}
+	if query.To, err = parseDateParam(c.Query("to")); err != nil {
+		return nil, errors.New("invalid to date")
+	}
+
+	return query, nil
+}
+
+// parseDateParam 解析 RFC3339 时间或 2006-01-02 格式的日期
+func parseDateParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
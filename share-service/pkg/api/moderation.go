@@ -0,0 +1,378 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	maxReportDetailsLength = 1000
+	maxModerationReason    = 500
+	defaultQueueLimit      = 50
+)
+
+// ReportShare 处理举报分享请求，同一举报者对同一分享只能举报一次
+// POST /api/share/:id/report
+func (h *Handler) ReportShare(c *gin.Context) {
+	var req models.CreateReportRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	if !models.ReportReason(req.Reason).Valid() {
+		errs.add("reason", "must be one of spam, malicious, illegal, abuse or other")
+	}
+	errs.text("details", req.Details, maxReportDetailsLength, true)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	report := &models.Report{
+		ReportID:    uuid.New().String(),
+		ShareID:     share.ShareID,
+		Reason:      models.ReportReason(req.Reason),
+		Details:     req.Details,
+		ReporterKey: h.reporterKey(c),
+		CreatedAt:   time.Now(),
+	}
+	if user := auth.CurrentUser(c); user != nil {
+		report.ReporterID = user.UserID
+	}
+
+	if err := h.storage.CreateReport(c.Request.Context(), report); err != nil {
+		if errors.Is(err, storage.ErrAlreadyReported) {
+			c.JSON(http.StatusConflict, gin.H{"error": "you have already reported this share"})
+			return
+		}
+		fmt.Printf("failed to create report: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to report share"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reportId": report.ReportID})
+}
+
+// reporterKey 返回举报者标识：登录用户按用户区分，匿名举报按 IP 的 HMAC 区分，避免保存原始 IP
+func (h *Handler) reporterKey(c *gin.Context) string {
+	if user := auth.CurrentUser(c); user != nil {
+		return "user:" + user.UserID
+	}
+	mac := hmac.New(sha256.New, h.accessSecret)
+	fmt.Fprintf(mac, "report|%s", c.ClientIP())
+	return "ip:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkBanned 检查当前客户端 IP 和作者是否被封禁，被封禁时写入 403 响应并返回 true
+func (h *Handler) checkBanned(c *gin.Context, author string) bool {
+	checks := []struct {
+		kind  models.BanKind
+		value string
+	}{
+		{models.BanIP, c.ClientIP()},
+		{models.BanAuthor, author},
+	}
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+		ban, err := h.storage.FindBan(c.Request.Context(), check.kind, check.value)
+		if err != nil {
+			// 查询失败时不阻止创建，避免封禁表故障影响正常使用
+			fmt.Printf("failed to check ban: %v\n", err)
+			continue
+		}
+		if ban != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you are banned from creating shares"})
+			return true
+		}
+	}
+	return false
+}
+
+// ListReportedShares 处理审核队列请求，按举报数从多到少返回被举报的分享
+// GET /api/admin/reports?status=open|all&offset=&limit=
+func (h *Handler) ListReportedShares(c *gin.Context) {
+	query := &models.ReportQuery{Limit: defaultQueueLimit}
+	switch c.DefaultQuery("status", "open") {
+	case "open":
+	case "all":
+		query.IncludeResolved = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or all"})
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		query.Limit = n
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		query.Offset = n
+	}
+
+	ctx := c.Request.Context()
+	reported, err := h.storage.ListReportedShares(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list reports"})
+		return
+	}
+
+	ids := make([]string, 0, len(reported))
+	for _, r := range reported {
+		ids = append(ids, r.ShareID)
+	}
+	shares, err := h.storage.GetSharesByIDs(ctx, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list reports"})
+		return
+	}
+	byID := make(map[string]*models.Share, len(shares))
+	for _, share := range shares {
+		byID[share.ShareID] = share
+	}
+	for _, r := range reported {
+		if share, ok := byID[r.ShareID]; ok {
+			r.Share = models.NewModerationShareView(share)
+		}
+	}
+
+	if reported == nil {
+		reported = []*models.ReportedShare{}
+	}
+	c.JSON(http.StatusOK, &models.ModerationQueueResponse{Shares: reported})
+}
+
+// GetModeratedShare 返回分享的管理员视图及其全部举报
+// GET /api/admin/shares/:id
+func (h *Handler) GetModeratedShare(c *gin.Context) {
+	ctx := c.Request.Context()
+	share, err := h.storage.GetShare(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return
+	}
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+
+	reports, err := h.storage.ListReports(ctx, share.ShareID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get share"})
+		return
+	}
+	if reports == nil {
+		reports = []*models.Report{}
+	}
+
+	c.JSON(http.StatusOK, &models.ModerationShareResponse{
+		Share:   models.NewModerationShareView(share),
+		Reports: reports,
+	})
+}
+
+// HideShare 隐藏分享并将其举报标记为已处理
+// POST /api/admin/shares/:id/hide
+func (h *Handler) HideShare(c *gin.Context) {
+	var req models.HideShareRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+	var errs fieldErrors
+	errs.text("reason", req.Reason, maxModerationReason, true)
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	shareId := c.Param("id")
+	if !h.setHidden(c, shareId, true, req.Reason) {
+		return
+	}
+	if err := h.storage.ResolveReports(c.Request.Context(), shareId); err != nil {
+		fmt.Printf("failed to resolve reports: %v\n", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"shareId": shareId, "hidden": true})
+}
+
+// UnhideShare 恢复被隐藏的分享
+// POST /api/admin/shares/:id/unhide
+func (h *Handler) UnhideShare(c *gin.Context) {
+	shareId := c.Param("id")
+	if !h.setHidden(c, shareId, false, "") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shareId": shareId, "hidden": false})
+}
+
+func (h *Handler) setHidden(c *gin.Context, shareId string, hidden bool, reason string) bool {
+	err := h.storage.SetShareHidden(c.Request.Context(), shareId, hidden, reason)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update share"})
+		return false
+	}
+	return true
+}
+
+// DismissReports 驳回分享的全部未处理举报，分享保持原状
+// POST /api/admin/shares/:id/dismiss
+func (h *Handler) DismissReports(c *gin.Context) {
+	shareId := c.Param("id")
+	if err := h.storage.ResolveReports(c.Request.Context(), shareId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to dismiss reports"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shareId": shareId})
+}
+
+// AdminDeleteShare 永久删除分享及其访问统计和举报
+// DELETE /api/admin/shares/:id
+func (h *Handler) AdminDeleteShare(c *gin.Context) {
+	err := h.storage.DeleteShare(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete share"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListBans 列出当前生效的封禁
+// GET /api/admin/bans
+func (h *Handler) ListBans(c *gin.Context) {
+	bans, err := h.storage.ListBans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list bans"})
+		return
+	}
+	if bans == nil {
+		bans = []*models.Ban{}
+	}
+	c.JSON(http.StatusOK, gin.H{"bans": bans})
+}
+
+// CreateBan 封禁作者或 IP，可选同时隐藏该作者的全部分享
+// POST /api/admin/bans
+func (h *Handler) CreateBan(c *gin.Context) {
+	var req models.CreateBanRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	kind := models.BanKind(req.Kind)
+	value := strings.TrimSpace(req.Value)
+	switch kind {
+	case models.BanAuthor:
+		errs.text("value", value, maxAuthorLength, false)
+	case models.BanIP:
+		if ip := net.ParseIP(value); ip == nil {
+			errs.add("value", "must be a valid IP address")
+		} else {
+			value = ip.String()
+		}
+		if req.HideShares {
+			errs.add("hide_shares", "is only supported for author bans")
+		}
+	default:
+		errs.add("kind", "must be author or ip")
+	}
+	errs.text("reason", req.Reason, maxModerationReason, true)
+
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		expiresIn, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			errs.add("expires_in", "must be a positive duration such as 24h")
+		}
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	ban := &models.Ban{
+		BanID:     uuid.New().String(),
+		Kind:      kind,
+		Value:     value,
+		Reason:    req.Reason,
+		CreatedBy: auth.CurrentUser(c).Username,
+		CreatedAt: time.Now(),
+	}
+	if expiresIn > 0 {
+		expiresAt := ban.CreatedAt.Add(expiresIn)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	ctx := c.Request.Context()
+	if err := h.storage.CreateBan(ctx, ban); err != nil {
+		fmt.Printf("failed to create ban: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create ban"})
+		return
+	}
+
+	var hidden int64
+	if req.HideShares {
+		var err error
+		hidden, err = h.storage.HideSharesByAuthor(ctx, value, req.Reason)
+		if err != nil {
+			fmt.Printf("failed to hide shares of %s: %v\n", value, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ban created but failed to hide shares"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"ban": ban, "hidden_shares": hidden})
+}
+
+// DeleteBan 解除封禁
+// DELETE /api/admin/bans/:id
+func (h *Handler) DeleteBan(c *gin.Context) {
+	err := h.storage.DeleteBan(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ban not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete ban"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/oidc"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	// 保存授权请求状态的 Cookie，仅在回调路径下发送
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/api/auth/oidc"
+	oidcStateTTL    = 10 * time.Minute
+
+	// 生成用户名时遇到重名的最大重试次数
+	maxUsernameAttempts = 5
+)
+
+// 不能出现在用户名中的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// oidcState 代表一次登录流程中需要在回调时取回的状态
+type oidcState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Redirect string `json:"r"`
+	Expires  int64  `json:"e"`
+}
+
+// AuthConfig 返回可用的登录方式，便于前端决定展示哪些入口
+// GET /api/auth/config
+func (h *Handler) AuthConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"registration": h.allowRegistration,
+		"oidc":         h.oidc != nil,
+	})
+}
+
+// OIDCLogin 跳转到身份提供方登录页
+// GET /api/auth/oidc/login?redirect=/path
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "single sign-on is not configured"})
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	target, err := h.oidc.AuthCodeURL(c.Request.Context(), req)
+	if err != nil {
+		fmt.Printf("failed to build authorization url: %v\n", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider unavailable"})
+		return
+	}
+
+	state := &oidcState{
+		State:    req.State,
+		Nonce:    req.Nonce,
+		Verifier: req.Verifier,
+		Redirect: safeRedirect(c.Query("redirect")),
+		Expires:  time.Now().Add(oidcStateTTL).Unix(),
+	}
+	h.setOIDCState(c, state)
+	c.Redirect(http.StatusFound, target)
+}
+
+// OIDCCallback 处理身份提供方的回调，校验身份后登录或创建用户
+// GET /api/auth/oidc/callback
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if h.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "single sign-on is not configured"})
+		return
+	}
+
+	state, ok := h.readOIDCState(c)
+	// 无论成功与否，授权状态都只能使用一次
+	h.clearOIDCState(c)
+	if !ok || c.Query("state") == "" || !hmac.Equal([]byte(c.Query("state")), []byte(state.State)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired login state"})
+		return
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed", "details": errCode + " " + c.Query("error_description")})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing authorization code"})
+		return
+	}
+
+	identity, err := h.oidc.Exchange(c.Request.Context(), code, &oidc.AuthRequest{
+		State:    state.State,
+		Nonce:    state.Nonce,
+		Verifier: state.Verifier,
+	})
+	if err != nil {
+		fmt.Printf("oidc login failed: %v\n", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed"})
+		return
+	}
+	if !h.oidcGroupAllowed(identity.Groups) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not a member of an allowed group"})
+		return
+	}
+
+	user, err := h.userForIdentity(c, identity)
+	if err != nil {
+		fmt.Printf("failed to resolve oidc user: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+
+	plain, _, err := h.issueSession(c, user)
+	if err != nil {
+		fmt.Printf("failed to create session: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	auth.SetSessionCookie(c, plain, h.sessionTTL)
+	c.Redirect(http.StatusFound, state.Redirect)
+}
+
+// userForIdentity 查找外部身份对应的用户，不存在时自动创建，存在时同步资料和用户组
+func (h *Handler) userForIdentity(c *gin.Context, id *oidc.Identity) (*models.User, error) {
+	ctx := c.Request.Context()
+	external := &models.ExternalIdentity{
+		Issuer:  id.Issuer,
+		Subject: id.Subject,
+		Name:    id.Name,
+	}
+	if id.EmailVerified {
+		external.Email = id.Email
+	}
+
+	user, err := h.storage.GetUserByIdentity(ctx, id.Issuer, id.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if err := h.storage.UpdateUserIdentity(ctx, user.UserID, external, id.Groups); err != nil {
+			return nil, err
+		}
+		user.Identity = external
+		user.Groups = id.Groups
+		return user, nil
+	}
+
+	base := usernameFromIdentity(id)
+	for attempt := 0; attempt < maxUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			username = withUsernameSuffix(base)
+		}
+
+		user, err := auth.NewUser(username, "")
+		if err != nil {
+			return nil, err
+		}
+		user.Identity = external
+		user.Groups = id.Groups
+
+		err = h.storage.CreateUser(ctx, user)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, storage.ErrUsernameTaken) {
+			return nil, err
+		}
+		// 可能是同一身份的并发登录，已创建则直接使用
+		if existing, _ := h.storage.GetUserByIdentity(ctx, id.Issuer, id.Subject); existing != nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("no free username for %q", base)
+}
+
+// oidcGroupAllowed 判断用户是否属于允许登录的用户组，未配置时允许所有用户
+func (h *Handler) oidcGroupAllowed(groups []string) bool {
+	if len(h.oidcGroups) == 0 {
+		return true
+	}
+	for _, g := range groups {
+		for _, allowed := range h.oidcGroups {
+			if g == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usernameFromIdentity 根据身份声明生成合法的用户名，依次尝试用户名声明、邮箱前缀和 subject
+func usernameFromIdentity(id *oidc.Identity) string {
+	candidates := []string{id.Username}
+	if local, _, ok := strings.Cut(id.Email, "@"); ok {
+		candidates = append(candidates, local)
+	}
+	candidates = append(candidates, id.Subject)
+
+	for _, candidate := range candidates {
+		name := usernameInvalidChars.ReplaceAllString(strings.ToLower(candidate), "-")
+		name = strings.TrimLeft(name, "_.-")
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		if normalized, err := auth.NormalizeUsername(name); err == nil {
+			return normalized
+		}
+	}
+	return withUsernameSuffix("user")
+}
+
+// withUsernameSuffix 为用户名追加随机后缀，并保证长度不超过限制
+func withUsernameSuffix(base string) string {
+	buf := make([]byte, 3)
+	rand.Read(buf)
+	suffix := "-" + hex.EncodeToString(buf)
+	if len(base)+len(suffix) > 32 {
+		base = base[:32-len(suffix)]
+	}
+	return base + suffix
+}
+
+// safeRedirect 只允许跳转到本站的相对路径，防止开放重定向
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+// oidcStateSignature 计算授权状态 Cookie 的签名
+func (h *Handler) oidcStateSignature(payload string) string {
+	mac := hmac.New(sha256.New, h.accessSecret)
+	fmt.Fprintf(mac, "oidc|%s", payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setOIDCState 保存授权请求的状态，Secure 属性与会话 Cookie 一致，
+// 通过 nginx 以 HTTPS 访问时后端收到的是 HTTP 请求，不能只看 c.Request.TLS
+func (h *Handler) setOIDCState(c *gin.Context, state *oidcState) {
+	data, _ := json.Marshal(state)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, payload+"."+h.oidcStateSignature(payload), int(oidcStateTTL.Seconds()), oidcCookiePath, "", auth.IsSecureRequest(c), true)
+}
+
+func (h *Handler) clearOIDCState(c *gin.Context) {
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", auth.IsSecureRequest(c), true)
+}
+
+func (h *Handler) readOIDCState(c *gin.Context) (*oidcState, bool) {
+	value, err := c.Cookie(oidcStateCookie)
+	if err != nil {
+		return nil, false
+	}
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(h.oidcStateSignature(payload))) {
+		return nil, false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+	var state oidcState
+	if err := json.Unmarshal(data, &state); err != nil || time.Now().Unix() > state.Expires {
+		return nil, false
+	}
+	return &state, true
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/ogimage"
+)
+
+// 预览图缓存保存的最大数量
+const defaultPreviewCacheSize = 256
+
+// previewPage 是落地页模板的数据
+type previewPage struct {
+	Share       *models.Share
+	Title       string
+	Description string
+	ShareURL    string
+	OEmbedURL   string
+	ImageURL    string
+	Width       int
+	Height      int
+}
+
+// ShareImage 返回分享的 Open Graph 预览图，按内容哈希缓存
+// 只有允许嵌入的分享才生成预览图，受保护的分享不会通过预览泄露内容
+// GET /share/:id/og.png
+func (h *Handler) ShareImage(c *gin.Context) {
+	share, status, message := h.embeddableShare(c, c.Param("id"))
+	if share == nil {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	key := previewKey(share)
+	etag := `"` + key + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, ok := h.previews.Get(key)
+	if !ok {
+		var err error
+		data, err = ogimage.Render(&ogimage.Card{
+			Title:   shareTitle(share),
+			Author:  share.Author,
+			Version: share.Version,
+			Code:    previewCode(share),
+			Footer:  "/share/" + share.ShareID,
+		})
+		if err != nil {
+			fmt.Printf("failed to render preview image: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render preview image"})
+			return
+		}
+		h.previews.Add(key, data)
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// previewKey 计算预览图的缓存键，包括代码内容哈希以及图上显示的元数据
+func previewKey(share *models.Share) string {
+	hash := share.ContentHash
+	if hash == "" {
+		hash = models.ContentHash(share.Code, share.Version, "go")
+	}
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%d|%s|%s|%s|%s", ogimage.Revision, hash, share.ShareID, share.Title, share.Author)
+	return hex.EncodeToString(sum.Sum(nil))[:32]
+}
+
+// shareLanding 返回带 Open Graph 和 Twitter Card 元数据的轻量落地页，供聊天工具和社交平台抓取链接预览
+// 落地页不计入访问次数；受保护的分享只返回通用的元数据
+// GET /share/:id，由 ShareFile 分发
+func (h *Handler) shareLanding(c *gin.Context, shareId string) {
+	base := h.baseURL(c)
+	page := &previewPage{
+		Title:       "Go Playground",
+		Description: "Write, run and share Go code in the browser.",
+		ShareURL:    base + "/share/" + url.PathEscape(shareId),
+	}
+
+	share, status, _ := h.embeddableShare(c, shareId)
+	if status == http.StatusForbidden {
+		// 分享存在但受保护，返回通用的预览
+		status = http.StatusOK
+	}
+	if share != nil {
+		status = http.StatusOK
+		page.Share = share
+		page.Title = shareTitle(share)
+		page.Description = share.Description
+		if page.Description == "" {
+			page.Description = "Go code shared on Go Playground"
+			if share.Author != "" {
+				page.Description += " by " + share.Author
+			}
+			page.Description += "."
+		}
+		page.OEmbedURL = base + "/api/oembed?format=json&url=" + url.QueryEscape(page.ShareURL)
+		page.ImageURL = page.ShareURL + "/og.png"
+		page.Width = ogimage.Width
+		page.Height = ogimage.Height
+		c.Header("Cache-Control", "public, max-age=300")
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := landingTemplate.Execute(c.Writer, page); err != nil {
+		fmt.Printf("failed to render landing page: %v\n", err)
+	}
+}
+
+// previewCode 返回预览图上显示的代码，多文件分享优先显示第一个 Go 文件
+func previewCode(share *models.Share) string {
+	files := shareFiles(share)
+	for _, f := range files {
+		if path.Ext(f.Name) == ".go" {
+			return string(f.Data)
+		}
+	}
+	if len(files) > 0 {
+		return string(files[0].Data)
+	}
+	return ""
+}
+
+func shareTitle(share *models.Share) string {
+	if share.Title != "" {
+		return share.Title
+	}
+	return "Share " + share.ShareID
+}
+
+var landingTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<link rel="canonical" href="{{.ShareURL}}">
+<meta property="og:site_name" content="Go Playground">
+<meta property="og:type" content="website">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.ShareURL}}">
+{{- if .ImageURL}}
+<meta property="og:image" content="{{.ImageURL}}">
+<meta property="og:image:type" content="image/png">
+<meta property="og:image:width" content="{{.Width}}">
+<meta property="og:image:height" content="{{.Height}}">
+<meta property="og:image:alt" content="{{.Title}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:image" content="{{.ImageURL}}">
+{{- else}}
+<meta name="twitter:card" content="summary">
+{{- end}}
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{- if .Share}}
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Title}}">
+{{- end}}
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Description}}</p>
+{{- if .ImageURL}}
+<p><img src="{{.ImageURL}}" width="600" height="315" alt="{{.Title}}"></p>
+{{- end}}
+<p><a href="{{.ShareURL}}">Open in Go Playground</a></p>
+</body>
+</html>
+`))
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/collab"
+	"github.com/playground/share-service/pkg/models"
+)
+
+// 未填写名称的匿名参与者显示的名称
+const defaultParticipantName = "Guest"
+
+// CreateSession 创建协作编辑会话，可以从已有分享开始，读取分享计入访问
+// POST /api/sessions
+func (h *Handler) CreateSession(c *gin.Context) {
+	var req models.CreateSessionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	if req.ShareID != "" && req.Code != "" {
+		errs.add("code", "must be empty when share_id is set")
+	}
+	errs.code("code", req.Code, h.maxCodeBytes)
+	version := req.Version
+	if version == "" && req.ShareID == "" {
+		version = models.DefaultVersion
+	}
+	if version != "" {
+		normalized, ok := models.NormalizeVersion(version)
+		if !ok {
+			errs.add("version", "unsupported version %q", version)
+		}
+		version = normalized
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	author := ""
+	if user := auth.CurrentUser(c); user != nil {
+		author = user.Username
+	}
+	if h.checkBanned(c, author) {
+		return
+	}
+
+	code := req.Code
+	if req.ShareID != "" {
+		share, ok := h.readShare(c, req.ShareID)
+		if !ok {
+			return
+		}
+		code = share.Code
+		if version == "" {
+			version = share.Version
+		}
+	}
+
+	session, err := h.sessions.Create(code, version)
+	switch {
+	case errors.Is(err, collab.ErrTooLarge):
+		respondInvalid(c, fieldErrors{{Field: "code", Message: fmt.Sprintf("must be at most %d bytes", h.maxCodeBytes)}})
+		return
+	case errors.Is(err, collab.ErrTooManySessions):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many active sessions"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sessionResponse(session))
+}
+
+// GetSession 返回会话当前的代码、修订号和在线参与者
+// GET /api/sessions/:id
+func (h *Handler) GetSession(c *gin.Context) {
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, sessionResponse(session))
+}
+
+// JoinSession 将 WebSocket 连接加入会话，登录用户使用用户名，匿名用户可以通过 name 参数指定显示名称
+// GET /api/sessions/:id/ws[?name=...]
+func (h *Handler) JoinSession(c *gin.Context) {
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	name, verified := c.Query("name"), false
+	if user := auth.CurrentUser(c); user != nil {
+		name, verified = user.Username, true
+	} else {
+		var errs fieldErrors
+		errs.text("name", name, maxAuthorLength, false)
+		if len(errs) > 0 {
+			respondInvalid(c, errs)
+			return
+		}
+		if name == "" {
+			name = defaultParticipantName
+		}
+	}
+	if h.checkBanned(c, name) {
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.sameOrigin}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入错误响应
+		return
+	}
+	session.Serve(conn, collab.NewClient(uuid.New().String(), name, verified))
+}
+
+// SnapshotSession 将会话当前的代码保存为新的分享，选项与创建分享相同，会话继续存在
+// POST /api/sessions/:id/snapshot
+func (h *Handler) SnapshotSession(c *gin.Context) {
+	var req models.SnapshotSessionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	session, ok := h.loadSession(c)
+	if !ok {
+		return
+	}
+
+	state := session.State()
+	origin := &models.ShareOrigin{
+		Kind:       "session",
+		Revision:   strconv.Itoa(state.Revision),
+		ImportedAt: time.Now(),
+	}
+	h.createShare(c, req.CreateRequest(state.Code, state.Version), origin)
+}
+
+// loadSession 读取路径中的会话，不存在时写入 404 响应
+func (h *Handler) loadSession(c *gin.Context) (*collab.Session, bool) {
+	session := h.sessions.Get(c.Param("id"))
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return nil, false
+	}
+	return session, true
+}
+
+// sameOrigin 只接受同源页面或 PUBLIC_URL 页面发起的 WebSocket 连接，防止其他网站借用访客的登录状态加入会话
+func (h *Handler) sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// 非浏览器客户端不发送 Origin
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if u.Host == r.Host {
+		return true
+	}
+	if h.publicURL == "" {
+		return false
+	}
+	public, err := url.Parse(h.publicURL)
+	return err == nil && public.Scheme == u.Scheme && public.Host == u.Host
+}
+
+func sessionResponse(session *collab.Session) *models.SessionResponse {
+	state := session.State()
+	return &models.SessionResponse{
+		ID:           session.ID,
+		URL:          "/api/sessions/" + session.ID + "/ws",
+		Code:         state.Code,
+		Version:      state.Version,
+		Revision:     state.Revision,
+		Participants: state.Participants,
+		CreatedAt:    session.CreatedAt,
+	}
+}
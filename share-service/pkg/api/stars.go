@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// StarShare 收藏分享，重复收藏不会重复计数，需要登录
+// PUT /api/share/:id/star
+func (h *Handler) StarShare(c *gin.Context) {
+	h.setStar(c, true)
+}
+
+// UnstarShare 取消收藏分享，需要登录
+// DELETE /api/share/:id/star
+func (h *Handler) UnstarShare(c *gin.Context) {
+	h.setStar(c, false)
+}
+
+func (h *Handler) setStar(c *gin.Context, starred bool) {
+	user := auth.CurrentUser(c)
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var stars int64
+	var err error
+	if starred {
+		stars, err = h.storage.StarShare(c.Request.Context(), share.ShareID, user.UserID)
+	} else {
+		stars, err = h.storage.UnstarShare(c.Request.Context(), share.ShareID, user.UserID)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		fmt.Printf("failed to update star: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update star"})
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.StarResponse{
+		ShareID: share.ShareID,
+		Stars:   stars,
+		Starred: starred,
+	})
+}
+
+// hasStarred 判断当前登录用户是否收藏了分享，匿名请求或查询失败时返回 false
+func (h *Handler) hasStarred(c *gin.Context, share *models.Share) bool {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		return false
+	}
+	starred, err := h.storage.HasStarred(c.Request.Context(), share.ShareID, user.UserID)
+	if err != nil {
+		fmt.Printf("failed to check star: %v\n", err)
+		return false
+	}
+	return starred
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// 统计接口默认和最大的查询天数
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// visitorKey 生成隐私友好的访客标识
+// 标识由当天的轮换盐值对 IP、User-Agent 和分享 ID 做 HMAC 得到，不保存原始 IP，且无法跨天、跨分享关联
+func (h *Handler) visitorKey(c *gin.Context, shareId string, now time.Time) string {
+	salt := hmac.New(sha256.New, h.accessSecret)
+	fmt.Fprintf(salt, "visitor|%s", now.UTC().Format("2006-01-02"))
+
+	mac := hmac.New(sha256.New, salt.Sum(nil))
+	fmt.Fprintf(mac, "%s|%s|%s", c.ClientIP(), c.Request.UserAgent(), shareId)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// referrerHost 提取来源站点的域名，同站访问和无效来源返回空
+func referrerHost(c *gin.Context) string {
+	ref := c.Request.Referer()
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == strings.ToLower(hostWithoutPort(c.Request.Host)) {
+		return ""
+	}
+	return host
+}
+
+func hostWithoutPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.Contains(host[i:], "]") {
+		return host[:i]
+	}
+	return host
+}
+
+// isEmbeddedRequest 判断请求是否来自嵌入页面
+func isEmbeddedRequest(c *gin.Context) bool {
+	if embed, _ := strconv.ParseBool(c.Query("embed")); embed {
+		return true
+	}
+	return c.GetHeader("Sec-Fetch-Dest") == "iframe"
+}
+
+// recordView 记录一次访问事件，返回该访客当天是否首次访问
+// 记录失败时按非首次访问处理，不影响请求本身
+func (h *Handler) recordView(c *gin.Context, share *models.Share, embedded bool) bool {
+	now := time.Now()
+	event := &models.ViewEvent{
+		ShareID:    share.ShareID,
+		VisitorKey: h.visitorKey(c, share.ShareID, now),
+		Referrer:   referrerHost(c),
+		Embedded:   embedded || isEmbeddedRequest(c),
+		Time:       now,
+	}
+
+	unique, err := h.storage.RecordView(c.Request.Context(), event)
+	if err != nil {
+		fmt.Printf("failed to record view: %v\n", err)
+		return false
+	}
+	return unique
+}
+
+// countView 记录访问事件，并在访客当天首次访问时增加分享的累计访问次数
+func (h *Handler) countView(c *gin.Context, share *models.Share, embedded bool) {
+	if !h.recordView(c, share, embedded) {
+		return
+	}
+
+	updatedViews, err := h.storage.IncrementViews(c.Request.Context(), share.ShareID)
+	if err != nil {
+		fmt.Printf("failed to increment views: %v\n", err)
+		// 继续处理请求，即使计数失败也不影响获取分享内容
+		return
+	}
+	share.Views = updatedViews
+	h.webhooks.Publish(models.EventShareViewed, share)
+}
+
+// GetShareStats 返回分享按天统计的访问时间序列
+// GET /api/share/:id/stats[?days=30]
+func (h *Handler) GetShareStats(c *gin.Context) {
+	days, ok := parseStatsDays(c)
+	if !ok {
+		return
+	}
+
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	h.respondStats(c, share, days, isOwner(c, share))
+}
+
+// parseStatsDays 解析统计天数参数，无效时写入 400 响应并返回 false
+func parseStatsDays(c *gin.Context) (int, bool) {
+	days := defaultStatsDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStatsDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxStatsDays)})
+			return 0, false
+		}
+		days = n
+	}
+	return days, true
+}
+
+// respondStats 查询分享最近 days 天的访问统计并写入响应，referrers 为 false 时不返回来源域名
+func (h *Handler) respondStats(c *gin.Context, share *models.Share, days int, referrers bool) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -(days - 1))
+	daily, err := h.storage.GetDailyViews(c.Request.Context(), share.ShareID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
+		return
+	}
+
+	resp := models.NewShareStatsResponse(share, daily, from, to)
+	if !referrers {
+		resp.HideReferrers()
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// 每个分享最多的标签数
+const maxTags = 10
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeTags 将标签统一为小写并去重，保持原有顺序
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: tags must be 1-32 characters of letters, digits, '_' or '-'", tag)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	return result, nil
+}
+
+// UpdateShareTags 替换分享的标签，仅所有者可操作
+// PUT /api/share/:id/tags
+func (h *Handler) UpdateShareTags(c *gin.Context) {
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		respondInvalid(c, fieldErrors{{Field: "tags", Message: err.Error()}})
+		return
+	}
+
+	share, ok := h.loadShare(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !isOwner(c, share) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can update tags"})
+		return
+	}
+
+	if err := h.storage.UpdateTags(c.Request.Context(), share.ShareID, tags); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tags"})
+		return
+	}
+	share.Tags = tags
+	h.webhooks.Publish(models.EventShareUpdated, share)
+
+	c.JSON(http.StatusOK, gin.H{"shareId": share.ShareID, "tags": tags})
+}
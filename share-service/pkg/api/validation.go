@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// DefaultMaxBodyBytes 是请求体的默认大小上限
+	DefaultMaxBodyBytes = 1 << 20
+	// DefaultMaxCodeBytes 是代码的默认大小上限
+	DefaultMaxCodeBytes = 64 << 10
+
+	// 文本字段的最大字符数
+	maxTitleLength       = 200
+	maxDescriptionLength = 2000
+	maxAuthorLength      = 64
+	maxNameLength        = 64
+	maxVersionLength     = 32
+)
+
+func init() {
+	// 校验错误中使用 JSON 字段名，与请求体保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// BodyLimit 限制请求体大小，超出时返回 413
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			respondTooLarge(c, maxBytes)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// bindJSON 读取并解析 JSON 请求体，失败时写入错误响应并返回 false：
+// 请求体过大返回 413，JSON 格式错误返回 400，非 UTF-8 内容或字段校验失败返回 422
+func (h *Handler) bindJSON(c *gin.Context, obj any) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondTooLarge(c, tooLarge.Limit)
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return false
+	}
+	if !utf8.Valid(body) {
+		respondInvalid(c, fieldErrors{{Field: "body", Message: "must be valid UTF-8"}})
+		return false
+	}
+
+	if err := binding.JSON.BindBody(body, obj); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errs := make(fieldErrors, 0, len(verrs))
+			for _, e := range verrs {
+				msg := "is invalid"
+				if e.Tag() == "required" {
+					msg = "is required"
+				}
+				errs.add(e.Field(), "%s", msg)
+			}
+			respondInvalid(c, errs)
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// fieldErrors 收集字段校验错误，便于一次返回全部问题
+type fieldErrors []models.FieldError
+
+func (e *fieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// text 校验文本字段：必须是合法 UTF-8，不超过 max 个字符；
+// multiline 为 false 时不允许换行等控制字符
+func (e *fieldErrors) text(field, value string, max int, multiline bool) {
+	if !utf8.ValidString(value) {
+		e.add(field, "must be valid UTF-8")
+		return
+	}
+	if n := utf8.RuneCountInString(value); n > max {
+		e.add(field, "must be at most %d characters (got %d)", max, n)
+		return
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && !(multiline && (r == '\n' || r == '\r' || r == '\t')) {
+			e.add(field, "must not contain control characters")
+			return
+		}
+	}
+}
+
+// code 校验代码字段：必须是合法 UTF-8，不超过 max 字节
+func (e *fieldErrors) code(field, value string, max int) {
+	if !utf8.ValidString(value) {
+		e.add(field, "must be valid UTF-8")
+		return
+	}
+	if len(value) > max {
+		e.add(field, "must be at most %d bytes (got %d)", max, len(value))
+	}
+}
+
+// respondInvalid 返回 422 以及各字段的校验错误
+func respondInvalid(c *gin.Context, errs fieldErrors) {
+	c.JSON(http.StatusUnprocessableEntity, &models.ValidationErrorResponse{
+		Error:  "validation failed",
+		Fields: errs,
+	})
+}
+
+func respondTooLarge(c *gin.Context, limit int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, &models.TooLargeResponse{
+		Error: "request body too large",
+		Limit: limit,
+	})
+}
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"github.com/playground/share-service/pkg/webhook"
+)
+
+const (
+	// 每个用户最多的 Webhook 订阅数
+	maxWebhooksPerUser = 20
+	// 订阅地址的最大长度
+	maxWebhookURLLength = 2048
+	// 按作者过滤时最多的作者数
+	maxWebhookAuthors = 20
+	// 自定义签名密钥的长度范围
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 128
+	// 投递记录列表的默认和最大条数
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+// ListWebhooks 列出当前用户的 Webhook 订阅
+// GET /api/webhooks
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.storage.ListWebhooks(c.Request.Context(), auth.CurrentUser(c).UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list webhooks"})
+		return
+	}
+	if webhooks == nil {
+		webhooks = []*models.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// CreateWebhook 为当前用户创建 Webhook 订阅，签名密钥只在响应中返回一次
+// POST /api/webhooks
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var errs fieldErrors
+	if err := h.validateWebhook(ctx, &errs, req.URL, req.Events, &req.Filter, req.ViewThreshold); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+	if req.Secret != "" && (len(req.Secret) < minWebhookSecretLength || len(req.Secret) > maxWebhookSecretLength) {
+		errs.add("secret", "must be between %d and %d bytes", minWebhookSecretLength, maxWebhookSecretLength)
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	user := auth.CurrentUser(c)
+	existing, err := h.storage.ListWebhooks(ctx, user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many webhooks"})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhook.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+			return
+		}
+	}
+
+	now := time.Now()
+	w := &models.Webhook{
+		WebhookID:     uuid.New().String(),
+		OwnerID:       user.UserID,
+		URL:           req.URL,
+		Secret:        secret,
+		Events:        req.Events,
+		Filter:        req.Filter,
+		ViewThreshold: req.ViewThreshold,
+		Active:        true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := h.storage.CreateWebhook(ctx, w); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+	h.webhooks.Invalidate()
+
+	c.JSON(http.StatusCreated, &models.WebhookResponse{Webhook: w, Secret: secret})
+}
+
+// GetWebhook 返回当前用户的 Webhook 订阅
+// GET /api/webhooks/:id
+func (h *Handler) GetWebhook(c *gin.Context) {
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// UpdateWebhook 修改 Webhook 订阅，rotate_secret 为 true 时返回新的签名密钥
+// PUT /api/webhooks/:id
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	var req models.UpdateWebhookRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if req.URL != nil {
+		w.URL = *req.URL
+	}
+	if req.Events != nil {
+		w.Events = *req.Events
+	}
+	if req.Filter != nil {
+		w.Filter = *req.Filter
+	}
+	if req.ViewThreshold != nil {
+		w.ViewThreshold = *req.ViewThreshold
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+
+	var errs fieldErrors
+	if err := h.validateWebhook(c.Request.Context(), &errs, w.URL, w.Events, &w.Filter, w.ViewThreshold); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+		return
+	}
+	if len(errs) > 0 {
+		respondInvalid(c, errs)
+		return
+	}
+
+	resp := &models.WebhookResponse{Webhook: w}
+	if req.RotateSecret {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+			return
+		}
+		w.Secret = secret
+		resp.Secret = secret
+	}
+	w.UpdatedAt = time.Now()
+
+	if err := h.storage.UpdateWebhook(c.Request.Context(), w); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+		return
+	}
+	h.webhooks.Invalidate()
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteWebhook 删除 Webhook 订阅及其投递记录，未完成的投递不再发送
+// DELETE /api/webhooks/:id
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.DeleteWebhook(c.Request.Context(), w.WebhookID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete webhook"})
+		return
+	}
+	h.webhooks.Invalidate()
+
+	c.Status(http.StatusNoContent)
+}
+
+// PingWebhook 向订阅地址发送一条 ping 测试事件，投递结果可以在投递记录中查看
+// POST /api/webhooks/:id/ping
+func (h *Handler) PingWebhook(c *gin.Context) {
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooks.Ping(c.Request.Context(), w)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to ping webhook"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// ListWebhookDeliveries 列出订阅最近的投递记录，按创建时间倒序
+// GET /api/webhooks/:id/deliveries[?limit=50]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	limit := defaultDeliveriesLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveriesLimit)})
+			return
+		}
+		limit = n
+	}
+
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.storage.ListDeliveries(c.Request.Context(), w.WebhookID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deliveries"})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	c.JSON(http.StatusOK, &models.ListDeliveriesResponse{Deliveries: deliveries})
+}
+
+// RedeliverWebhook 立即重新投递一条已结束的投递记录，请求体和投递 ID 保持不变
+// POST /api/webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	w, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	delivery, err := h.storage.GetDelivery(ctx, c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get delivery"})
+		return
+	}
+	if delivery == nil || delivery.WebhookID != w.WebhookID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
+	if delivery.Status == models.DeliveryPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "delivery is still pending"})
+		return
+	}
+
+	if err := h.storage.RetryDelivery(ctx, delivery.DeliveryID, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to redeliver"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": delivery.DeliveryID, "status": models.DeliveryPending})
+}
+
+// loadWebhook 读取路径中的订阅，不存在或不属于当前用户时写入 404 响应
+func (h *Handler) loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	w, err := h.storage.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get webhook"})
+		return nil, false
+	}
+	if w == nil || w.OwnerID != auth.CurrentUser(c).UserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return nil, false
+	}
+	return w, true
+}
+
+// validateWebhook 校验订阅的地址、事件和过滤条件，只在读取合集失败时返回错误
+func (h *Handler) validateWebhook(ctx context.Context, errs *fieldErrors, rawURL string, events []models.WebhookEventType, filter *models.WebhookFilter, viewThreshold int64) error {
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add("url", "must be an absolute http or https URL")
+	} else if len(rawURL) > maxWebhookURLLength {
+		errs.add("url", "must be at most %d bytes", maxWebhookURLLength)
+	} else if err := h.webhooks.CheckURL(u); err != nil {
+		errs.add("url", "must not point to a loopback, link-local or private address")
+	}
+
+	if len(events) == 0 {
+		errs.add("events", "must include at least one event")
+	}
+	seen := make(map[models.WebhookEventType]bool)
+	for _, e := range events {
+		if !e.Valid() {
+			errs.add("events", "unknown event %q", e)
+		} else if seen[e] {
+			errs.add("events", "duplicate event %q", e)
+		}
+		seen[e] = true
+	}
+	if viewThreshold < 0 {
+		errs.add("view_threshold", "must not be negative")
+	} else if seen[models.EventShareViewed] && viewThreshold == 0 {
+		errs.add("view_threshold", "is required when subscribing to %s", models.EventShareViewed)
+	}
+
+	if len(filter.Authors) > maxWebhookAuthors {
+		errs.add("filter.authors", "must have at most %d entries", maxWebhookAuthors)
+	}
+	for _, author := range filter.Authors {
+		if author == "" || len(author) > maxAuthorLength {
+			errs.add("filter.authors", "must be non-empty and at most %d bytes", maxAuthorLength)
+			break
+		}
+	}
+	if filter.Collection != "" {
+		collection, err := h.storage.GetCollection(ctx, filter.Collection)
+		if err != nil {
+			return err
+		}
+		if collection == nil {
+			errs.add("filter.collection", "collection not found")
+		}
+	}
+	return nil
+}
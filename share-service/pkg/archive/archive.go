@@ -0,0 +1,269 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/txtar"
+)
+
+// Format 表示归档格式
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatTar    Format = "tar"
+)
+
+// ParseFormat 解析格式名称
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatNDJSON, FormatTar:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown archive format %q, expected ndjson or tar", s)
+}
+
+// Layout 表示 tar 格式中代码在 txtar 文件里的存放方式
+type Layout string
+
+const (
+	// LayoutFile 表示代码是 txtar 中唯一的 prog.go 文件
+	LayoutFile Layout = "file"
+	// LayoutTxtar 表示代码本身是多文件的 txtar 归档，文件原样展开，开头的注释保存在 code 字段
+	LayoutTxtar Layout = "txtar"
+	// LayoutInline 表示代码无法无损地放入 txtar（例如缺少结尾换行），保存在 code 字段中
+	LayoutInline Layout = "inline"
+)
+
+// progFile 是单文件分享在 txtar 中的文件名
+const progFile = "prog.go"
+
+// Writer 将分享依次写入归档
+type Writer interface {
+	Write(share *models.Share) error
+	// Close 写入归档结尾，不关闭底层的 io.Writer
+	Close() error
+}
+
+// NewWriter 创建指定格式的归档写入器
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatTar:
+		return &tarWriter{tw: tar.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q", format)
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(share *models.Share) error {
+	return w.enc.Encode(NewRecord(share))
+}
+
+func (w *ndjsonWriter) Close() error { return nil }
+
+type tarWriter struct {
+	tw *tar.Writer
+}
+
+func (w *tarWriter) Write(share *models.Share) error {
+	data, err := marshalTxtar(NewRecord(share))
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Name:     path.Join("shares", share.ShareID+".txtar"),
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  share.CreatedAt,
+		Typeflag: tar.TypeReg,
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = w.tw.Write(data)
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	return w.tw.Close()
+}
+
+// marshalTxtar 将记录编码为 txtar：注释部分为 JSON 元数据，文件部分为代码
+func marshalTxtar(rec *Record) ([]byte, error) {
+	code := rec.Code
+	a := &txtar.Archive{}
+	switch {
+	case code == "":
+		rec.Layout = LayoutFile
+	case !txtar.HasFiles([]byte(code)) && strings.HasSuffix(code, "\n"):
+		rec.Layout = LayoutFile
+		rec.Code = ""
+		a.Files = []txtar.File{{Name: progFile, Data: []byte(code)}}
+	default:
+		// 多文件代码只有在能够原样还原时才展开
+		parsed := txtar.Parse([]byte(code))
+		if len(parsed.Files) > 0 && string(txtar.Format(parsed)) == code {
+			rec.Layout = LayoutTxtar
+			rec.Code = string(parsed.Comment)
+			a.Files = parsed.Files
+		} else {
+			rec.Layout = LayoutInline
+		}
+	}
+
+	meta, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	a.Comment = meta
+	return txtar.Format(a), nil
+}
+
+// unmarshalTxtar 从 txtar 还原记录
+func unmarshalTxtar(data []byte) (*Record, error) {
+	a := txtar.Parse(data)
+	var rec Record
+	if err := json.Unmarshal(a.Comment, &rec); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %v", err)
+	}
+
+	switch rec.Layout {
+	case LayoutFile:
+		switch len(a.Files) {
+		case 0:
+			rec.Code = ""
+		case 1:
+			rec.Code = string(a.Files[0].Data)
+		default:
+			return nil, fmt.Errorf("expected a single file, found %d", len(a.Files))
+		}
+	case LayoutTxtar:
+		rec.Code = string(txtar.Format(&txtar.Archive{Comment: []byte(rec.Code), Files: a.Files}))
+	case LayoutInline:
+	default:
+		return nil, fmt.Errorf("unknown layout %q", rec.Layout)
+	}
+	rec.Layout = ""
+	return &rec, nil
+}
+
+// Reader 从归档中依次读取分享
+type Reader interface {
+	// Next 返回下一个分享，读完时返回 io.EOF
+	Next() (*models.Share, error)
+}
+
+// NewReader 创建归档读取器，format 为空时根据内容自动识别，gzip 压缩的归档会自动解压
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(zr)
+	}
+
+	if format == "" {
+		format = detect(br)
+	}
+	switch format {
+	case FormatNDJSON:
+		dec := json.NewDecoder(br)
+		dec.DisallowUnknownFields()
+		return &ndjsonReader{dec: dec}, nil
+	case FormatTar:
+		return &tarReader{tr: tar.NewReader(br)}, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q", format)
+}
+
+// detect 根据第一个非空白字符识别格式：JSON 记录以 { 开头，其余按 tar 处理
+func detect(br *bufio.Reader) Format {
+	buf, _ := br.Peek(512)
+	buf = bytes.TrimLeft(buf, " \t\r\n")
+	if len(buf) == 0 || buf[0] == '{' {
+		return FormatNDJSON
+	}
+	return FormatTar
+}
+
+type ndjsonReader struct {
+	dec  *json.Decoder
+	line int
+}
+
+func (r *ndjsonReader) Next() (*models.Share, error) {
+	r.line++
+	var rec Record
+	if err := r.dec.Decode(&rec); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("record %d: %v", r.line, err)
+	}
+	if err := rec.validate(); err != nil {
+		return nil, fmt.Errorf("record %d: %v", r.line, err)
+	}
+	return rec.Share(), nil
+}
+
+type tarReader struct {
+	tr *tar.Reader
+}
+
+func (r *tarReader) Next() (*models.Share, error) {
+	for {
+		hdr, err := r.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Ext(hdr.Name) != ".txtar" {
+			continue
+		}
+
+		data, err := io.ReadAll(r.tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", hdr.Name, err)
+		}
+		rec, err := unmarshalTxtar(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", hdr.Name, err)
+		}
+		if err := rec.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", hdr.Name, err)
+		}
+		return rec.Share(), nil
+	}
+}
+
+// validate 检查导入记录的必填字段
+func (r *Record) validate() error {
+	switch {
+	case r.Version < 1 || r.Version > Version:
+		return fmt.Errorf("unsupported record version %d", r.Version)
+	case r.ShareID == "":
+		return errors.New("missing shareId")
+	case r.CreatedAt.IsZero():
+		return errors.New("missing created_at")
+	case r.CreatedAt.After(time.Now().Add(24 * time.Hour)):
+		return errors.New("created_at is in the future")
+	}
+	return nil
+}
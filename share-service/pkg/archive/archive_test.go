@@ -0,0 +1,167 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+)
+
+// testShares 覆盖 tar 格式中代码的各种存放方式
+func testShares() []*models.Share {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 123000000, time.UTC)
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []*models.Share{
+		{ShareID: "file", Code: "package main\n\nfunc main() {}\n", CreatedAt: created, Views: 42},
+		{ShareID: "nonewline", Code: "package main\n\nfunc main() {}", CreatedAt: created, ExpiresAt: &expires, Views: 7},
+		{ShareID: "multi", Code: "// leading comment\npackage main\n-- go.mod --\nmodule demo\n-- util/util.go --\npackage util\n", CreatedAt: created, ExpiresAt: &expires, Views: 3},
+		{ShareID: "multinoheader", Code: "-- a.go --\npackage a\n-- b.go --\npackage b\n", CreatedAt: created},
+		// 最后一个文件缺少结尾换行，重新格式化无法原样还原
+		{ShareID: "multinonewline", Code: "package main\n-- go.mod --\nmodule demo", CreatedAt: created, Views: 1},
+		{ShareID: "empty", Code: "", CreatedAt: created, ExpiresAt: &expires},
+		{ShareID: "meta", Code: "x\n", CreatedAt: created, Views: 9, Title: "t", PasswordHash: "hash", OwnerKeyHash: "owner", MaxViews: 10, Tags: []string{"a", "b"}, Hidden: true},
+	}
+}
+
+func writeArchive(t *testing.T, format Format, shares []*models.Share, compress bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&buf)
+		out = zw
+	}
+	w, err := NewWriter(out, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range shares {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("write %s: %v", s.ShareID, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func readArchive(t *testing.T, data []byte, format Format) []*models.Share {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shares []*models.Share
+	for {
+		s, err := r.Next()
+		if err == io.EOF {
+			return shares
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, s)
+	}
+}
+
+func assertSameShares(t *testing.T, got, want []*models.Share) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("read %d shares, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.ShareID != w.ShareID || g.Code != w.Code || g.Views != w.Views || !g.CreatedAt.Equal(w.CreatedAt) {
+			t.Errorf("share %s = %+v, want %+v", w.ShareID, g, w)
+		}
+		if (g.ExpiresAt == nil) != (w.ExpiresAt == nil) || (w.ExpiresAt != nil && !g.ExpiresAt.Equal(*w.ExpiresAt)) {
+			t.Errorf("share %s expires at %v, want %v", w.ShareID, g.ExpiresAt, w.ExpiresAt)
+		}
+		if g.Title != w.Title || g.PasswordHash != w.PasswordHash || g.OwnerKeyHash != w.OwnerKeyHash ||
+			g.MaxViews != w.MaxViews || len(g.Tags) != len(w.Tags) || g.Hidden != w.Hidden {
+			t.Errorf("share %s metadata = %+v, want %+v", w.ShareID, g, w)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatNDJSON, FormatTar} {
+		for _, compress := range []bool{false, true} {
+			data := writeArchive(t, format, testShares(), compress)
+			// 格式和压缩都应能自动识别
+			for _, readFormat := range []Format{format, ""} {
+				name := string(format)
+				if compress {
+					name += "/gzip"
+				}
+				if readFormat == "" {
+					name += "/detect"
+				}
+				t.Run(name, func(t *testing.T) {
+					assertSameShares(t, readArchive(t, data, readFormat), testShares())
+				})
+			}
+		}
+	}
+}
+
+func TestTxtarLayout(t *testing.T) {
+	want := map[string]Layout{
+		"file":           LayoutFile,
+		"nonewline":      LayoutInline,
+		"multi":          LayoutTxtar,
+		"multinoheader":  LayoutTxtar,
+		"multinonewline": LayoutInline,
+		"empty":          LayoutFile,
+	}
+	for _, s := range testShares() {
+		layout, ok := want[s.ShareID]
+		if !ok {
+			continue
+		}
+		rec := NewRecord(s)
+		data, err := marshalTxtar(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Layout != layout {
+			t.Errorf("%s: layout %q, want %q", s.ShareID, rec.Layout, layout)
+		}
+		back, err := unmarshalTxtar(data)
+		if err != nil {
+			t.Fatalf("%s: %v", s.ShareID, err)
+		}
+		if back.Code != s.Code || back.Layout != "" {
+			t.Errorf("%s: code %q, want %q", s.ShareID, back.Code, s.Code)
+		}
+	}
+}
+
+func TestReaderRejectsInvalidRecords(t *testing.T) {
+	tests := []string{
+		`{"v":1,"created_at":"2024-01-01T00:00:00Z"}`,
+		`{"v":1,"shareId":"x"}`,
+		`{"v":99,"shareId":"x","created_at":"2024-01-01T00:00:00Z"}`,
+		`{"v":1,"shareId":"x","created_at":"2024-01-01T00:00:00Z","unknown":true}`,
+		`{"v":1,"shareId":"x","created_at":"2999-01-01T00:00:00Z"}`,
+	}
+	for _, data := range tests {
+		r, err := NewReader(bytes.NewReader([]byte(data+"\n")), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Next(); err == nil || err == io.EOF {
+			t.Errorf("record %s: error = %v, want a validation error", data, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package archive 实现分享的导出和导入格式，用于在环境之间迁移数据和离线备份。
+//
+// 支持两种格式：
+//   - ndjson：每行一个 JSON 记录，包含分享的全部字段
+//   - tar：每个分享对应一个 shares/<shareId>.txtar 文件，注释部分为 JSON 元数据，文件部分为代码
+//
+// 两种格式都保留 ShareID、创建时间、过期时间、访问次数以及密码哈希、所有者等信息，
+// 导入后原有链接和所有者密钥继续有效。按天聚合的访问统计、举报记录、评论和收藏不包括在内。
+package archive
+
+import (
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+)
+
+// Version 是当前的归档记录版本
+const Version = 1
+
+// Record 代表归档中的一个分享，字段与 models.Share 对应，但不包含存储内部的 ID
+type Record struct {
+	Version      int                 `json:"v"`
+	ShareID      string              `json:"shareId"`
+	Code         string              `json:"code,omitempty"`
+	Layout       Layout              `json:"layout,omitempty"` // 仅 tar 格式使用，表示代码在 txtar 中的存放方式
+	Language     string              `json:"language"`
+	GoVersion    string              `json:"version"`
+	Title        string              `json:"title,omitempty"`
+	Description  string              `json:"description,omitempty"`
+	Author       string              `json:"author,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty"`
+	Views        int64               `json:"views"`
+	LastViewed   *time.Time          `json:"last_viewed,omitempty"`
+	ContentHash  string              `json:"content_hash,omitempty"`
+	LastRun      *models.RunResult   `json:"last_run,omitempty"`
+	Visibility   models.Visibility   `json:"visibility,omitempty"`
+	PasswordHash string              `json:"password_hash,omitempty"`
+	OwnerKeyHash string              `json:"owner_key_hash,omitempty"`
+	MaxViews     int64               `json:"max_views,omitempty"`
+	Exhausted    bool                `json:"exhausted,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	OwnerID      string              `json:"owner_id,omitempty"`
+	CreatorIP    string              `json:"creator_ip,omitempty"`
+	Hidden       bool                `json:"hidden,omitempty"`
+	HiddenReason string              `json:"hidden_reason,omitempty"`
+	Origin       *models.ShareOrigin `json:"origin,omitempty"`
+}
+
+// NewRecord 从分享生成归档记录
+func NewRecord(s *models.Share) *Record {
+	return &Record{
+		Version:      Version,
+		ShareID:      s.ShareID,
+		Code:         s.Code,
+		Language:     s.Language,
+		GoVersion:    s.Version,
+		Title:        s.Title,
+		Description:  s.Description,
+		Author:       s.Author,
+		CreatedAt:    s.CreatedAt,
+		ExpiresAt:    s.ExpiresAt,
+		Views:        s.Views,
+		LastViewed:   s.LastViewed,
+		ContentHash:  s.ContentHash,
+		LastRun:      s.LastRun,
+		Visibility:   s.Visibility,
+		PasswordHash: s.PasswordHash,
+		OwnerKeyHash: s.OwnerKeyHash,
+		MaxViews:     s.MaxViews,
+		Exhausted:    s.Exhausted,
+		Tags:         s.Tags,
+		OwnerID:      s.OwnerID,
+		CreatorIP:    s.CreatorIP,
+		Hidden:       s.Hidden,
+		HiddenReason: s.HiddenReason,
+		Origin:       s.Origin,
+	}
+}
+
+// Share 将归档记录还原为分享
+func (r *Record) Share() *models.Share {
+	return &models.Share{
+		ShareID:      r.ShareID,
+		Code:         r.Code,
+		Language:     r.Language,
+		Version:      r.GoVersion,
+		Title:        r.Title,
+		Description:  r.Description,
+		Author:       r.Author,
+		CreatedAt:    r.CreatedAt,
+		ExpiresAt:    r.ExpiresAt,
+		Views:        r.Views,
+		LastViewed:   r.LastViewed,
+		ContentHash:  r.ContentHash,
+		LastRun:      r.LastRun,
+		Visibility:   r.Visibility,
+		PasswordHash: r.PasswordHash,
+		OwnerKeyHash: r.OwnerKeyHash,
+		MaxViews:     r.MaxViews,
+		Exhausted:    r.Exhausted,
+		Tags:         r.Tags,
+		OwnerID:      r.OwnerID,
+		CreatorIP:    r.CreatorIP,
+		Hidden:       r.Hidden,
+		HiddenReason: r.HiddenReason,
+		Origin:       r.Origin,
+	}
+}
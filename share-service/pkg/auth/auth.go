@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// 令牌明文前缀，便于识别令牌类型以及被密钥扫描工具发现
+	sessionPrefix = "pgs_"
+	apiKeyPrefix  = "pgk_"
+
+	// bcrypt 只使用密码的前 72 字节
+	MaxPasswordLength = 72
+	MinPasswordLength = 8
+)
+
+var (
+	// ErrInvalidUsername 表示用户名格式不合法
+	ErrInvalidUsername = errors.New("username must be 3-32 characters of letters, digits, '_', '.' or '-'")
+	// ErrInvalidPassword 表示密码长度不合法
+	ErrInvalidPassword = fmt.Errorf("password must be %d-%d bytes", MinPasswordLength, MaxPasswordLength)
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,31}$`)
+
+// NormalizeUsername 将用户名统一为小写并校验格式
+func NormalizeUsername(username string) (string, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if !usernamePattern.MatchString(username) {
+		return "", ErrInvalidUsername
+	}
+	return username, nil
+}
+
+// ValidatePassword 校验密码长度
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码是否与哈希匹配
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewUser 创建新用户对象，password 为空时不设置密码（例如通过单点登录创建的用户）
+func NewUser(username, password string) (*models.User, error) {
+	user := &models.User{
+		UserID:    uuid.NewString(),
+		Username:  username,
+		CreatedAt: time.Now(),
+	}
+	if password != "" {
+		hash, err := HashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+	return user, nil
+}
+
+// NewToken 为用户生成新的访问令牌，返回令牌明文和待保存的令牌记录
+func NewToken(userId string, kind models.TokenKind, name string, ttl time.Duration) (string, *models.Token, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+
+	prefix := sessionPrefix
+	if kind == models.TokenAPIKey {
+		prefix = apiKeyPrefix
+	}
+	plain := prefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	token := &models.Token{
+		TokenID:   uuid.NewString(),
+		UserID:    userId,
+		Kind:      kind,
+		Name:      name,
+		Prefix:    plain[:len(prefix)+6],
+		Hash:      HashToken(plain),
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := token.CreatedAt.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+	return plain, token, nil
+}
+
+// HashToken 计算令牌明文的哈希，令牌本身具有足够的熵，无需加盐
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
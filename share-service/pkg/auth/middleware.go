@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	// APIKeyHeader 是携带 API Key 的请求头，也可以使用 Authorization: Bearer
+	APIKeyHeader = "X-API-Key"
+
+	// SessionCookie 是单点登录后保存会话令牌的 Cookie，仅在请求未携带请求头凭证时使用
+	SessionCookie = "pg_session"
+
+	contextUserKey  = "auth.user"
+	contextTokenKey = "auth.token"
+
+	// 令牌最后使用时间的更新间隔，避免每个请求都写数据库
+	touchInterval = time.Minute
+)
+
+// Authenticator 负责从请求中识别用户
+type Authenticator struct {
+	store       storage.Storage
+	adminUsers  map[string]bool // 通过配置指定的管理员 userId
+	adminGroups map[string]bool // 成员均为管理员的用户组
+}
+
+// Option 用于配置 Authenticator
+type Option func(*Authenticator)
+
+// WithAdmins 将指定 userId 的用户以及指定用户组的成员视为管理员。
+// 用户名可以被任何人注册或由身份提供方下发，不能用来识别管理员
+func WithAdmins(userIDs, groups []string) Option {
+	return func(a *Authenticator) {
+		for _, id := range userIDs {
+			a.adminUsers[id] = true
+		}
+		for _, group := range groups {
+			a.adminGroups[group] = true
+		}
+	}
+}
+
+// New 创建认证器
+func New(store storage.Storage, opts ...Option) *Authenticator {
+	a := &Authenticator{
+		store:       store,
+		adminUsers:  make(map[string]bool),
+		adminGroups: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Middleware 识别请求携带的会话令牌或 API Key，并将用户保存到上下文中
+// 未携带凭证的请求按匿名用户继续处理，请求头中携带了无效凭证时返回 401
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential, fromCookie := credentialFromRequest(c)
+		if credential == "" {
+			c.Next()
+			return
+		}
+
+		user, token, err := a.Resolve(c, credential)
+		if err != nil {
+			fmt.Printf("failed to resolve credential: %v\n", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
+			return
+		}
+		if user == nil {
+			if fromCookie {
+				// 会话 Cookie 过期后按匿名用户处理，并清除该 Cookie
+				ClearSessionCookie(c)
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired credentials"})
+			return
+		}
+
+		c.Set(contextUserKey, user)
+		c.Set(contextTokenKey, token)
+		c.Next()
+	}
+}
+
+// Resolve 根据令牌明文查找用户，令牌无效时返回 nil
+func (a *Authenticator) Resolve(c *gin.Context, credential string) (*models.User, *models.Token, error) {
+	ctx := c.Request.Context()
+	token, err := a.store.GetTokenByHash(ctx, HashToken(credential))
+	if err != nil || token == nil {
+		return nil, nil, err
+	}
+
+	user, err := a.store.GetUserByID(ctx, token.UserID)
+	if err != nil || user == nil {
+		return nil, nil, err
+	}
+
+	// 数据库中标记的管理员以外，配置中的管理员用户和用户组成员也视为管理员
+	if !user.Admin {
+		user.Admin = a.isAdmin(user)
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		if err := a.store.TouchToken(ctx, token.TokenID, now); err != nil {
+			fmt.Printf("failed to touch token: %v\n", err)
+		}
+	}
+	return user, token, nil
+}
+
+func (a *Authenticator) isAdmin(user *models.User) bool {
+	if a.adminUsers[user.UserID] {
+		return true
+	}
+	for _, group := range user.Groups {
+		if a.adminGroups[group] {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAdmin 要求请求必须来自管理员，需要放在 Middleware 之后
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		if !user.Admin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin 判断当前请求是否来自管理员
+func IsAdmin(c *gin.Context) bool {
+	user := CurrentUser(c)
+	return user != nil && user.Admin
+}
+
+// RequireUser 要求请求必须已认证，需要放在 Middleware 之后
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentUser(c) == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser 返回当前请求的用户，匿名请求返回 nil
+func CurrentUser(c *gin.Context) *models.User {
+	if v, ok := c.Get(contextUserKey); ok {
+		if user, ok := v.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// CurrentToken 返回当前请求使用的令牌，匿名请求返回 nil
+func CurrentToken(c *gin.Context) *models.Token {
+	if v, ok := c.Get(contextTokenKey); ok {
+		if token, ok := v.(*models.Token); ok {
+			return token
+		}
+	}
+	return nil
+}
+
+// credentialFromRequest 从 Authorization 或 X-API-Key 请求头中读取凭证，
+// 都未携带时读取会话 Cookie，第二个返回值表示凭证是否来自 Cookie
+func credentialFromRequest(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, value, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(value), false
+		}
+	}
+	if key := strings.TrimSpace(c.GetHeader(APIKeyHeader)); key != "" {
+		return key, false
+	}
+	if cookie, err := c.Cookie(SessionCookie); err == nil && cookie != "" {
+		return cookie, true
+	}
+	return "", false
+}
+
+// SetSessionCookie 将会话令牌写入 Cookie
+// Cookie 使用 SameSite=Lax，跨站点发起的 POST 等请求不会携带该 Cookie
+func SetSessionCookie(c *gin.Context, token string, ttl time.Duration) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookie, token, int(ttl.Seconds()), "/", "", IsSecureRequest(c), true)
+}
+
+// ClearSessionCookie 清除会话 Cookie
+func ClearSessionCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookie, "", -1, "/", "", IsSecureRequest(c), true)
+}
+
+// IsSecureRequest 判断请求是否通过 HTTPS 到达，兼容 nginx 反向代理，
+// 决定会话 Cookie 以及登录过程中其他 Cookie 的 Secure 属性
+func IsSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+}
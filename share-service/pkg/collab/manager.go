@@ -0,0 +1,126 @@
+// Package collab 实现多人实时协作编辑会话。
+//
+// 参与者通过 WebSocket 加入会话，编辑以操作转换（OT）合并：服务端保存权威文档和修订号，
+// 客户端提交基于某个修订号的操作，服务端将其与并发的操作转换后应用，向提交者发送确认并广播给其他参与者。
+// 协议与 ot.js 的客户端状态机兼容。光标和在线参与者同样通过会话广播。
+//
+// 会话只保存在创建它的实例内存中，无人参与超过空闲时间后自动关闭；需要长期保存的内容应当生成分享快照。
+package collab
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultMaxSessions = 1000
+	DefaultMaxClients  = 20
+	DefaultMaxBytes    = 64 * 1024
+	DefaultIdleTimeout = 30 * time.Minute
+)
+
+var (
+	// ErrTooManySessions 表示本实例的会话数已达上限
+	ErrTooManySessions = errors.New("too many sessions")
+	// ErrTooLarge 表示初始代码超过文档大小上限
+	ErrTooLarge = errors.New("code is too large")
+)
+
+// Manager 管理本实例中的全部会话
+type Manager struct {
+	maxSessions int
+	maxClients  int
+	maxBytes    int
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// Option 用于配置 Manager
+type Option func(*Manager)
+
+// WithMaxSessions 设置本实例同时存在的最大会话数
+func WithMaxSessions(n int) Option {
+	return func(m *Manager) {
+		m.maxSessions = n
+	}
+}
+
+// WithMaxClients 设置每个会话的最大参与者数
+func WithMaxClients(n int) Option {
+	return func(m *Manager) {
+		m.maxClients = n
+	}
+}
+
+// WithMaxBytes 设置文档的最大字节数，与分享代码的大小限制一致，最大为 MaxOperationLen
+func WithMaxBytes(n int) Option {
+	return func(m *Manager) {
+		m.maxBytes = min(n, MaxOperationLen)
+	}
+}
+
+// WithIdleTimeout 设置会话无人参与后保留的时间
+func WithIdleTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.idleTimeout = d
+	}
+}
+
+// NewManager 创建 Manager
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{
+		maxSessions: DefaultMaxSessions,
+		maxClients:  DefaultMaxClients,
+		maxBytes:    DefaultMaxBytes,
+		idleTimeout: DefaultIdleTimeout,
+		sessions:    make(map[string]*Session),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Create 以给定的代码和 Go 版本创建会话，会话 ID 同时是加入会话的凭据
+func (m *Manager) Create(code, version string) (*Session, error) {
+	if len(code) > m.maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sessions) >= m.maxSessions {
+		return nil, ErrTooManySessions
+	}
+	s := &Session{
+		ID:        uuid.New().String(),
+		CreatedAt: time.Now(),
+		manager:   m,
+		doc:       utf16.Encode([]rune(code)),
+		version:   version,
+		clients:   make(map[string]*Client),
+	}
+	// 创建后无人加入的会话同样在空闲时间后关闭
+	s.idle = time.AfterFunc(m.idleTimeout, s.expireIfIdle)
+	m.sessions[s.ID] = s
+	return s, nil
+}
+
+// Get 返回会话，不存在或已关闭时返回 nil
+func (m *Manager) Get(id string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions[id]
+}
+
+func (m *Manager) remove(id string) {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+}
@@ -0,0 +1,270 @@
+package collab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ErrLengthMismatch 表示操作的基准长度与文档或另一个操作不一致
+var ErrLengthMismatch = errors.New("operation length does not match the document")
+
+// MaxOperationLen 是解析 JSON 时操作前后文档长度的上限，也是文档大小上限的最大值，
+// 防止客户端提交极大的保留或删除长度使长度计算溢出
+const MaxOperationLen = 1 << 24
+
+// component 是操作中的一段：n > 0 保留 n 个单位，n < 0 删除 -n 个单位，n == 0 插入 ins
+type component struct {
+	n   int
+	ins []uint16
+}
+
+// Operation 是对整个文档的一次编辑，由依次作用于文档的保留、插入和删除组成。
+// 位置和长度以 UTF-16 代码单元计算，与浏览器中编辑器的偏移一致。
+//
+// JSON 格式与 ot.js 相同：正整数表示保留，负整数表示删除，字符串表示插入，
+// 例如在长度为 10 的文档第 3 个单位后插入 "x" 并删除末尾 2 个单位为 [3, "x", 5, -2]。
+type Operation struct {
+	ops       []component
+	baseLen   int // 操作前文档的长度
+	targetLen int // 操作后文档的长度
+}
+
+// Retain 保留 n 个单位
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	o.targetLen += n
+	if last := len(o.ops) - 1; last >= 0 && o.ops[last].n > 0 {
+		o.ops[last].n += n
+		return o
+	}
+	o.ops = append(o.ops, component{n: n})
+	return o
+}
+
+// Insert 在当前位置插入 s
+func (o *Operation) Insert(s []uint16) *Operation {
+	if len(s) == 0 {
+		return o
+	}
+	o.targetLen += len(s)
+	last := len(o.ops) - 1
+	switch {
+	case last >= 0 && o.ops[last].n == 0:
+		o.ops[last].ins = append(o.ops[last].ins, s...)
+	case last >= 0 && o.ops[last].n < 0:
+		// 相邻的插入和删除总是插入在前，保证等价的操作只有一种表示
+		if last > 0 && o.ops[last-1].n == 0 {
+			o.ops[last-1].ins = append(o.ops[last-1].ins, s...)
+		} else {
+			o.ops = append(o.ops, o.ops[last])
+			o.ops[last] = component{ins: append([]uint16(nil), s...)}
+		}
+	default:
+		o.ops = append(o.ops, component{ins: append([]uint16(nil), s...)})
+	}
+	return o
+}
+
+// Delete 删除 n 个单位
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	if last := len(o.ops) - 1; last >= 0 && o.ops[last].n < 0 {
+		o.ops[last].n -= n
+		return o
+	}
+	o.ops = append(o.ops, component{n: -n})
+	return o
+}
+
+// BaseLen 返回操作要求的文档长度
+func (o *Operation) BaseLen() int { return o.baseLen }
+
+// TargetLen 返回操作后的文档长度
+func (o *Operation) TargetLen() int { return o.targetLen }
+
+// IsNoop 判断操作是否不改变文档
+func (o *Operation) IsNoop() bool {
+	return len(o.ops) == 0 || (len(o.ops) == 1 && o.ops[0].n > 0)
+}
+
+// Apply 将操作作用于文档，返回新文档
+func (o *Operation) Apply(doc []uint16) ([]uint16, error) {
+	if len(doc) != o.baseLen {
+		return nil, ErrLengthMismatch
+	}
+	out := make([]uint16, 0, o.targetLen)
+	i := 0
+	for _, c := range o.ops {
+		switch {
+		case c.n > 0:
+			if c.n > len(doc)-i {
+				return nil, ErrLengthMismatch
+			}
+			out = append(out, doc[i:i+c.n]...)
+			i += c.n
+		case c.n < 0:
+			if -c.n > len(doc)-i {
+				return nil, ErrLengthMismatch
+			}
+			i -= c.n
+		default:
+			out = append(out, c.ins...)
+		}
+	}
+	if i != len(doc) {
+		return nil, ErrLengthMismatch
+	}
+	return out, nil
+}
+
+// TransformIndex 返回文档中的位置在操作之后的新位置，用于调整光标
+func (o *Operation) TransformIndex(index int) int {
+	newIndex := index
+	for _, c := range o.ops {
+		switch {
+		case c.n > 0:
+			index -= c.n
+		case c.n < 0:
+			newIndex -= min(index, -c.n)
+			index += c.n
+		default:
+			newIndex += len(c.ins)
+		}
+		if index < 0 {
+			break
+		}
+	}
+	return newIndex
+}
+
+// Transform 转换作用于同一文档的两个并发操作 a 和 b，返回 a' 和 b'，
+// 使 b 之后应用 a' 与 a 之后应用 b' 得到相同的文档。两者在同一位置插入时 a 的内容在前。
+func Transform(a, b *Operation) (*Operation, *Operation, error) {
+	if a.baseLen != b.baseLen {
+		return nil, nil, ErrLengthMismatch
+	}
+	ap, bp := &Operation{}, &Operation{}
+	ops1, ops2 := a.ops, b.ops
+	c1, c2 := nextComponent(&ops1), nextComponent(&ops2)
+
+	for c1 != nil || c2 != nil {
+		if c1 != nil && c1.n == 0 {
+			ap.Insert(c1.ins)
+			bp.Retain(len(c1.ins))
+			c1 = nextComponent(&ops1)
+			continue
+		}
+		if c2 != nil && c2.n == 0 {
+			ap.Retain(len(c2.ins))
+			bp.Insert(c2.ins)
+			c2 = nextComponent(&ops2)
+			continue
+		}
+		if c1 == nil || c2 == nil {
+			return nil, nil, ErrLengthMismatch
+		}
+
+		switch {
+		case c1.n > 0 && c2.n > 0:
+			n := min(c1.n, c2.n)
+			ap.Retain(n)
+			bp.Retain(n)
+			c1, c2 = consume(c1, n, &ops1), consume(c2, n, &ops2)
+		case c1.n < 0 && c2.n < 0:
+			// 双方删除了同一段内容
+			n := min(-c1.n, -c2.n)
+			c1, c2 = consume(c1, n, &ops1), consume(c2, n, &ops2)
+		case c1.n < 0 && c2.n > 0:
+			n := min(-c1.n, c2.n)
+			ap.Delete(n)
+			c1, c2 = consume(c1, n, &ops1), consume(c2, n, &ops2)
+		default:
+			n := min(c1.n, -c2.n)
+			bp.Delete(n)
+			c1, c2 = consume(c1, n, &ops1), consume(c2, n, &ops2)
+		}
+	}
+	return ap, bp, nil
+}
+
+// nextComponent 取出 ops 的第一段，ops 为空时返回 nil
+func nextComponent(ops *[]component) *component {
+	if len(*ops) == 0 {
+		return nil
+	}
+	c := (*ops)[0]
+	*ops = (*ops)[1:]
+	return &c
+}
+
+// consume 从保留或删除段中消耗 n 个单位，用完时取下一段
+func consume(c *component, n int, ops *[]component) *component {
+	if c.n > 0 {
+		c.n -= n
+	} else {
+		c.n += n
+	}
+	if c.n == 0 {
+		return nextComponent(ops)
+	}
+	return c
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	parts := make([]any, 0, len(o.ops))
+	for _, c := range o.ops {
+		if c.n != 0 {
+			parts = append(parts, c.n)
+		} else {
+			parts = append(parts, string(utf16.Decode(c.ins)))
+		}
+	}
+	return json.Marshal(parts)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，操作前后的文档长度超过 MaxOperationLen 时返回错误
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	*o = Operation{}
+	for _, part := range parts {
+		if bytes.HasPrefix(part, []byte(`"`)) {
+			var s string
+			if err := json.Unmarshal(part, &s); err != nil {
+				return err
+			}
+			ins := utf16.Encode([]rune(s))
+			if len(ins) > MaxOperationLen-o.targetLen {
+				return fmt.Errorf("operation is longer than %d units", MaxOperationLen)
+			}
+			o.Insert(ins)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(part, &n); err != nil || n == 0 {
+			return fmt.Errorf("invalid operation component %s", part)
+		}
+		// 逐段检查剩余长度，避免累加时溢出
+		switch {
+		case n > 0 && n <= MaxOperationLen-max(o.baseLen, o.targetLen):
+			o.Retain(n)
+		case n < 0 && -n <= MaxOperationLen-o.baseLen:
+			o.Delete(-n)
+		default:
+			return fmt.Errorf("operation is longer than %d units", MaxOperationLen)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+)
+
+func units(s string) []uint16 { return utf16.Encode([]rune(s)) }
+
+func mustOp(t *testing.T, data string) *Operation {
+	t.Helper()
+	var op Operation
+	if err := json.Unmarshal([]byte(data), &op); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return &op
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc, op, want string
+	}{
+		{"hello world", `[5, ",", 6]`, "hello, world"},
+		{"hello world", `[6, -5, "gopher"]`, "hello gopher"},
+		{"abc", `[-3]`, ""},
+		{"", `["x"]`, "x"},
+		{"😀x", `[2, -1, "y"]`, "😀y"},
+	}
+	for _, tt := range tests {
+		got, err := mustOp(t, tt.op).Apply(units(tt.doc))
+		if err != nil {
+			t.Errorf("Apply(%q, %s): %v", tt.doc, tt.op, err)
+			continue
+		}
+		if s := string(utf16.Decode(got)); s != tt.want {
+			t.Errorf("Apply(%q, %s) = %q, want %q", tt.doc, tt.op, s, tt.want)
+		}
+	}
+}
+
+func TestApplyLengthMismatch(t *testing.T) {
+	for _, data := range []string{`[3]`, `[5, -1]`, `["x", 2]`} {
+		if _, err := mustOp(t, data).Apply(units("abcd")); !errors.Is(err, ErrLengthMismatch) {
+			t.Errorf("Apply(%s) error = %v, want ErrLengthMismatch", data, err)
+		}
+	}
+}
+
+func TestUnmarshalRejectsOverflow(t *testing.T) {
+	tests := []string{
+		// 两次极大的保留会使 baseLen 溢出为小整数
+		`[9223372036854775807, 9223372036854775807, 12]`,
+		`[-9223372036854775807, -9223372036854775807, 12]`,
+		`[16777217]`,
+		`[16777216, 1]`,
+		`[16777216, -1]`,
+		`[0]`,
+		`[1.5]`,
+		`[true]`,
+	}
+	for _, data := range tests {
+		var op Operation
+		if err := json.Unmarshal([]byte(data), &op); err == nil {
+			t.Errorf("unmarshal %s: expected an error, got base length %d", data, op.BaseLen())
+		}
+	}
+
+	op := mustOp(t, `[16777216]`)
+	if op.BaseLen() != MaxOperationLen {
+		t.Errorf("BaseLen() = %d, want %d", op.BaseLen(), MaxOperationLen)
+	}
+}
+
+func TestApplyDoesNotPanicOnCraftedOperation(t *testing.T) {
+	// 绕过 UnmarshalJSON 构造长度与各段不一致的操作，Apply 应当返回错误而不是越界
+	op := &Operation{ops: []component{{n: 8}, {n: -8}}, baseLen: 10, targetLen: 8}
+	if _, err := op.Apply(make([]uint16, 10)); !errors.Is(err, ErrLengthMismatch) {
+		t.Fatalf("Apply error = %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	data := `[3,"x",5,-2]`
+	out, err := json.Marshal(mustOp(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Errorf("Marshal = %s, want %s", out, data)
+	}
+}
+
+func TestTransformConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		doc := randomDoc(rng, rng.Intn(20))
+		a, b := randomOp(rng, len(doc)), randomOp(rng, len(doc))
+		ap, bp, err := Transform(a, b)
+		if err != nil {
+			t.Fatalf("Transform: %v", err)
+		}
+
+		afterA, err := a.Apply(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		afterB, err := b.Apply(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		left, err := bp.Apply(afterA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		right, err := ap.Apply(afterB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(utf16.Decode(left)) != string(utf16.Decode(right)) {
+			t.Fatalf("documents diverged: %q vs %q", string(utf16.Decode(left)), string(utf16.Decode(right)))
+		}
+	}
+}
+
+func randomDoc(rng *rand.Rand, n int) []uint16 {
+	doc := make([]uint16, n)
+	for i := range doc {
+		doc[i] = uint16('a' + rng.Intn(26))
+	}
+	return doc
+}
+
+// randomOp 生成作用于长度为 n 的文档的随机操作
+func randomOp(rng *rand.Rand, n int) *Operation {
+	op := &Operation{}
+	for left := n; left > 0; {
+		k := 1 + rng.Intn(left)
+		switch rng.Intn(3) {
+		case 0:
+			op.Retain(k)
+			left -= k
+		case 1:
+			op.Delete(k)
+			left -= k
+		default:
+			op.Insert(randomDoc(rng, 1+rng.Intn(3)))
+		}
+	}
+	if rng.Intn(2) == 0 {
+		op.Insert(randomDoc(rng, 1+rng.Intn(3)))
+	}
+	return op
+}
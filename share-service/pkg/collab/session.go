@@ -0,0 +1,398 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/playground/share-service/pkg/models"
+)
+
+const (
+	// 服务端保留的最近操作数，客户端基于更早的修订提交操作时需要重新加入
+	maxHistory = 1000
+	// 每个客户端待发送消息的缓冲数，缓冲满时断开该客户端，避免慢客户端拖慢整个会话
+	sendBuffer = 256
+
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+)
+
+// 客户端的光标颜色，按加入顺序轮流分配
+var colors = []string{"#e6194b", "#3cb44b", "#4363d8", "#f58231", "#911eb4", "#42d4f4", "#f032e6", "#9a6324"}
+
+var (
+	// ErrSessionFull 表示会话的参与者已达上限
+	ErrSessionFull = errors.New("session is full")
+	// ErrSessionClosed 表示会话已因长时间无人参与被关闭
+	ErrSessionClosed = errors.New("session is closed")
+)
+
+// clientMessage 是客户端发送的消息
+type clientMessage struct {
+	Type     string                `json:"type"` // op、cursor 或 version
+	Revision int                   `json:"revision"`
+	Op       *Operation            `json:"op,omitempty"`
+	Cursor   *models.SessionCursor `json:"cursor,omitempty"`
+	Version  string                `json:"version,omitempty"`
+}
+
+// serverMessage 是服务端发送的消息，revision 总是会话当前的修订号
+type serverMessage struct {
+	Type         string                       `json:"type"` // init、ack、op、cursor、version、join、leave 或 error
+	Revision     int                          `json:"revision"`
+	ClientID     string                       `json:"client_id,omitempty"` // 消息涉及的参与者
+	Op           *Operation                   `json:"op,omitempty"`
+	Cursor       *models.SessionCursor        `json:"cursor,omitempty"`
+	Code         *string                      `json:"code,omitempty"`
+	Version      string                       `json:"version,omitempty"`
+	Participant  *models.SessionParticipant   `json:"participant,omitempty"`
+	Participants []*models.SessionParticipant `json:"participants,omitempty"`
+	Error        string                       `json:"error,omitempty"`
+}
+
+// Client 是会话中的一个 WebSocket 连接
+type Client struct {
+	participant models.SessionParticipant
+	send        chan []byte
+	closed      bool // 发送队列是否已关闭，由会话的 mu 保护
+}
+
+// NewClient 创建参与者，verified 表示 name 为登录用户的用户名
+func NewClient(id, name string, verified bool) *Client {
+	return &Client{
+		participant: models.SessionParticipant{ID: id, Name: name, Verified: verified},
+		send:        make(chan []byte, sendBuffer),
+	}
+}
+
+// close 关闭发送队列，写循环随后关闭连接，调用方持有会话的 mu。
+// 关闭后客户端可能仍留在会话中直到读循环结束，向已关闭的队列发送会 panic，因此发送前需要检查 closed
+func (c *Client) close() {
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// Session 是一个协作编辑会话。服务端保存权威文档，客户端基于某个修订号提交操作，
+// 服务端将其与之后的操作转换后应用，确认给提交者并广播给其他参与者。
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+
+	manager *Manager
+
+	mu       sync.Mutex
+	doc      []uint16
+	version  string
+	revision int
+	history  []*Operation // 最近的操作，最后一个对应修订号 revision
+	clients  map[string]*Client
+	joined   int // 累计加入的参与者数，用于分配颜色
+	idle     *time.Timer
+	closed   bool
+}
+
+// SessionState 是会话在某一时刻的状态
+type SessionState struct {
+	Code         string
+	Version      string
+	Revision     int
+	Participants []*models.SessionParticipant
+}
+
+// State 返回会话当前的文档和参与者
+func (s *Session) State() *SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &SessionState{
+		Code:         string(utf16.Decode(s.doc)),
+		Version:      s.version,
+		Revision:     s.revision,
+		Participants: s.participants(),
+	}
+}
+
+// Serve 将客户端加入会话并处理其消息，直到连接断开
+func (s *Session) Serve(conn *websocket.Conn, c *Client) {
+	// 单条消息最多包含整个文档，JSON 转义后可能膨胀数倍
+	conn.SetReadLimit(int64(s.manager.maxBytes)*6 + 64*1024)
+	go c.writeLoop(conn)
+
+	if err := s.join(c); err != nil {
+		s.sendError(c, err)
+		s.leave(c)
+		return
+	}
+	defer s.leave(c)
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		var msg clientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if err := s.handle(c, &msg); err != nil {
+			// 客户端状态与服务端不一致，发送错误后断开，客户端重新连接即可恢复
+			s.sendError(c, err)
+			return
+		}
+	}
+}
+
+func (s *Session) handle(c *Client, msg *clientMessage) error {
+	switch msg.Type {
+	case "op":
+		if msg.Op == nil {
+			return errors.New("op is required")
+		}
+		return s.submit(c, msg.Revision, msg.Op)
+	case "cursor":
+		s.setCursor(c, msg.Cursor)
+		return nil
+	case "version":
+		return s.setVersion(c, msg.Version)
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+}
+
+func (s *Session) join(c *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSessionClosed
+	}
+	if len(s.clients) >= s.manager.maxClients {
+		return ErrSessionFull
+	}
+	s.idle.Stop()
+
+	c.participant.Color = colors[s.joined%len(colors)]
+	s.joined++
+	s.clients[c.participant.ID] = c
+
+	code := string(utf16.Decode(s.doc))
+	s.sendTo(c, &serverMessage{
+		Type:         "init",
+		Revision:     s.revision,
+		ClientID:     c.participant.ID,
+		Code:         &code,
+		Version:      s.version,
+		Participants: s.participants(),
+	})
+	participant := c.participant
+	s.broadcast(c, &serverMessage{Type: "join", Revision: s.revision, ClientID: participant.ID, Participant: &participant})
+	return nil
+}
+
+func (s *Session) leave(c *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.close()
+	if _, ok := s.clients[c.participant.ID]; !ok {
+		return
+	}
+	delete(s.clients, c.participant.ID)
+	s.broadcast(nil, &serverMessage{Type: "leave", Revision: s.revision, ClientID: c.participant.ID})
+	if len(s.clients) == 0 && !s.closed {
+		s.idle.Reset(s.manager.idleTimeout)
+	}
+}
+
+// submit 将客户端基于 revision 提交的操作转换到最新修订并应用
+func (s *Session) submit(c *Client, revision int, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := s.revision - len(s.history)
+	if revision < base || revision > s.revision {
+		return fmt.Errorf("revision %d is not available, rejoin the session", revision)
+	}
+	for _, concurrent := range s.history[revision-base:] {
+		var err error
+		if op, _, err = Transform(op, concurrent); err != nil {
+			return err
+		}
+	}
+
+	// UTF-16 代码单元数不超过 UTF-8 字节数，目标长度超过上限时无需应用
+	if op.TargetLen() > s.manager.maxBytes {
+		return fmt.Errorf("document must be at most %d bytes", s.manager.maxBytes)
+	}
+	doc, err := op.Apply(s.doc)
+	if err != nil {
+		return err
+	}
+	if n := utf8Len(doc); n > s.manager.maxBytes {
+		return fmt.Errorf("document must be at most %d bytes (got %d)", s.manager.maxBytes, n)
+	}
+
+	s.doc = doc
+	s.revision++
+	s.history = append(s.history, op)
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+	for _, other := range s.clients {
+		if cur := other.participant.Cursor; cur != nil {
+			cur.Position = op.TransformIndex(cur.Position)
+			cur.Anchor = op.TransformIndex(cur.Anchor)
+		}
+	}
+
+	s.sendTo(c, &serverMessage{Type: "ack", Revision: s.revision})
+	s.broadcast(c, &serverMessage{Type: "op", Revision: s.revision, ClientID: c.participant.ID, Op: op})
+	return nil
+}
+
+// setCursor 更新客户端的光标并广播，cursor 为 nil 表示清除光标。
+// 客户端可能尚未收到最新的操作，超出文档的位置截断到文档末尾
+func (s *Session) setCursor(c *Client, cursor *models.SessionCursor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cursor != nil {
+		cursor = &models.SessionCursor{
+			Position: max(0, min(cursor.Position, len(s.doc))),
+			Anchor:   max(0, min(cursor.Anchor, len(s.doc))),
+		}
+	}
+	c.participant.Cursor = cursor
+	s.broadcast(c, &serverMessage{Type: "cursor", Revision: s.revision, ClientID: c.participant.ID, Cursor: cursor})
+}
+
+// setVersion 修改会话使用的 Go 版本
+func (s *Session) setVersion(c *Client, version string) error {
+	normalized, ok := models.NormalizeVersion(version)
+	if !ok {
+		return fmt.Errorf("unsupported version %q", version)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = normalized
+	s.broadcast(nil, &serverMessage{Type: "version", Revision: s.revision, ClientID: c.participant.ID, Version: normalized})
+	return nil
+}
+
+// expireIfIdle 在无人参与的会话超时后关闭会话
+func (s *Session) expireIfIdle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.clients) > 0 || s.closed {
+		return
+	}
+	s.closed = true
+	s.manager.remove(s.ID)
+}
+
+func (s *Session) participants() []*models.SessionParticipant {
+	list := make([]*models.SessionParticipant, 0, len(s.clients))
+	for _, c := range s.clients {
+		p := c.participant
+		if p.Cursor != nil {
+			cursor := *p.Cursor
+			p.Cursor = &cursor
+		}
+		list = append(list, &p)
+	}
+	return list
+}
+
+// broadcast 向除 except 外的全部参与者发送消息，调用方持有 s.mu
+func (s *Session) broadcast(except *Client, msg *serverMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	for _, c := range s.clients {
+		if c != except {
+			s.enqueue(c, data)
+		}
+	}
+}
+
+// sendTo 向单个参与者发送消息，调用方持有 s.mu
+func (s *Session) sendTo(c *Client, msg *serverMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.enqueue(c, data)
+}
+
+// enqueue 将消息放入客户端的发送队列，调用方持有 s.mu
+func (s *Session) enqueue(c *Client, data []byte) {
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		// 缓冲已满，断开该客户端，读循环结束后将其移出会话
+		c.close()
+	}
+}
+
+// sendError 发送错误消息，只在客户端尚未加入会话或即将断开时调用
+func (s *Session) sendError(c *Client, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendTo(c, &serverMessage{Type: "error", Revision: s.revision, Error: err.Error()})
+}
+
+// writeLoop 发送队列中的消息并定期发送 ping，队列关闭后关闭连接
+func (c *Client) writeLoop(conn *websocket.Conn) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+	for {
+		select {
+		case data, ok := <-c.send:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// utf8Len 返回 UTF-16 文档编码为 UTF-8 后的字节数
+func utf8Len(doc []uint16) int {
+	n := 0
+	for i := 0; i < len(doc); i++ {
+		r := rune(doc[i])
+		if utf16.IsSurrogate(r) && i+1 < len(doc) {
+			if dec := utf16.DecodeRune(r, rune(doc[i+1])); dec != utf8.RuneError {
+				r = dec
+				i++
+			}
+		}
+		l := utf8.RuneLen(r)
+		if l < 0 {
+			l = utf8.RuneLen(utf8.RuneError) // 孤立的代理项解码为 U+FFFD
+		}
+		n += l
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package collab
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/playground/share-service/pkg/models"
+)
+
+// drain 取出客户端队列中已有的消息，返回消息类型
+func drain(c *Client) []string {
+	var types []string
+	for {
+		select {
+		case data, ok := <-c.send:
+			if !ok {
+				return types
+			}
+			var msg serverMessage
+			json.Unmarshal(data, &msg)
+			types = append(types, msg.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestSlowClientIsDisconnected(t *testing.T) {
+	s, err := NewManager().Create("hello", "go1.24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, fast := NewClient("slow", "slow", false), NewClient("fast", "fast", false)
+	if err := s.join(slow); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.join(fast); err != nil {
+		t.Fatal(err)
+	}
+	drain(fast)
+
+	// slow 从不读取，光标广播填满其缓冲后被断开
+	for i := 0; i <= sendBuffer; i++ {
+		s.setCursor(fast, &models.SessionCursor{Position: i % 5})
+	}
+	s.mu.Lock()
+	closed := slow.closed
+	s.mu.Unlock()
+	if !closed {
+		t.Fatal("client with a full buffer was not disconnected")
+	}
+
+	// 读循环结束前 slow 仍在会话中，之后的广播和错误消息不能向已关闭的队列发送
+	if err := s.submit(fast, 0, mustOp(t, `[5, "!"]`)); err != nil {
+		t.Fatal(err)
+	}
+	s.setCursor(fast, nil)
+	if err := s.setVersion(fast, "go1.23"); err != nil {
+		t.Fatal(err)
+	}
+	s.sendError(slow, errors.New("late error"))
+	late := NewClient("late", "late", false)
+	if err := s.join(late); err != nil {
+		t.Fatal(err)
+	}
+
+	s.leave(slow)
+	if got := drain(fast); len(got) == 0 || got[len(got)-1] != "leave" {
+		t.Errorf("fast received %v, want a final leave", got)
+	}
+	if state := s.State(); state.Code != "hello!" || len(state.Participants) != 2 {
+		t.Errorf("unexpected state %+v", state)
+	}
+}
+
+func TestJoinFullSessionClosesClient(t *testing.T) {
+	s, err := NewManager(WithMaxClients(1)).Create("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.join(NewClient("a", "a", false)); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("b", "b", false)
+	if err := s.join(c); !errors.Is(err, ErrSessionFull) {
+		t.Fatalf("join = %v, want ErrSessionFull", err)
+	}
+	s.sendError(c, ErrSessionFull)
+	s.leave(c)
+	if got := drain(c); len(got) != 1 || got[0] != "error" {
+		t.Errorf("rejected client received %v, want a single error", got)
+	}
+	if !c.closed {
+		t.Error("rejected client was not closed")
+	}
+}
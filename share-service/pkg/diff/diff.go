@@ -0,0 +1,316 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 默认的上下文行数，与 diff -u 保持一致
+const DefaultContext = 3
+
+// 比较的最大步数，大致为总行数与编辑距离之积，用于限制差异很大的长文本的计算时间
+const maxCost = 20_000_000
+
+// ErrTooComplex 表示两段文本差异过大，比较的计算量超过上限
+var ErrTooComplex = errors.New("texts differ too much to compare")
+
+// LineKind 表示差异行的类型
+type LineKind string
+
+const (
+	Equal  LineKind = "context"
+	Insert LineKind = "insert"
+	Delete LineKind = "delete"
+)
+
+// Line 代表差异中的一行
+type Line struct {
+	Kind    LineKind `json:"kind"`
+	Text    string   `json:"text"`
+	OldLine int      `json:"old_line,omitempty"` // 在旧文本中的行号（从1开始）
+	NewLine int      `json:"new_line,omitempty"` // 在新文本中的行号（从1开始）
+
+	// 该行之前两侧已消耗的行数，用于计算差异块的起始位置
+	oldAt, newAt int
+}
+
+// Hunk 代表一个差异块
+type Hunk struct {
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Result 代表两段文本的差异结果
+type Result struct {
+	Hunks      []Hunk `json:"hunks"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+}
+
+// Equal 判断两段文本是否完全一致
+func (r *Result) Equal() bool {
+	return r.Insertions == 0 && r.Deletions == 0
+}
+
+// Compute 按行比较两段文本，context 为每个差异块保留的上下文行数，
+// 差异过大时返回 ErrTooComplex
+func Compute(oldText, newText string, context int) (*Result, error) {
+	if context < 0 {
+		context = DefaultContext
+	}
+
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	lines, err := myers(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Hunks: []Hunk{}}
+	for _, l := range lines {
+		switch l.Kind {
+		case Insert:
+			res.Insertions++
+		case Delete:
+			res.Deletions++
+		}
+	}
+	if res.Equal() {
+		return res, nil
+	}
+
+	// 将编辑脚本按上下文分组为差异块
+	i := 0
+	for i < len(lines) {
+		// 找到下一个变更
+		for i < len(lines) && lines[i].Kind == Equal {
+			i++
+		}
+		if i >= len(lines) {
+			break
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Kind != Equal {
+				end++
+				continue
+			}
+			// 统计连续的相同行，超过两倍上下文则结束当前块
+			run := 0
+			for end+run < len(lines) && lines[end+run].Kind == Equal {
+				run++
+			}
+			if end+run >= len(lines) || run > 2*context {
+				end += min(run, context)
+				break
+			}
+			end += run
+		}
+
+		res.Hunks = append(res.Hunks, newHunk(lines[start:end]))
+		i = end
+	}
+
+	return res, nil
+}
+
+// Unified 生成统一格式（diff -u）的差异文本
+func (r *Result) Unified(oldName, newName string) string {
+	if r.Equal() {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", oldName)
+	fmt.Fprintf(&sb, "+++ %s\n", newName)
+	for _, h := range r.Hunks {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case Insert:
+				sb.WriteByte('+')
+			case Delete:
+				sb.WriteByte('-')
+			default:
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(l.Text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func newHunk(lines []Line) Hunk {
+	h := Hunk{Lines: lines}
+	for _, l := range lines {
+		if l.Kind != Insert {
+			h.OldLines++
+		}
+		if l.Kind != Delete {
+			h.NewLines++
+		}
+	}
+
+	// 空范围时起始行号指向前一行，与 diff -u 的约定一致
+	h.OldStart = lines[0].oldAt
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	h.NewStart = lines[0].newAt
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	return strings.Split(s, "\n")
+}
+
+// myers 使用线性空间的 Myers 差分算法计算最短编辑脚本：
+// 每次找出最短编辑路径中间的一段对角线（middle snake），再分别递归处理两侧，
+// 内存与行数成正比。比较的总步数超过 maxCost 时返回 ErrTooComplex
+func myers(a, b []string) ([]Line, error) {
+	size := 2*((len(a)+len(b)+1)/2) + 3
+	d := &differ{a: a, b: b, vf: make([]int, size), vb: make([]int, size)}
+	if err := d.compare(0, len(a), 0, len(b)); err != nil {
+		return nil, err
+	}
+	return d.lines, nil
+}
+
+type differ struct {
+	a, b   []string
+	vf, vb []int // 正向和反向搜索中每条对角线到达的最远位置
+	cost   int
+	lines  []Line
+}
+
+// compare 生成 a[a0:a1] 与 b[b0:b1] 的编辑脚本
+func (d *differ) compare(a0, a1, b0, b1 int) error {
+	// 去掉相同的前缀和后缀
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.equal(a0, b0)
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-1-suffix] == d.b[b1-1-suffix] {
+		suffix++
+	}
+	a1 -= suffix
+	b1 -= suffix
+
+	switch {
+	case a0 == a1:
+		for y := b0; y < b1; y++ {
+			d.lines = append(d.lines, Line{Kind: Insert, Text: d.b[y], NewLine: y + 1, oldAt: a0, newAt: y})
+		}
+	case b0 == b1:
+		for x := a0; x < a1; x++ {
+			d.lines = append(d.lines, Line{Kind: Delete, Text: d.a[x], OldLine: x + 1, oldAt: x, newAt: b0})
+		}
+	default:
+		// 去掉前后缀后两侧都不为空时编辑距离至少为 2，中间对角线两侧的编辑距离都严格变小
+		x, y, u, v, err := d.middleSnake(a0, a1, b0, b1)
+		if err != nil {
+			return err
+		}
+		if err := d.compare(a0, x, b0, y); err != nil {
+			return err
+		}
+		for ; x < u; x, y = x+1, y+1 {
+			d.equal(x, y)
+		}
+		if err := d.compare(u, a1, v, b1); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.equal(a1+i, b1+i)
+	}
+	return nil
+}
+
+// middleSnake 同时从两端搜索最短编辑路径，返回两端路径相遇处的对角线 (x, y) 到 (u, v)
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (int, int, int, int, error) {
+	n, m := a1-a0, b1-b0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	delta := n - m
+	odd := delta%2 != 0
+	d.vf[off+1], d.vb[off+1] = 0, 0
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && d.vf[off+k-1] < d.vf[off+k+1]) {
+				x = d.vf[off+k+1]
+			} else {
+				x = d.vf[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			d.vf[off+k] = x
+			if d.cost += x - x0 + 1; d.cost > maxCost {
+				return 0, 0, 0, 0, ErrTooComplex
+			}
+			// 编辑距离为奇数时在正向搜索中检查与上一轮反向路径的重叠
+			if odd && k >= delta-(step-1) && k <= delta+(step-1) && x+d.vb[off+delta-k] >= n {
+				return a0 + x0, b0 + y0, a0 + x, b0 + y, nil
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && d.vb[off+k-1] < d.vb[off+k+1]) {
+				x = d.vb[off+k+1]
+			} else {
+				x = d.vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[a1-1-x] == d.b[b1-1-y] {
+				x++
+				y++
+			}
+			d.vb[off+k] = x
+			if d.cost += x - x0 + 1; d.cost > maxCost {
+				return 0, 0, 0, 0, ErrTooComplex
+			}
+			// 编辑距离为偶数时在反向搜索中检查与本轮正向路径的重叠
+			if !odd && delta-k >= -step && delta-k <= step && x+d.vf[off+delta-k] >= n {
+				return a1 - x, b1 - y, a1 - x0, b1 - y0, nil
+			}
+		}
+	}
+	// 两个方向的搜索最多各走一半编辑距离就会相遇
+	panic("diff: middle snake not found")
+}
+
+func (d *differ) equal(x, y int) {
+	d.lines = append(d.lines, Line{Kind: Equal, Text: d.a[x], OldLine: x + 1, NewLine: y + 1, oldAt: x, newAt: y})
+}
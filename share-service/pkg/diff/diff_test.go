@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	oldText := "package main\n\nfunc main() {\n\tprintln(1)\n}\n"
+	newText := "package main\n\nfunc main() {\n\tprintln(2)\n}\n"
+	res, err := Compute(oldText, newText, DefaultContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a\n+++ b\n@@ -1,5 +1,5 @@\n package main\n \n func main() {\n-\tprintln(1)\n+\tprintln(2)\n }\n"
+	if got := res.Unified("a", "b"); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+	if res.Insertions != 1 || res.Deletions != 1 {
+		t.Errorf("got +%d -%d, want +1 -1", res.Insertions, res.Deletions)
+	}
+}
+
+func TestIdentical(t *testing.T) {
+	res, err := Compute("a\nb\n", "a\r\nb", DefaultContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal() || len(res.Hunks) != 0 || res.Unified("a", "b") != "" {
+		t.Errorf("expected no differences, got %+v", res)
+	}
+}
+
+func TestMyersIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a, b := randomLines(rng), randomLines(rng)
+		lines, err := myers(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var gotA, gotB []string
+		edits := 0
+		for _, l := range lines {
+			switch l.Kind {
+			case Equal:
+				gotA, gotB = append(gotA, l.Text), append(gotB, l.Text)
+			case Delete:
+				gotA = append(gotA, l.Text)
+				edits++
+			case Insert:
+				gotB = append(gotB, l.Text)
+				edits++
+			}
+		}
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("script for %v -> %v does not reproduce the inputs: %v", a, b, lines)
+		}
+		if want := editDistance(a, b); edits != want {
+			t.Fatalf("script for %v -> %v has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestLineNumbers(t *testing.T) {
+	lines, err := myers([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range lines {
+		if l.Kind != Insert && l.OldLine != l.oldAt+1 {
+			t.Errorf("%+v: old line does not match position", l)
+		}
+		if l.Kind != Delete && l.NewLine != l.newAt+1 {
+			t.Errorf("%+v: new line does not match position", l)
+		}
+	}
+}
+
+func TestTooComplex(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	if _, err := Compute(a.String(), b.String(), DefaultContext); !errors.Is(err, ErrTooComplex) {
+		t.Fatalf("Compute error = %v, want ErrTooComplex", err)
+	}
+}
+
+func randomLines(rng *rand.Rand) []string {
+	lines := make([]string, rng.Intn(12))
+	for i := range lines {
+		lines[i] = string(rune('a' + rng.Intn(3)))
+	}
+	return lines
+}
+
+// editDistance 使用动态规划计算只有插入和删除时的最小编辑次数
+func editDistance(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+		dp[i][0] = i
+	}
+	for j := range dp[0] {
+		dp[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1]
+			} else {
+				dp[i][j] = 1 + min(dp[i-1][j], dp[i][j-1])
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
@@ -0,0 +1,145 @@
+// Package highlight 使用 go/scanner 对 Go 源码做语法着色，供嵌入页面和预览图使用。
+// 着色只依赖词法分析，语法错误或不完整的代码同样可以处理。
+package highlight
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// Kind 表示片段的着色类别
+type Kind uint8
+
+const (
+	Plain   Kind = iota // 普通标识符、运算符和空白
+	Keyword             // 关键字
+	String              // 字符串和字符字面量
+	Number              // 数字字面量
+	Comment             // 注释
+	Builtin             // 预声明的类型、函数和常量
+)
+
+// Class 返回类别对应的 CSS 类名，Plain 返回空
+func (k Kind) Class() string {
+	switch k {
+	case Keyword:
+		return "kw"
+	case String:
+		return "str"
+	case Number:
+		return "num"
+	case Comment:
+		return "com"
+	case Builtin:
+		return "bi"
+	}
+	return ""
+}
+
+// Span 代表一段相同类别的文本，不包含换行
+type Span struct {
+	Kind Kind
+	Text string
+}
+
+// Line 代表一行代码的着色片段
+type Line []Span
+
+var builtins = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true,
+	"delete": true, "imag": true, "len": true, "make": true, "max": true, "min": true, "new": true,
+	"panic": true, "print": true, "println": true, "real": true, "recover": true,
+}
+
+// Lines 将 Go 源码切分为按行排列的着色片段，保留全部原始文本（包括空白）
+func Lines(src string) []Line {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(token.Position, string) {}, scanner.ScanComments)
+
+	var spans []Span
+	end := 0 // 上一个记号结束的位置
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// 跳过扫描器自动插入的分号
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+
+		offset := file.Offset(pos)
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		if offset < end || offset+len(text) > len(src) || src[offset:offset+len(text)] != text {
+			// 扫描器对部分非法输入返回的文本与源码不一致，交给下一个记号之间的空白处理
+			continue
+		}
+		if offset > end {
+			spans = append(spans, Span{Kind: Plain, Text: src[end:offset]})
+		}
+		spans = append(spans, Span{Kind: kindOf(tok, lit), Text: text})
+		end = offset + len(text)
+	}
+	if end < len(src) {
+		spans = append(spans, Span{Kind: Plain, Text: src[end:]})
+	}
+
+	return splitLines(spans)
+}
+
+func kindOf(tok token.Token, lit string) Kind {
+	switch {
+	case tok.IsKeyword():
+		return Keyword
+	case tok == token.STRING || tok == token.CHAR:
+		return String
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return Number
+	case tok == token.COMMENT:
+		return Comment
+	case tok == token.IDENT && builtins[lit]:
+		return Builtin
+	}
+	return Plain
+}
+
+// splitLines 按换行拆分片段，跨行的注释和原始字符串会被拆到多行中
+func splitLines(spans []Span) []Line {
+	lines := []Line{nil}
+	for _, span := range spans {
+		parts := strings.Split(span.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part == "" {
+				continue
+			}
+			cur := &lines[len(lines)-1]
+			// 合并相邻的同类片段，减少输出的标签数量
+			if n := len(*cur); n > 0 && (*cur)[n-1].Kind == span.Kind {
+				(*cur)[n-1].Text += part
+			} else {
+				*cur = append(*cur, Span{Kind: span.Kind, Text: part})
+			}
+		}
+	}
+	// 以换行结尾的源码不额外产生空行
+	if len(lines) > 1 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,108 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// 预置字母表
+const (
+	AlphabetHex    = "0123456789abcdef"
+	AlphabetBase62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+const (
+	DefaultLength = 8
+	minLength     = 4
+	maxLength     = 64
+)
+
+var (
+	// ErrInvalidSlug 表示自定义 ID 格式不合法
+	ErrInvalidSlug = errors.New("slug must be 3-64 characters of lowercase letters, digits and '-', starting with a letter or digit")
+	// ErrReservedSlug 表示自定义 ID 为保留字
+	ErrReservedSlug = errors.New("slug is reserved")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,63}$`)
+
+// 保留字，避免与路由或常见页面冲突
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "auth": true, "collection": true, "collections": true,
+	"diff": true, "download": true, "edit": true, "embed": true, "health": true,
+	"help": true, "import": true, "login": true, "logout": true, "me": true,
+	"new": true, "oembed": true, "raw": true, "share": true, "shares": true,
+	"static": true, "stats": true, "trending": true, "user": true, "users": true,
+}
+
+// Generator 生成随机分享 ID
+type Generator struct {
+	length   int
+	alphabet []byte
+}
+
+// New 创建 ID 生成器，alphabet 可以是 "hex"、"base62" 或自定义字符集
+func New(length int, alphabet string) (*Generator, error) {
+	if length == 0 {
+		length = DefaultLength
+	}
+	if length < minLength || length > maxLength {
+		return nil, fmt.Errorf("id length must be between %d and %d", minLength, maxLength)
+	}
+
+	switch strings.ToLower(alphabet) {
+	case "", "base62":
+		alphabet = AlphabetBase62
+	case "hex":
+		alphabet = AlphabetHex
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range alphabet {
+		if r > 0x7f || !isURLSafe(byte(r)) {
+			return nil, fmt.Errorf("alphabet contains non URL-safe character %q", r)
+		}
+		if seen[r] {
+			return nil, fmt.Errorf("alphabet contains duplicate character %q", r)
+		}
+		seen[r] = true
+	}
+	if len(alphabet) < 2 {
+		return nil, errors.New("alphabet must contain at least 2 characters")
+	}
+
+	return &Generator{length: length, alphabet: []byte(alphabet)}, nil
+}
+
+// Generate 生成一个新的随机 ID
+func (g *Generator) Generate() (string, error) {
+	base := big.NewInt(int64(len(g.alphabet)))
+	id := make([]byte, g.length)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", err
+		}
+		id[i] = g.alphabet[n.Int64()]
+	}
+	return string(id), nil
+}
+
+// ValidateSlug 校验用户自定义的 ID
+func ValidateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) || strings.HasSuffix(slug, "-") {
+		return ErrInvalidSlug
+	}
+	if reservedSlugs[slug] {
+		return ErrReservedSlug
+	}
+	return nil
+}
+
+func isURLSafe(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_'
+}
@@ -0,0 +1,106 @@
+package idgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		length       int
+		alphabet     string
+		wantLength   int
+		wantAlphabet string
+		wantErr      bool
+	}{
+		{length: 0, alphabet: "", wantLength: DefaultLength, wantAlphabet: AlphabetBase62},
+		{length: 12, alphabet: "HEX", wantLength: 12, wantAlphabet: AlphabetHex},
+		{length: 4, alphabet: "base62", wantLength: 4, wantAlphabet: AlphabetBase62},
+		{length: 64, alphabet: "ab-_", wantLength: 64, wantAlphabet: "ab-_"},
+		{length: 3, wantErr: true},
+		{length: 65, wantErr: true},
+		{length: -1, wantErr: true},
+		{length: 8, alphabet: "a", wantErr: true},
+		{length: 8, alphabet: "abca", wantErr: true},
+		{length: 8, alphabet: "ab/", wantErr: true},
+		{length: 8, alphabet: "ab é", wantErr: true},
+	}
+	for _, tt := range tests {
+		g, err := New(tt.length, tt.alphabet)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("New(%d, %q) succeeded, want an error", tt.length, tt.alphabet)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%d, %q): %v", tt.length, tt.alphabet, err)
+			continue
+		}
+		if g.length != tt.wantLength || string(g.alphabet) != tt.wantAlphabet {
+			t.Errorf("New(%d, %q) = %d %q, want %d %q", tt.length, tt.alphabet, g.length, g.alphabet, tt.wantLength, tt.wantAlphabet)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	g, err := New(6, "xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[byte]bool)
+	for i := 0; i < 200; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 6 || strings.Trim(id, "xyz") != "" {
+			t.Fatalf("Generate() = %q, want 6 characters from %q", id, "xyz")
+		}
+		for j := 0; j < len(id); j++ {
+			seen[id[j]] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("generated IDs used %d of 3 characters", len(seen))
+	}
+
+	g, _ = New(0, "")
+	ids := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id, _ := g.Generate()
+		if ids[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		ids[id] = true
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		want error
+	}{
+		{"hello-world", nil},
+		{"abc", nil},
+		{"2fa", nil},
+		{strings.Repeat("a", 64), nil},
+		{"ab", ErrInvalidSlug},
+		{strings.Repeat("a", 65), ErrInvalidSlug},
+		{"-abc", ErrInvalidSlug},
+		{"abc-", ErrInvalidSlug},
+		{"Hello", ErrInvalidSlug},
+		{"a_b_c", ErrInvalidSlug},
+		{"a b c", ErrInvalidSlug},
+		{"héllo", ErrInvalidSlug},
+		{"admin", ErrReservedSlug},
+		{"api", ErrReservedSlug},
+		{"trending", ErrReservedSlug},
+	}
+	for _, tt := range tests {
+		if err := ValidateSlug(tt.slug); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateSlug(%q) = %v, want %v", tt.slug, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/playground/share-service/pkg/txtar"
+)
+
+// DefaultGistAPI 是 GitHub API 的默认地址
+const DefaultGistAPI = "https://api.github.com"
+
+// Gist 通过 GitHub API 获取 Gist
+type Gist struct {
+	BaseURL string       // GitHub API 地址，为空时使用 DefaultGistAPI
+	Token   string       // 可选的访问令牌，用于提高 API 限额
+	Client  *http.Client // 为空时使用 10 秒超时的客户端
+}
+
+// gistResponse 是 GitHub API 返回的 Gist，只包含需要的字段
+type gistResponse struct {
+	ID          string `json:"id"`
+	HTMLURL     string `json:"html_url"`
+	Description string `json:"description"`
+	Owner       *struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	History []struct {
+		Version string `json:"version"`
+	} `json:"history"`
+	Files map[string]*struct {
+		Filename  string `json:"filename"`
+		Size      int    `json:"size"`
+		Truncated bool   `json:"truncated"`
+		RawURL    string `json:"raw_url"`
+		Content   string `json:"content"`
+	} `json:"files"`
+}
+
+// Fetch 实现 Fetcher 接口
+func (g *Gist) Fetch(ctx context.Context, ref *Ref) (*Snippet, error) {
+	base := strings.TrimSuffix(g.BaseURL, "/")
+	if base == "" {
+		base = DefaultGistAPI
+	}
+	endpoint := base + "/gists/" + url.PathEscape(ref.ID)
+	if ref.Revision != "" {
+		endpoint += "/" + url.PathEscape(ref.Revision)
+	}
+
+	// API 响应包含 JSON 转义和其他元数据，允许比代码本身更大
+	body, err := g.get(ctx, endpoint, "application/vnd.github+json", 4*MaxSnippetBytes)
+	if err != nil {
+		return nil, err
+	}
+	var resp gistResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid gist response: %w", err)
+	}
+
+	var files []txtar.File
+	total := 0
+	for name, f := range resp.Files {
+		if f == nil {
+			continue
+		}
+		if f.Filename != "" {
+			name = f.Filename
+		}
+		content := f.Content
+		// 超过 1MB 的文件在 API 响应中被截断，需要从 raw_url 获取完整内容
+		if f.Truncated {
+			if !g.trustedRawURL(f.RawURL, base) {
+				return nil, fmt.Errorf("unexpected raw url for gist file %s", name)
+			}
+			raw, err := g.get(ctx, f.RawURL, "", MaxSnippetBytes)
+			if err != nil {
+				return nil, err
+			}
+			content = string(raw)
+		}
+		total += len(content)
+		if total > MaxSnippetBytes {
+			return nil, ErrTooLarge
+		}
+		files = append(files, txtar.File{Name: name, Data: []byte(content)})
+	}
+	if len(files) == 0 {
+		return nil, ErrEmpty
+	}
+
+	snippet := &Snippet{
+		Code:        Join(files),
+		Description: resp.Description,
+		URL:         resp.HTMLURL,
+		Revision:    ref.Revision,
+	}
+	if resp.Owner != nil {
+		snippet.Owner = resp.Owner.Login
+	}
+	if snippet.Revision == "" && len(resp.History) > 0 {
+		snippet.Revision = resp.History[0].Version
+	}
+	return snippet, nil
+}
+
+// trustedRawURL 只允许从 GitHub 的原始内容域名或配置的 API 所在主机获取文件，避免被引导访问内网地址
+func (g *Gist) trustedRawURL(raw, base string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "gist.githubusercontent.com" {
+		return true
+	}
+	b, err := url.Parse(base)
+	return err == nil && strings.EqualFold(b.Host, u.Host)
+}
+
+func (g *Gist) get(ctx context.Context, endpoint, accept string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	return fetch(g.Client, req, limit)
+}
+
+// fetch 发送请求并读取响应，404 返回 ErrNotFound，响应超出 limit 字节时返回 ErrTooLarge
+func fetch(client *http.Client, req *http.Request, limit int64) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req.Header.Set("User-Agent", "go-playground-share-service")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
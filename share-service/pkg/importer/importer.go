@@ -0,0 +1,189 @@
+// Package importer 从 GitHub Gist 和官方 Go Playground 导入代码片段。
+//
+// 每种来源由一个 Fetcher 实现，Importer 负责解析用户提供的地址并分发给对应的 Fetcher。
+// Fetcher 的服务地址可以配置，测试时可以指向本地的替身 HTTP 服务。
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/playground/share-service/pkg/txtar"
+)
+
+// Kind 表示代码片段的来源
+type Kind string
+
+const (
+	KindGist       Kind = "gist"       // GitHub Gist
+	KindPlayground Kind = "playground" // 官方 Go Playground（go.dev/play）
+)
+
+// MaxSnippetBytes 是单个代码片段允许读取的最大字节数，超出后返回 ErrTooLarge
+const MaxSnippetBytes = 1 << 20
+
+var (
+	// ErrUnsupportedSource 表示无法识别的导入地址
+	ErrUnsupportedSource = errors.New("unsupported source, use a gist URL, a go.dev/play link or a playground share ID")
+	// ErrNotFound 表示来源中不存在该代码片段
+	ErrNotFound = errors.New("snippet not found")
+	// ErrTooLarge 表示代码片段超出大小限制
+	ErrTooLarge = errors.New("snippet is too large")
+	// ErrEmpty 表示代码片段不包含任何文件
+	ErrEmpty = errors.New("snippet is empty")
+)
+
+// Ref 代表解析后的导入地址
+type Ref struct {
+	Kind     Kind
+	ID       string
+	Revision string // Gist 的历史版本，为空表示最新版本
+}
+
+// Snippet 代表从来源获取的代码片段
+type Snippet struct {
+	Code        string // 单文件为文件内容，多文件为 txtar 格式
+	Description string
+	Owner       string // 来源中的作者，例如 Gist 所有者的用户名
+	URL         string // 来源中的页面地址
+	Revision    string
+}
+
+// Fetcher 从一种来源获取代码片段
+type Fetcher interface {
+	Fetch(ctx context.Context, ref *Ref) (*Snippet, error)
+}
+
+// Importer 根据地址选择 Fetcher 导入代码片段
+type Importer struct {
+	fetchers map[Kind]Fetcher
+}
+
+// Option 用于配置 Importer
+type Option func(*Importer)
+
+// WithFetcher 设置某种来源使用的 Fetcher
+func WithFetcher(kind Kind, f Fetcher) Option {
+	return func(im *Importer) {
+		im.fetchers[kind] = f
+	}
+}
+
+// New 创建 Importer，默认从 api.github.com 和 go.dev 获取
+func New(opts ...Option) *Importer {
+	im := &Importer{fetchers: map[Kind]Fetcher{
+		KindGist:       &Gist{},
+		KindPlayground: &Playground{},
+	}}
+	for _, opt := range opts {
+		opt(im)
+	}
+	return im
+}
+
+// Import 解析地址并获取代码片段
+func (im *Importer) Import(ctx context.Context, source string) (*Ref, *Snippet, error) {
+	ref, err := ParseRef(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, ok := im.fetchers[ref.Kind]
+	if !ok {
+		return nil, nil, ErrUnsupportedSource
+	}
+	snippet, err := f.Fetch(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimSpace(snippet.Code) == "" {
+		return nil, nil, ErrEmpty
+	}
+	return ref, snippet, nil
+}
+
+var (
+	gistIDPattern       = regexp.MustCompile(`^[0-9a-f]{20,40}$`)
+	revisionPattern     = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	playgroundIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+)
+
+// ParseRef 解析导入地址，支持以下写法：
+//   - https://gist.github.com/<user>/<id>[/<revision>]
+//   - https://gist.githubusercontent.com/<user>/<id>/raw/...
+//   - https://go.dev/play/p/<id>、https://play.golang.org/p/<id>[.go]
+//   - 单独的官方 Playground 分享 ID，可以带 .go 后缀
+func ParseRef(source string) (*Ref, error) {
+	source = strings.TrimSpace(source)
+	if id := strings.TrimSuffix(source, ".go"); playgroundIDPattern.MatchString(id) {
+		return &Ref{Kind: KindPlayground, ID: id}, nil
+	}
+
+	if !strings.Contains(source, "://") {
+		source = "https://" + source
+	}
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, ErrUnsupportedSource
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	switch strings.ToLower(u.Hostname()) {
+	case "gist.github.com":
+		// 地址可以省略用户名
+		if len(parts) > 0 && gistIDPattern.MatchString(parts[0]) {
+			parts = append([]string{""}, parts...)
+		}
+		if len(parts) < 2 || !gistIDPattern.MatchString(strings.TrimSuffix(parts[1], ".git")) {
+			break
+		}
+		ref := &Ref{Kind: KindGist, ID: strings.TrimSuffix(parts[1], ".git")}
+		if len(parts) > 2 && revisionPattern.MatchString(parts[2]) {
+			ref.Revision = parts[2]
+		}
+		return ref, nil
+
+	case "gist.githubusercontent.com":
+		if len(parts) < 2 || !gistIDPattern.MatchString(parts[1]) {
+			break
+		}
+		ref := &Ref{Kind: KindGist, ID: parts[1]}
+		if len(parts) > 3 && parts[2] == "raw" && revisionPattern.MatchString(parts[3]) {
+			ref.Revision = parts[3]
+		}
+		return ref, nil
+
+	case "go.dev", "play.golang.org", "play.golang.com", "golang.org":
+		if parts[0] == "play" {
+			parts = parts[1:]
+		}
+		if len(parts) != 2 || parts[0] != "p" {
+			break
+		}
+		id := strings.TrimSuffix(parts[1], ".go")
+		if !playgroundIDPattern.MatchString(id) {
+			break
+		}
+		return &Ref{Kind: KindPlayground, ID: id}, nil
+	}
+	return nil, ErrUnsupportedSource
+}
+
+// Join 将多个文件合并为分享代码：单个文件直接返回内容，多个文件合并为 txtar，
+// main.go 排在最前，其余按文件名排序
+func Join(files []txtar.File) string {
+	if len(files) == 1 {
+		return string(files[0].Data)
+	}
+	sorted := append([]txtar.File(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if (sorted[i].Name == "main.go") != (sorted[j].Name == "main.go") {
+			return sorted[i].Name == "main.go"
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return string(txtar.Format(&txtar.Archive{Files: sorted}))
+}
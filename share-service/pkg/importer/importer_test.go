@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/playground/share-service/pkg/txtar"
+)
+
+const (
+	testGistID   = "0123456789abcdef0123"
+	testRevision = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		source string
+		want   *Ref
+	}{
+		{"https://gist.github.com/alice/" + testGistID, &Ref{Kind: KindGist, ID: testGistID}},
+		{"gist.github.com/" + testGistID, &Ref{Kind: KindGist, ID: testGistID}},
+		{"https://gist.github.com/alice/" + testGistID + ".git", &Ref{Kind: KindGist, ID: testGistID}},
+		{"https://gist.github.com/alice/" + testGistID + "/" + testRevision, &Ref{Kind: KindGist, ID: testGistID, Revision: testRevision}},
+		{"https://gist.githubusercontent.com/alice/" + testGistID + "/raw/main.go", &Ref{Kind: KindGist, ID: testGistID}},
+		{"https://gist.githubusercontent.com/alice/" + testGistID + "/raw/" + testRevision + "/main.go", &Ref{Kind: KindGist, ID: testGistID, Revision: testRevision}},
+		{"https://go.dev/play/p/abcDEF_-12", &Ref{Kind: KindPlayground, ID: "abcDEF_-12"}},
+		{"go.dev/play/p/abcDEF12.go", &Ref{Kind: KindPlayground, ID: "abcDEF12"}},
+		{"https://play.golang.org/p/abcDEF12", &Ref{Kind: KindPlayground, ID: "abcDEF12"}},
+		{"  abcDEF12  ", &Ref{Kind: KindPlayground, ID: "abcDEF12"}},
+		{"abcDEF12.go", &Ref{Kind: KindPlayground, ID: "abcDEF12"}},
+		{"https://gist.github.com/alice", nil},
+		{"https://gist.github.com/alice/not-a-gist", nil},
+		{"https://go.dev/doc/p/abcDEF12", nil},
+		{"https://go.dev/play/p/abc", nil},
+		{"https://example.com/p/abcDEF12", nil},
+		{"ftp://go.dev/play/p/abcDEF12", nil},
+		{"short", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParseRef(tt.source)
+		if tt.want == nil {
+			if !errors.Is(err, ErrUnsupportedSource) {
+				t.Errorf("ParseRef(%q) = %+v, %v, want ErrUnsupportedSource", tt.source, got, err)
+			}
+			continue
+		}
+		if err != nil || *got != *tt.want {
+			t.Errorf("ParseRef(%q) = %+v, %v, want %+v", tt.source, got, err, tt.want)
+		}
+	}
+}
+
+// gistFile 是替身 GitHub API 返回的文件
+type gistFile struct {
+	Filename  string `json:"filename"`
+	Truncated bool   `json:"truncated"`
+	RawURL    string `json:"raw_url"`
+	Content   string `json:"content"`
+}
+
+// newGistServer 启动替身 GitHub API，files 为 testGistID 的文件，raw 为 /raw/ 下的原始内容
+func newGistServer(t *testing.T, files func(base string) map[string]gistFile, raw map[string]string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := raw[strings.TrimPrefix(r.URL.Path, "/raw/")]; ok && strings.HasPrefix(r.URL.Path, "/raw/") {
+			w.Write([]byte(content))
+			return
+		}
+		if r.URL.Path != "/gists/"+testGistID && r.URL.Path != "/gists/"+testGistID+"/"+testRevision {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          testGistID,
+			"html_url":    "https://gist.github.com/alice/" + testGistID,
+			"description": "demo",
+			"owner":       map[string]string{"login": "alice"},
+			"history":     []map[string]string{{"version": testRevision}},
+			"files":       files(srv.URL),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGistFetch(t *testing.T) {
+	full := "package util\n\n// " + strings.Repeat("x", 100) + "\n"
+	srv := newGistServer(t, func(base string) map[string]gistFile {
+		return map[string]gistFile{
+			"util.go": {Filename: "util.go", Truncated: true, RawURL: base + "/raw/util.go", Content: full[:20]},
+			"main.go": {Filename: "main.go", Content: "package main\n"},
+			"go.mod":  {Filename: "go.mod", Content: "module demo\n"},
+		}
+	}, map[string]string{"util.go": full})
+
+	g := &Gist{BaseURL: srv.URL}
+	snippet, err := g.Fetch(context.Background(), &Ref{Kind: KindGist, ID: testGistID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Owner != "alice" || snippet.Description != "demo" || snippet.Revision != testRevision {
+		t.Errorf("unexpected metadata %+v", snippet)
+	}
+
+	// 多文件合并为 txtar，main.go 在最前，截断的文件从 raw_url 取得完整内容
+	a := txtar.Parse([]byte(snippet.Code))
+	var names []string
+	for _, f := range a.Files {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "main.go,go.mod,util.go" {
+		t.Fatalf("files = %v", names)
+	}
+	if string(a.Files[2].Data) != full {
+		t.Errorf("truncated file was not fetched from raw_url: %q", a.Files[2].Data)
+	}
+
+	single := newGistServer(t, func(string) map[string]gistFile {
+		return map[string]gistFile{"main.go": {Filename: "main.go", Content: "package main\n"}}
+	}, nil)
+	snippet, err = (&Gist{BaseURL: single.URL}).Fetch(context.Background(), &Ref{Kind: KindGist, ID: testGistID, Revision: testRevision})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snippet.Code != "package main\n" || snippet.Revision != testRevision {
+		t.Errorf("single file gist = %+v", snippet)
+	}
+}
+
+func TestGistFetchErrors(t *testing.T) {
+	ctx := context.Background()
+	empty := newGistServer(t, func(string) map[string]gistFile { return map[string]gistFile{} }, nil)
+	if _, err := (&Gist{BaseURL: empty.URL}).Fetch(ctx, &Ref{ID: "ffffffffffffffffffff"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing gist: %v, want ErrNotFound", err)
+	}
+	if _, err := (&Gist{BaseURL: empty.URL}).Fetch(ctx, &Ref{ID: testGistID}); !errors.Is(err, ErrEmpty) {
+		t.Errorf("gist without files: %v, want ErrEmpty", err)
+	}
+
+	large := strings.Repeat("x", MaxSnippetBytes/2+1)
+	tooLarge := newGistServer(t, func(string) map[string]gistFile {
+		return map[string]gistFile{"a.go": {Filename: "a.go", Content: large}, "b.go": {Filename: "b.go", Content: large}}
+	}, nil)
+	if _, err := (&Gist{BaseURL: tooLarge.URL}).Fetch(ctx, &Ref{ID: testGistID}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized gist: %v, want ErrTooLarge", err)
+	}
+
+	rawTooLarge := newGistServer(t, func(base string) map[string]gistFile {
+		return map[string]gistFile{"a.go": {Filename: "a.go", Truncated: true, RawURL: base + "/raw/a.go"}}
+	}, map[string]string{"a.go": strings.Repeat("x", MaxSnippetBytes+1)})
+	if _, err := (&Gist{BaseURL: rawTooLarge.URL}).Fetch(ctx, &Ref{ID: testGistID}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized raw file: %v, want ErrTooLarge", err)
+	}
+
+	foreign := newGistServer(t, func(string) map[string]gistFile {
+		return map[string]gistFile{"a.go": {Filename: "a.go", Truncated: true, RawURL: "http://169.254.169.254/latest"}}
+	}, nil)
+	if _, err := (&Gist{BaseURL: foreign.URL}).Fetch(ctx, &Ref{ID: testGistID}); err == nil {
+		t.Error("truncated file with a foreign raw_url was fetched")
+	}
+}
+
+func TestTrustedRawURL(t *testing.T) {
+	g := &Gist{}
+	tests := []struct {
+		raw, base string
+		want      bool
+	}{
+		{"https://gist.githubusercontent.com/alice/x/raw/main.go", DefaultGistAPI, true},
+		{"https://GIST.githubusercontent.com/alice/x/raw/main.go", DefaultGistAPI, true},
+		{"http://127.0.0.1:8080/raw/main.go", "http://127.0.0.1:8080", true},
+		{"http://127.0.0.1:8081/raw/main.go", "http://127.0.0.1:8080", false},
+		{"https://evil.example.com/main.go", DefaultGistAPI, false},
+		{"https://gist.githubusercontent.com.evil.example.com/x", DefaultGistAPI, false},
+		{"http://169.254.169.254/latest/meta-data", DefaultGistAPI, false},
+		{"file:///etc/passwd", DefaultGistAPI, false},
+		{"::", DefaultGistAPI, false},
+	}
+	for _, tt := range tests {
+		if got := g.trustedRawURL(tt.raw, tt.base); got != tt.want {
+			t.Errorf("trustedRawURL(%q, %q) = %v, want %v", tt.raw, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestPlaygroundFetch(t *testing.T) {
+	shares := map[string]string{
+		"single12": "package main\n",
+		"multi123": "package main\n-- go.mod --\nmodule demo\n",
+		"huge1234": strings.Repeat("x", MaxSnippetBytes+1),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, ok := shares[r.URL.Query().Get("id")]
+		if r.URL.Path != "/_/share" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(code))
+	}))
+	defer srv.Close()
+
+	p := &Playground{BaseURL: srv.URL + "/"}
+	ctx := context.Background()
+	for _, id := range []string{"single12", "multi123"} {
+		snippet, err := p.Fetch(ctx, &Ref{Kind: KindPlayground, ID: id})
+		if err != nil {
+			t.Fatalf("Fetch(%s): %v", id, err)
+		}
+		// 多文件分享本身就是 txtar 格式，原样保留
+		if snippet.Code != shares[id] || snippet.URL != DefaultPlaygroundURL+"/play/p/"+id {
+			t.Errorf("Fetch(%s) = %+v", id, snippet)
+		}
+	}
+	if _, err := p.Fetch(ctx, &Ref{ID: "missing1"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing share: %v, want ErrNotFound", err)
+	}
+	if _, err := p.Fetch(ctx, &Ref{ID: "huge1234"}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized share: %v, want ErrTooLarge", err)
+	}
+}
+
+// stubFetcher 返回固定的代码片段
+type stubFetcher struct{ code string }
+
+func (f stubFetcher) Fetch(context.Context, *Ref) (*Snippet, error) {
+	return &Snippet{Code: f.code}, nil
+}
+
+func TestImport(t *testing.T) {
+	im := New(WithFetcher(KindPlayground, stubFetcher{"package main\n"}), WithFetcher(KindGist, stubFetcher{" \n"}))
+	ctx := context.Background()
+
+	ref, snippet, err := im.Import(ctx, "https://go.dev/play/p/abcDEF12")
+	if err != nil || ref.ID != "abcDEF12" || snippet.Code != "package main\n" {
+		t.Errorf("Import = %+v, %+v, %v", ref, snippet, err)
+	}
+	if _, _, err := im.Import(ctx, "https://gist.github.com/"+testGistID); !errors.Is(err, ErrEmpty) {
+		t.Errorf("blank snippet: %v, want ErrEmpty", err)
+	}
+	if _, _, err := im.Import(ctx, "https://example.com/x"); !errors.Is(err, ErrUnsupportedSource) {
+		t.Errorf("unknown source: %v, want ErrUnsupportedSource", err)
+	}
+}
@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultPlaygroundURL 是官方 Go Playground 的默认地址
+const DefaultPlaygroundURL = "https://go.dev"
+
+// Playground 从官方 Go Playground 获取分享，多文件分享本身就是 txtar 格式，原样保留
+type Playground struct {
+	BaseURL string       // Playground 地址，为空时使用 DefaultPlaygroundURL
+	Client  *http.Client // 为空时使用 10 秒超时的客户端
+}
+
+// Fetch 实现 Fetcher 接口
+func (p *Playground) Fetch(ctx context.Context, ref *Ref) (*Snippet, error) {
+	base := strings.TrimSuffix(p.BaseURL, "/")
+	if base == "" {
+		base = DefaultPlaygroundURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/_/share?id="+url.QueryEscape(ref.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := fetch(p.Client, req, MaxSnippetBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Snippet{
+		Code: string(body),
+		URL:  DefaultPlaygroundURL + "/play/p/" + ref.ID,
+	}, nil
+}
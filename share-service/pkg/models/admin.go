@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// SetExpiryRequest 代表管理员修改分享过期时间的请求，三个字段只能设置一个
+type SetExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 指定新的过期时间
+	ExtendBy  string     `json:"extend_by,omitempty"`  // 在当前过期时间基础上延长，已过期的分享从当前时间起算
+	Never     bool       `json:"never,omitempty"`      // 改为永不过期
+}
+
+// Resolve 根据分享当前的过期时间计算新的过期时间，返回 nil 表示永不过期
+func (r *SetExpiryRequest) Resolve(current *time.Time, now time.Time) (*time.Time, error) {
+	set := 0
+	if r.ExpiresAt != nil {
+		set++
+	}
+	if r.ExtendBy != "" {
+		set++
+	}
+	if r.Never {
+		set++
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of expires_at, extend_by or never must be set")
+	}
+
+	switch {
+	case r.Never:
+		return nil, nil
+	case r.ExpiresAt != nil:
+		if !r.ExpiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		return r.ExpiresAt, nil
+	}
+
+	by, err := time.ParseDuration(r.ExtendBy)
+	if err != nil || by <= 0 {
+		return nil, errors.New("extend_by must be a positive duration such as 72h")
+	}
+	if current == nil {
+		return nil, errors.New("share never expires, use expires_at to set an expiry")
+	}
+	base := *current
+	if base.Before(now) {
+		base = now
+	}
+	expiresAt := base.Add(by)
+	return &expiresAt, nil
+}
+
+// PurgeResponse 代表批量删除的响应
+type PurgeResponse struct {
+	Deleted int64 `json:"deleted"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection 代表一组有序的分享
+type Collection struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	CollectionID string             `bson:"collectionId" json:"collectionId"`
+	Title        string             `bson:"title" json:"title"`
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	Author       string             `bson:"author,omitempty" json:"author,omitempty"`
+	ShareIDs     []string           `bson:"shares" json:"shares"` // 按展示顺序排列的分享 ID
+	OwnerKeyHash string             `bson:"owner_key_hash,omitempty" json:"-"`
+	OwnerID      string             `bson:"owner_id,omitempty" json:"-"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CreateCollectionRequest 代表创建合集的请求
+type CreateCollectionRequest struct {
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	ShareIDs    []string `json:"shares,omitempty"`
+}
+
+// UpdateCollectionRequest 代表更新合集的请求，未提供的字段保持不变
+type UpdateCollectionRequest struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	ShareIDs    *[]string `json:"shares,omitempty"`
+}
+
+// CreateCollectionResponse 代表创建合集的响应
+type CreateCollectionResponse struct {
+	CollectionID string `json:"collectionId"`
+	URL          string `json:"url"`
+	OwnerKey     string `json:"owner_key"` // 所有者密钥，仅在创建时返回一次
+}
+
+// CollectionResponse 代表获取合集的响应
+type CollectionResponse struct {
+	CollectionID string         `json:"collectionId"`
+	URL          string         `json:"url"`
+	Title        string         `json:"title"`
+	Description  string         `json:"description,omitempty"`
+	Author       string         `json:"author,omitempty"`
+	Shares       []ShareSummary `json:"shares"` // 仅包含当前可访问的分享
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment 代表分享下的一条评论，顶层评论开启一个讨论线程，回复通过 ParentID 关联到顶层评论
+type Comment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	CommentID    string             `bson:"commentId" json:"id"`
+	ShareID      string             `bson:"shareId" json:"-"`
+	ParentID     string             `bson:"parent_id,omitempty" json:"parent_id,omitempty"` // 回复的顶层评论，为空表示顶层评论
+	Body         string             `bson:"body" json:"body"`
+	Author       string             `bson:"author,omitempty" json:"author,omitempty"`
+	Anchor       *LineAnchor        `bson:"anchor,omitempty" json:"anchor,omitempty"` // 评论针对的代码行，仅顶层评论可以设置
+	OwnerKeyHash string             `bson:"owner_key_hash,omitempty" json:"-"`        // 匿名评论者密钥的 SHA-256 哈希
+	OwnerID      string             `bson:"owner_id,omitempty" json:"-"`              // 登录评论者的用户 ID
+	CreatorIP    string             `bson:"creator_ip,omitempty" json:"-"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    *time.Time         `bson:"updated_at,omitempty" json:"updated_at,omitempty"` // 最后编辑时间，未编辑过为空
+	Verified     bool               `bson:"-" json:"verified"`                                // 评论者是否为已验证的注册用户
+}
+
+// LineAnchor 代表评论针对的代码行范围，行号从 1 开始，包含首尾两行
+type LineAnchor struct {
+	File  string `bson:"file,omitempty" json:"file,omitempty"` // 多文件分享中的文件名，为空表示第一个文件
+	Start int    `bson:"start" json:"start"`
+	End   int    `bson:"end" json:"end"`
+}
+
+// CreateCommentRequest 代表发表评论的请求
+type CreateCommentRequest struct {
+	Body     string      `json:"body" binding:"required"`
+	Author   string      `json:"author,omitempty"`    // 匿名评论时显示的名字，登录用户使用用户名
+	ParentID string      `json:"parent_id,omitempty"` // 回复的顶层评论
+	Anchor   *LineAnchor `json:"anchor,omitempty"`
+}
+
+// UpdateCommentRequest 代表编辑评论的请求
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// CreateCommentResponse 代表发表评论的响应
+type CreateCommentResponse struct {
+	*Comment
+	OwnerKey string `json:"owner_key,omitempty"` // 匿名评论者用于编辑和删除的密钥，仅在创建时返回一次
+}
+
+// CommentThread 代表一个顶层评论及其按时间排列的回复
+type CommentThread struct {
+	*Comment
+	Replies []*Comment `json:"replies"`
+}
+
+// CommentsResponse 代表分享评论列表的响应
+type CommentsResponse struct {
+	ShareID string           `json:"shareId"`
+	Count   int              `json:"count"` // 评论总数，包括回复
+	Threads []*CommentThread `json:"threads"`
+}
+
+// NewCommentThreads 将按时间排列的评论组织为线程，找不到顶层评论的回复被忽略
+func NewCommentThreads(comments []*Comment) []*CommentThread {
+	threads := []*CommentThread{}
+	byID := make(map[string]*CommentThread)
+	for _, comment := range comments {
+		if comment.ParentID == "" {
+			thread := &CommentThread{Comment: comment, Replies: []*Comment{}}
+			byID[comment.CommentID] = thread
+			threads = append(threads, thread)
+		}
+	}
+	for _, comment := range comments {
+		if thread, ok := byID[comment.ParentID]; ok && comment.ParentID != "" {
+			thread.Replies = append(thread.Replies, comment)
+		}
+	}
+	return threads
+}
@@ -0,0 +1,23 @@
+package models
+
+import "github.com/playground/share-service/pkg/diff"
+
+// DiffSide 描述差异比较中的一侧
+type DiffSide struct {
+	ShareID     string `json:"shareId"`
+	Version     string `json:"version"`
+	Title       string `json:"title,omitempty"`
+	Formatted   bool   `json:"formatted"`              // 是否已经过 gofmt 规范化
+	FormatError string `json:"format_error,omitempty"` // 格式化失败的原因
+}
+
+// DiffResponse 代表两个分享之间的差异
+type DiffResponse struct {
+	From       DiffSide    `json:"from"`
+	To         DiffSide    `json:"to"`
+	Identical  bool        `json:"identical"`
+	Unified    string      `json:"unified"`
+	Hunks      []diff.Hunk `json:"hunks"`
+	Insertions int         `json:"insertions"`
+	Deletions  int         `json:"deletions"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// ShareSort 表示分享列表的排序方式
+type ShareSort string
+
+const (
+	SortRecent ShareSort = "recent" // 按创建时间倒序
+	SortViews  ShareSort = "views"  // 按访问次数倒序
+
+	// SortTrending 按热度倒序，只包括近期有访问或收藏的分享
+	SortTrending ShareSort = "trending"
+)
+
+// ShareQuery 代表分享列表的查询条件
+type ShareQuery struct {
+	Author  string
+	Tag     string
+	Version string
+	From    *time.Time // 创建时间下限（包含）
+	To      *time.Time // 创建时间上限（不包含）
+	Search  string     // 全文搜索标题、描述和代码
+	Sort    ShareSort
+	Cursor  string // 上一页返回的游标，格式由存储实现决定
+	Limit   int
+
+	// OwnerID 不为空时列出该用户的全部分享（包括非公开分享）
+	OwnerID string
+
+	// All 为 true 时列出全部分享，包括非公开、隐藏和已过期的分享，仅用于管理操作
+	All bool
+}
+
+// ShareSummary 代表列表中的分享摘要，不包含代码内容
+type ShareSummary struct {
+	ShareID     string     `json:"shareId"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Version     string     `json:"version"`
+	Tags        []string   `json:"tags,omitempty"`
+	Visibility  Visibility `json:"visibility"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Views       int64      `json:"views"`
+	Stars       int64      `json:"stars"`
+	Hidden      bool       `json:"hidden,omitempty"` // 被管理员隐藏，仅所有者和管理员可见
+}
+
+// NewShareSummary 从分享生成摘要
+func NewShareSummary(s *Share) ShareSummary {
+	return ShareSummary{
+		ShareID:     s.ShareID,
+		Title:       s.Title,
+		Description: s.Description,
+		Author:      s.Author,
+		Version:     s.Version,
+		Tags:        s.Tags,
+		Visibility:  s.EffectiveVisibility(),
+		CreatedAt:   s.CreatedAt,
+		ExpiresAt:   s.ExpiresAt,
+		Views:       s.Views,
+		Stars:       s.Stars,
+		Hidden:      s.Hidden,
+	}
+}
+
+// ListSharesResponse 代表分享列表的响应
+type ListSharesResponse struct {
+	Shares     []ShareSummary `json:"shares"`
+	NextCursor string         `json:"next_cursor,omitempty"` // 为空表示没有更多数据
+}
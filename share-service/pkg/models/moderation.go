@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportReason 表示举报原因
+type ReportReason string
+
+const (
+	ReasonSpam      ReportReason = "spam"      // 垃圾信息或广告
+	ReasonMalicious ReportReason = "malicious" // 恶意代码
+	ReasonIllegal   ReportReason = "illegal"   // 违法内容
+	ReasonAbuse     ReportReason = "abuse"     // 骚扰、仇恨等不当内容
+	ReasonOther     ReportReason = "other"
+)
+
+// Valid 判断举报原因是否合法
+func (r ReportReason) Valid() bool {
+	switch r {
+	case ReasonSpam, ReasonMalicious, ReasonIllegal, ReasonAbuse, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// Report 代表一条对分享的举报
+type Report struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ReportID    string             `bson:"reportId" json:"reportId"`
+	ShareID     string             `bson:"shareId" json:"shareId"`
+	Reason      ReportReason       `bson:"reason" json:"reason"`
+	Details     string             `bson:"details,omitempty" json:"details,omitempty"`
+	ReporterKey string             `bson:"reporter_key" json:"-"` // 举报者标识的哈希，同一举报者只能举报一次
+	ReporterID  string             `bson:"reporter_id,omitempty" json:"reporter_id,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	Resolved    bool               `bson:"resolved,omitempty" json:"resolved"`
+}
+
+// CreateReportRequest 代表举报分享的请求
+type CreateReportRequest struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details,omitempty"`
+}
+
+// ReportQuery 代表查询被举报分享的条件
+type ReportQuery struct {
+	IncludeResolved bool // 是否包括已处理的举报
+	Offset          int
+	Limit           int
+}
+
+// ReportedShare 代表审核队列中的一个被举报分享
+type ReportedShare struct {
+	ShareID        string               `bson:"_id" json:"shareId"`
+	Reports        int                  `bson:"reports" json:"reports"`
+	Reasons        []ReportReason       `bson:"reasons" json:"reasons"`
+	LastReportedAt time.Time            `bson:"last_reported_at" json:"last_reported_at"`
+	Share          *ModerationShareView `bson:"-" json:"share,omitempty"` // 分享已被删除时为空
+}
+
+// ModerationShareView 代表管理员看到的分享信息，包含隐藏状态和创建者信息
+type ModerationShareView struct {
+	ShareSummary
+	Hidden       bool   `json:"hidden"`
+	HiddenReason string `json:"hidden_reason,omitempty"`
+	OwnerID      string `json:"owner_id,omitempty"`
+	CreatorIP    string `json:"creator_ip,omitempty"`
+}
+
+// NewModerationShareView 从分享生成管理员视图
+func NewModerationShareView(s *Share) *ModerationShareView {
+	return &ModerationShareView{
+		ShareSummary: NewShareSummary(s),
+		Hidden:       s.Hidden,
+		HiddenReason: s.HiddenReason,
+		OwnerID:      s.OwnerID,
+		CreatorIP:    s.CreatorIP,
+	}
+}
+
+// HideShareRequest 代表隐藏分享的请求
+type HideShareRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// BanKind 表示封禁的对象类型
+type BanKind string
+
+const (
+	BanAuthor BanKind = "author" // 按作者名（登录用户即用户名）封禁
+	BanIP     BanKind = "ip"     // 按客户端 IP 封禁
+)
+
+// Ban 代表一条封禁记录，被封禁者不能再创建分享或合集
+type Ban struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	BanID     string             `bson:"banId" json:"banId"`
+	Kind      BanKind            `bson:"kind" json:"kind"`
+	Value     string             `bson:"value" json:"value"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedBy string             `bson:"created_by,omitempty" json:"created_by,omitempty"` // 执行封禁的管理员用户名
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// CreateBanRequest 代表创建封禁的请求
+type CreateBanRequest struct {
+	Kind       string `json:"kind" binding:"required"`
+	Value      string `json:"value" binding:"required"`
+	Reason     string `json:"reason,omitempty"`
+	ExpiresIn  string `json:"expires_in,omitempty"`  // 留空表示永久封禁
+	HideShares bool   `json:"hide_shares,omitempty"` // 封禁作者时同时隐藏其全部分享
+}
+
+// ModerationQueueResponse 代表审核队列的响应
+type ModerationQueueResponse struct {
+	Shares []*ReportedShare `json:"shares"`
+}
+
+// ModerationShareResponse 代表管理员查看单个分享的响应
+type ModerationShareResponse struct {
+	Share   *ModerationShareView `json:"share"`
+	Reports []*Report            `json:"reports"`
+}
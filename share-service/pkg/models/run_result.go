@@ -2,10 +2,11 @@ package models
 
 // RunResult 代码运行结果
 type RunResult struct {
-	Output    string `json:"output"`     // 运行输出
-	Error     string `json:"error"`      // 错误信息
-	ExitCode  int    `json:"exit_code"`  // 退出码
-	Duration  int64  `json:"duration"`   // 运行时长（毫秒）
-	Memory    int64  `json:"memory"`     // 内存使用（字节）
-	CreatedAt int64  `json:"created_at"` // 创建时间戳
+	Output    string `bson:"output" json:"output"`                             // 运行输出
+	Error     string `bson:"error,omitempty" json:"error"`                     // 错误信息
+	ExitCode  int    `bson:"exit_code" json:"exit_code"`                       // 退出码
+	Duration  int64  `bson:"duration" json:"duration"`                         // 运行时长（毫秒）
+	Memory    int64  `bson:"memory" json:"memory"`                             // 内存使用（字节）
+	GoVersion string `bson:"go_version,omitempty" json:"go_version,omitempty"` // 产生该结果的 Go 版本
+	CreatedAt int64  `bson:"created_at" json:"created_at"`                     // 创建时间戳
 }
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// CreateSessionRequest 代表创建协作编辑会话的请求，share_id 和 code 二选一
+type CreateSessionRequest struct {
+	ShareID string `json:"share_id,omitempty"` // 以已有分享的代码和版本开始
+	Code    string `json:"code,omitempty"`
+	Version string `json:"version,omitempty"` // 默认为 DefaultVersion
+}
+
+// SessionResponse 代表协作编辑会话的当前状态
+type SessionResponse struct {
+	ID           string                `json:"id"`
+	URL          string                `json:"url"` // WebSocket 连接地址
+	Code         string                `json:"code"`
+	Version      string                `json:"version"`
+	Revision     int                   `json:"revision"`
+	Participants []*SessionParticipant `json:"participants"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+// SessionCursor 是参与者的光标和选区，位置以 UTF-16 代码单元计算，没有选区时 Anchor 等于 Position
+type SessionCursor struct {
+	Position int `json:"position"`
+	Anchor   int `json:"anchor"`
+}
+
+// SessionParticipant 是会话中一个连接的在线信息
+type SessionParticipant struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Color    string         `json:"color"`
+	Verified bool           `json:"verified,omitempty"` // 名称为登录用户的用户名
+	Cursor   *SessionCursor `json:"cursor,omitempty"`
+}
+
+// SnapshotSessionRequest 代表将会话当前内容保存为分享的请求，选项与创建分享相同
+type SnapshotSessionRequest struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	ExpiresIn   string   `json:"expires_in,omitempty"`
+	Slug        string   `json:"slug,omitempty"`
+	Run         bool     `json:"run,omitempty"`
+	Visibility  string   `json:"visibility,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	MaxViews    int64    `json:"max_views,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// CreateRequest 使用会话当前的代码和版本生成创建分享的请求
+func (r *SnapshotSessionRequest) CreateRequest(code, version string) *CreateShareRequest {
+	return &CreateShareRequest{
+		Code:        code,
+		Version:     version,
+		Title:       r.Title,
+		Description: r.Description,
+		Author:      r.Author,
+		ExpiresIn:   r.ExpiresIn,
+		Slug:        r.Slug,
+		NoDedupe:    true, // 每次快照都生成新分享
+		Run:         r.Run,
+		Visibility:  r.Visibility,
+		Password:    r.Password,
+		MaxViews:    r.MaxViews,
+		Tags:        r.Tags,
+	}
+}
@@ -6,30 +6,89 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Visibility 表示分享的可见性
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"   // 公开，出现在列表中
+	VisibilityUnlisted Visibility = "unlisted" // 不公开列出，知道链接即可访问
+	VisibilityPrivate  Visibility = "private"  // 仅所有者可访问
+	VisibilityPassword Visibility = "password" // 需要密码访问
+)
+
+// Valid 判断可见性取值是否合法
+func (v Visibility) Valid() bool {
+	switch v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate, VisibilityPassword:
+		return true
+	}
+	return false
+}
+
 // Share 代表一个分享的代码片段
 type Share struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	ShareID     string             `bson:"shareId" json:"shareId"`
-	Code        string             `bson:"code" json:"code"`
-	Language    string             `bson:"language" json:"language"`
-	Version     string             `bson:"version" json:"version"`
-	Title       string             `bson:"title,omitempty" json:"title,omitempty"`
-	Description string             `bson:"description,omitempty" json:"description,omitempty"`
-	Author      string             `bson:"author,omitempty" json:"author,omitempty"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	ExpiresAt   *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
-	Views       int64              `bson:"views" json:"views"`
-	LastViewed  *time.Time         `bson:"last_viewed,omitempty" json:"last_viewed,omitempty"`
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ShareID      string             `bson:"shareId" json:"shareId"`
+	Code         string             `bson:"code" json:"code"`
+	Language     string             `bson:"language" json:"language"`
+	Version      string             `bson:"version" json:"version"`
+	Title        string             `bson:"title,omitempty" json:"title,omitempty"`
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	Author       string             `bson:"author,omitempty" json:"author,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt    *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	Views        int64              `bson:"views" json:"views"`
+	Stars        int64              `bson:"stars,omitempty" json:"stars,omitempty"`
+	Trending     float64            `bson:"trending_score,omitempty" json:"-"` // 由后台任务根据近期访问和收藏计算，0 表示不在热门列表中
+	LastViewed   *time.Time         `bson:"last_viewed,omitempty" json:"last_viewed,omitempty"`
+	ContentHash  string             `bson:"content_hash,omitempty" json:"-"` // 代码及执行相关元数据的哈希，用于去重
+	LastRun      *RunResult         `bson:"last_run,omitempty" json:"last_run,omitempty"`
+	Visibility   Visibility         `bson:"visibility,omitempty" json:"visibility,omitempty"` // 为空时视为公开
+	PasswordHash string             `bson:"password_hash,omitempty" json:"-"`                 // bcrypt 哈希
+	OwnerKeyHash string             `bson:"owner_key_hash,omitempty" json:"-"`                // 所有者密钥的 SHA-256 哈希
+	MaxViews     int64              `bson:"max_views,omitempty" json:"max_views,omitempty"`   // 最大访问次数，0 表示不限
+	Exhausted    bool               `bson:"exhausted,omitempty" json:"exhausted,omitempty"`   // 访问次数已用尽，内容已清除
+	Tags         []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	OwnerID      string             `bson:"owner_id,omitempty" json:"-"`   // 创建者的用户 ID，匿名分享为空
+	CreatorIP    string             `bson:"creator_ip,omitempty" json:"-"` // 创建者 IP，仅管理员可见，用于封禁滥用者
+	Hidden       bool               `bson:"hidden,omitempty" json:"-"`     // 被管理员隐藏，访问时返回 451
+	HiddenReason string             `bson:"hidden_reason,omitempty" json:"-"`
+	Origin       *ShareOrigin       `bson:"origin,omitempty" json:"origin,omitempty"` // 从外部导入的分享记录来源
+}
+
+// ShareOrigin 记录导入分享的来源
+type ShareOrigin struct {
+	Kind       string    `bson:"kind" json:"kind"` // gist、playground 或 session（协作会话快照）
+	ID         string    `bson:"id" json:"id"`     // 会话快照不记录会话 ID，会话 ID 同时是加入会话的凭据
+	URL        string    `bson:"url,omitempty" json:"url,omitempty"`
+	Revision   string    `bson:"revision,omitempty" json:"revision,omitempty"` // Gist 的历史版本
+	Owner      string    `bson:"owner,omitempty" json:"owner,omitempty"`       // 来源中的作者
+	ImportedAt time.Time `bson:"imported_at" json:"imported_at"`
+}
+
+// EffectiveVisibility 返回分享的实际可见性，兼容未设置该字段的旧数据
+func (s *Share) EffectiveVisibility() Visibility {
+	if s.Visibility == "" {
+		return VisibilityPublic
+	}
+	return s.Visibility
 }
 
 // CreateShareRequest 代表创建分享的请求
 type CreateShareRequest struct {
-	Code        string `json:"code" binding:"required"`
-	Version     string `json:"version" binding:"required"`
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	Author      string `json:"author,omitempty"`
-	ExpiresIn   string `json:"expires_in,omitempty"` // 例如: "24h", "7d"
+	Code        string   `json:"code" binding:"required"`
+	Version     string   `json:"version" binding:"required"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	ExpiresIn   string   `json:"expires_in,omitempty"` // 例如: "24h", "7d"
+	Slug        string   `json:"slug,omitempty"`       // 自定义分享 ID，留空则随机生成
+	NoDedupe    bool     `json:"no_dedupe,omitempty"`  // 为 true 时总是创建新分享
+	Run         bool     `json:"run,omitempty"`        // 为 true 时运行代码并保存运行结果快照
+	Visibility  string   `json:"visibility,omitempty"` // public、unlisted、private 或 password
+	Password    string   `json:"password,omitempty"`   // visibility 为 password 时必填
+	MaxViews    int64    `json:"max_views,omitempty"`  // 最大访问次数，1 表示阅后即焚
+	Tags        []string `json:"tags,omitempty"`
 }
 
 // CreateShareResponse 代表创建分享的响应
@@ -37,16 +96,66 @@ type CreateShareResponse struct {
 	ShareID   string     `json:"shareId"`
 	URL       string     `json:"url"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Existing  bool       `json:"existing,omitempty"`  // 是否复用了内容相同的已有分享
+	OwnerKey  string     `json:"owner_key,omitempty"` // 所有者密钥，仅在创建时返回一次
+}
+
+// ImportShareRequest 代表从外部来源导入分享的请求，除代码外的选项与创建分享相同
+type ImportShareRequest struct {
+	Source      string   `json:"source" binding:"required"` // Gist 地址、官方 Playground 链接或分享 ID
+	Version     string   `json:"version,omitempty"`         // 默认为 DefaultVersion
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"` // 默认使用来源中的描述
+	Author      string   `json:"author,omitempty"`
+	ExpiresIn   string   `json:"expires_in,omitempty"`
+	Slug        string   `json:"slug,omitempty"`
+	Run         bool     `json:"run,omitempty"`
+	Visibility  string   `json:"visibility,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	MaxViews    int64    `json:"max_views,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// CreateRequest 使用导入的代码生成创建分享的请求
+func (r *ImportShareRequest) CreateRequest(code string) *CreateShareRequest {
+	version := r.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	return &CreateShareRequest{
+		Code:        code,
+		Version:     version,
+		Title:       r.Title,
+		Description: r.Description,
+		Author:      r.Author,
+		ExpiresIn:   r.ExpiresIn,
+		Slug:        r.Slug,
+		NoDedupe:    true, // 来源不同的分享不与已有分享合并
+		Run:         r.Run,
+		Visibility:  r.Visibility,
+		Password:    r.Password,
+		MaxViews:    r.MaxViews,
+		Tags:        r.Tags,
+	}
 }
 
 // GetShareResponse 代表获取分享的响应
 type GetShareResponse struct {
-	Code        string     `json:"code"`
-	Version     string     `json:"version"`
-	Title       string     `json:"title,omitempty"`
-	Description string     `json:"description,omitempty"`
-	Author      string     `json:"author,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	Views       int64      `json:"views"`
+	Code        string           `json:"code"`
+	Version     string           `json:"version"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Author      string           `json:"author,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	Views       int64            `json:"views"`
+	Stars       int64            `json:"stars"`
+	Starred     bool             `json:"starred,omitempty"`  // 当前登录用户是否已收藏
+	LastRun     *RunResult       `json:"last_run,omitempty"` // 作者保存的运行结果快照
+	Visibility  Visibility       `json:"visibility"`
+	MaxViews    int64            `json:"max_views,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Verified    bool             `json:"verified"` // 作者是否为已验证的注册用户
+	Origin      *ShareOrigin     `json:"origin,omitempty"`
+	Comments    []*CommentThread `json:"comments,omitempty"`
 }
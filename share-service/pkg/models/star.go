@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Star 代表登录用户对分享的收藏，同一用户对同一分享只有一条记录
+type Star struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ShareID   string             `bson:"shareId" json:"shareId"`
+	UserID    string             `bson:"user_id" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// StarResponse 代表收藏或取消收藏后的状态
+type StarResponse struct {
+	ShareID string `json:"shareId"`
+	Stars   int64  `json:"stars"`
+	Starred bool   `json:"starred"`
+}
+
+// ShareActivity 代表分享在某一天（UTC）的独立访客数和新增收藏数，用于计算热度
+type ShareActivity struct {
+	ShareID string
+	Day     string // 格式为 2006-01-02
+	Views   int64
+	Stars   int64
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// User 代表一个注册用户
+type User struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID       string             `bson:"userId" json:"userId"`
+	Username     string             `bson:"username" json:"username"`
+	PasswordHash string             `bson:"password_hash,omitempty" json:"-"`
+	Identity     *ExternalIdentity  `bson:"identity,omitempty" json:"identity,omitempty"` // 通过单点登录创建的用户的外部身份
+	Groups       []string           `bson:"groups,omitempty" json:"groups,omitempty"`     // 身份提供方下发的用户组，每次登录时同步
+	Admin        bool               `bson:"admin,omitempty" json:"admin,omitempty"`       // 是否为管理员
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	LastLoginAt  *time.Time         `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+}
+
+// ExternalIdentity 代表用户在外部身份提供方中的身份
+type ExternalIdentity struct {
+	Issuer  string `bson:"issuer" json:"issuer"`
+	Subject string `bson:"subject" json:"subject"`
+	Email   string `bson:"email,omitempty" json:"email,omitempty"`
+	Name    string `bson:"name,omitempty" json:"name,omitempty"`
+}
+
+// TokenKind 表示访问令牌的类型
+type TokenKind string
+
+const (
+	TokenSession TokenKind = "session" // 登录后签发的会话令牌
+	TokenAPIKey  TokenKind = "api_key" // 用户创建的长期 API Key
+)
+
+// Token 代表一个访问令牌，只保存令牌的哈希
+type Token struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	TokenID    string             `bson:"tokenId" json:"id"`
+	UserID     string             `bson:"userId" json:"-"`
+	Kind       TokenKind          `bson:"kind" json:"kind"`
+	Name       string             `bson:"name,omitempty" json:"name,omitempty"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // 令牌明文的前几位，便于用户识别
+	Hash       string             `bson:"hash" json:"-"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// CredentialsRequest 代表注册和登录请求
+type CredentialsRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse 代表登录成功的响应
+type LoginResponse struct {
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	User      *User      `json:"user"`
+}
+
+// CreateAPIKeyRequest 代表创建 API Key 的请求
+type CreateAPIKeyRequest struct {
+	Name      string `json:"name" binding:"required"`
+	ExpiresIn string `json:"expires_in,omitempty"` // 留空表示永不过期
+}
+
+// CreateAPIKeyResponse 代表创建 API Key 的响应，明文只返回一次
+type CreateAPIKeyResponse struct {
+	Key   string `json:"key"`
+	Token *Token `json:"api_key"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// FieldError 描述请求中某个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse 代表请求校验失败（422）的响应
+type ValidationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+// TooLargeResponse 代表请求体过大（413）的响应
+type TooLargeResponse struct {
+	Error string `json:"error"`
+	Limit int64  `json:"limit"` // 允许的最大字节数
+}
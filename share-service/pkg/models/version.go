@@ -0,0 +1,41 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// DefaultVersion 是未指定版本时使用的 Go 版本，与默认后端一致
+const DefaultVersion = "go1.24"
+
+// NormalizeVersion 将各种写法的版本号统一为后端服务使用的格式
+func NormalizeVersion(version string) (string, bool) {
+	switch version {
+	case "go1.25", "1.25", "go1.25.0", "1.25.0":
+		return "go1.25", true
+	case "go1.22", "1.22", "go1.22.0", "1.22.0":
+		return "go1.22", true
+	case "go1.23", "1.23", "go1.23.0", "1.23.0":
+		return "go1.23", true
+	case "go1.24", "1.24", "go1.24.0", "1.24.0":
+		return "go1.24", true
+	default:
+		return "", false
+	}
+}
+
+// ContentHash 计算代码及执行相关元数据的哈希，用于去重
+func ContentHash(code, version, language string) string {
+	// 统一版本写法，使 "1.24" 与 "go1.24" 得到相同的哈希
+	if normalized, ok := NormalizeVersion(version); ok {
+		version = normalized
+	}
+
+	h := sha256.New()
+	h.Write([]byte(language))
+	h.Write([]byte{0})
+	h.Write([]byte(version))
+	h.Write([]byte{0})
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// ViewEvent 代表一次分享访问
+type ViewEvent struct {
+	ShareID    string
+	VisitorKey string // 经过哈希的访客标识，不包含原始 IP
+	Referrer   string // 来源站点域名，直接访问时为空
+	Embedded   bool   // 是否通过嵌入页面访问
+	Time       time.Time
+}
+
+// DailyViews 代表分享在某一天（UTC）的访问统计
+type DailyViews struct {
+	Date      string           `bson:"day" json:"date"` // 格式为 2006-01-02
+	Total     int64            `bson:"total" json:"total"`
+	Unique    int64            `bson:"unique" json:"unique"`
+	Embedded  int64            `bson:"embedded" json:"embedded"`
+	Direct    int64            `bson:"direct" json:"direct"`
+	Referrers map[string]int64 `bson:"referrers,omitempty" json:"referrers,omitempty"`
+}
+
+// ShareStatsResponse 代表分享访问统计的响应
+type ShareStatsResponse struct {
+	ShareID   string           `json:"shareId"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Views     int64            `json:"views"` // 分享的累计访问次数
+	Total     int64            `json:"total"` // 统计区间内的总访问次数
+	Unique    int64            `json:"unique"`
+	Referrers map[string]int64 `json:"referrers,omitempty"` // 只返回给分享所有者和管理员
+	Series    []DailyViews     `json:"series"`
+}
+
+// HideReferrers 去掉来源域名，来源可能暴露分享被贴在哪些内部站点
+func (r *ShareStatsResponse) HideReferrers() {
+	r.Referrers = nil
+	for i := range r.Series {
+		r.Series[i].Referrers = nil
+	}
+}
+
+// NewShareStatsResponse 汇总 [from, to] 区间的每日统计，没有访问记录的日期补零
+func NewShareStatsResponse(share *Share, daily []*DailyViews, from, to time.Time) *ShareStatsResponse {
+	byDate := make(map[string]*DailyViews, len(daily))
+	for _, d := range daily {
+		byDate[d.Date] = d
+	}
+
+	resp := &ShareStatsResponse{
+		ShareID:   share.ShareID,
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		Views:     share.Views,
+		Referrers: make(map[string]int64),
+		Series:    []DailyViews{},
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		point := DailyViews{Date: date}
+		if v, ok := byDate[date]; ok {
+			point = *v
+		}
+		resp.Total += point.Total
+		resp.Unique += point.Unique
+		for host, n := range point.Referrers {
+			resp.Referrers[host] += n
+		}
+		resp.Series = append(resp.Series, point)
+	}
+	return resp
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookEventType 表示 Webhook 订阅的分享生命周期事件
+type WebhookEventType string
+
+const (
+	EventShareCreated WebhookEventType = "share.created" // 创建分享，包括导入
+	EventShareUpdated WebhookEventType = "share.updated" // 修改标签、刷新运行结果或过期时间
+	EventShareViewed  WebhookEventType = "share.viewed"  // 访问次数达到订阅设置的阈值
+	EventShareExpired WebhookEventType = "share.expired" // 分享到达过期时间
+	EventPing         WebhookEventType = "ping"          // 手动触发的测试事件，总是投递
+)
+
+// WebhookEventTypes 是可以订阅的全部事件
+var WebhookEventTypes = []WebhookEventType{EventShareCreated, EventShareUpdated, EventShareViewed, EventShareExpired}
+
+// Valid 判断事件类型是否可以订阅
+func (t WebhookEventType) Valid() bool {
+	for _, e := range WebhookEventTypes {
+		if t == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook 代表一个 Webhook 订阅，归属创建它的登录用户
+type Webhook struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	WebhookID     string             `bson:"webhookId" json:"id"`
+	OwnerID       string             `bson:"owner_id" json:"-"`
+	URL           string             `bson:"url" json:"url"`
+	Secret        string             `bson:"secret" json:"-"` // 签名密钥，需要原文计算 HMAC
+	Events        []WebhookEventType `bson:"events" json:"events"`
+	Filter        WebhookFilter      `bson:"filter" json:"filter"`
+	ViewThreshold int64              `bson:"view_threshold,omitempty" json:"view_threshold,omitempty"` // share.viewed 事件的访问次数阈值
+	Active        bool               `bson:"active" json:"active"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WebhookFilter 限定订阅的分享范围，多个条件同时满足才投递，全部为空表示不限制
+type WebhookFilter struct {
+	Collection string   `bson:"collection,omitempty" json:"collection,omitempty"` // 只包括该合集中的分享
+	Authors    []string `bson:"authors,omitempty" json:"authors,omitempty"`       // 只包括这些作者的分享
+}
+
+// Subscribes 判断订阅是否包含该事件
+func (w *Webhook) Subscribes(event WebhookEventType) bool {
+	if event == EventPing {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookRequest 代表创建 Webhook 订阅的请求
+type CreateWebhookRequest struct {
+	URL           string             `json:"url" binding:"required"`
+	Events        []WebhookEventType `json:"events" binding:"required"`
+	Filter        WebhookFilter      `json:"filter"`
+	ViewThreshold int64              `json:"view_threshold,omitempty"`
+	Secret        string             `json:"secret,omitempty"` // 留空时自动生成
+}
+
+// UpdateWebhookRequest 代表更新 Webhook 订阅的请求，未提供的字段保持不变
+type UpdateWebhookRequest struct {
+	URL           *string             `json:"url,omitempty"`
+	Events        *[]WebhookEventType `json:"events,omitempty"`
+	Filter        *WebhookFilter      `json:"filter,omitempty"`
+	ViewThreshold *int64              `json:"view_threshold,omitempty"`
+	Active        *bool               `json:"active,omitempty"`
+	RotateSecret  bool                `json:"rotate_secret,omitempty"` // 为 true 时生成新的签名密钥
+}
+
+// WebhookResponse 代表 Webhook 订阅，签名密钥仅在创建和轮换时返回
+type WebhookResponse struct {
+	*Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// DeliveryStatus 表示投递状态
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // 等待投递或重试
+	DeliverySucceeded DeliveryStatus = "succeeded" // 接收方返回 2xx
+	DeliveryFailed    DeliveryStatus = "failed"    // 重试次数用尽或订阅已停用
+	DeliveryCancelled DeliveryStatus = "cancelled" // 投递前事件已不成立，例如过期时间被延长
+)
+
+// WebhookDelivery 代表一次事件投递及其全部尝试记录
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	DeliveryID    string             `bson:"deliveryId" json:"id"`
+	WebhookID     string             `bson:"webhookId" json:"webhook_id"`
+	Event         WebhookEventType   `bson:"event" json:"event"`
+	ShareID       string             `bson:"shareId,omitempty" json:"shareId,omitempty"`
+	DedupeKey     string             `bson:"dedupe_key,omitempty" json:"-"` // 同一事件只投递一次，例如访问阈值和过期事件
+	Payload       string             `bson:"payload" json:"payload"`        // 发送的 JSON 请求体
+	Status        DeliveryStatus     `bson:"status" json:"status"`
+	Attempts      []DeliveryAttempt  `bson:"attempts,omitempty" json:"attempts"`
+	NextAttemptAt *time.Time         `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"` // 投递结束后清除
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// DeliveryAttempt 代表一次投递尝试
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"` // 未收到响应时为 0
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS int64     `bson:"duration_ms" json:"duration_ms"`
+	Response   string    `bson:"response,omitempty" json:"response,omitempty"` // 响应体的开头部分，便于排查
+}
+
+// WebhookPayload 是投递给接收方的 JSON 请求体
+type WebhookPayload struct {
+	ID        string           `json:"id"` // 投递 ID，接收方可以用于去重
+	WebhookID string           `json:"webhook_id"`
+	Event     WebhookEventType `json:"event"`
+	CreatedAt time.Time        `json:"created_at"`
+	Share     *ShareSummary    `json:"share,omitempty"`
+	URL       string           `json:"url,omitempty"` // 分享页面地址，配置了 PUBLIC_URL 时为完整地址
+}
+
+// ListDeliveriesResponse 代表投递记录列表的响应
+type ListDeliveriesResponse struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,199 @@
+// Package ogimage 生成分享链接的 Open Graph 预览图。
+// 预览图使用纯 Go 实现的字体光栅化绘制，不依赖系统字体和外部程序。
+package ogimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+
+	"github.com/playground/share-service/pkg/highlight"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Width 和 Height 是 Open Graph 推荐的预览图尺寸
+	Width  = 1200
+	Height = 630
+
+	// Revision 是绘制逻辑的版本，修改版式后递增以使旧的缓存失效
+	Revision = 1
+
+	padding    = 64
+	lineHeight = 34
+	tabWidth   = 4
+)
+
+// Card 代表预览图上显示的内容
+type Card struct {
+	Title   string
+	Author  string
+	Version string
+	Code    string // 只显示开头能放下的若干行
+	Footer  string // 右下角显示的文字，通常是分享地址
+}
+
+var (
+	background = color.RGBA{0x0d, 0x11, 0x17, 0xff}
+	panel      = color.RGBA{0x16, 0x1b, 0x22, 0xff}
+	foreground = color.RGBA{0xe6, 0xed, 0xf3, 0xff}
+	muted      = color.RGBA{0x8b, 0x94, 0x9e, 0xff}
+	accent     = color.RGBA{0x00, 0xad, 0xd8, 0xff}
+
+	// 代码着色与嵌入页面的深色主题一致
+	palette = map[highlight.Kind]color.Color{
+		highlight.Plain:   color.RGBA{0xc9, 0xd1, 0xd9, 0xff},
+		highlight.Keyword: color.RGBA{0xff, 0x7b, 0x72, 0xff},
+		highlight.String:  color.RGBA{0xa5, 0xd6, 0xff, 0xff},
+		highlight.Number:  color.RGBA{0x79, 0xc0, 0xff, 0xff},
+		highlight.Comment: color.RGBA{0x8b, 0x94, 0x9e, 0xff},
+		highlight.Builtin: color.RGBA{0xd2, 0xa8, 0xff, 0xff},
+	}
+)
+
+// faces 缓存解析后的字体，字体对象不是并发安全的，因此每次绘制都需加锁
+var (
+	facesOnce sync.Once
+	facesErr  error
+	facesMu   sync.Mutex
+	titleFace font.Face
+	textFace  font.Face
+	codeFace  font.Face
+)
+
+func loadFaces() error {
+	facesOnce.Do(func() {
+		load := func(data []byte, size float64) font.Face {
+			if facesErr != nil {
+				return nil
+			}
+			f, err := opentype.Parse(data)
+			if err != nil {
+				facesErr = err
+				return nil
+			}
+			face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+			if err != nil {
+				facesErr = err
+			}
+			return face
+		}
+		titleFace = load(gobold.TTF, 56)
+		textFace = load(goregular.TTF, 28)
+		codeFace = load(gomono.TTF, 24)
+	})
+	return facesErr
+}
+
+// Render 绘制预览图并编码为 PNG
+func Render(card *Card) ([]byte, error) {
+	if err := loadFaces(); err != nil {
+		return nil, err
+	}
+	facesMu.Lock()
+	defer facesMu.Unlock()
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	// 顶部的强调色条
+	draw.Draw(img, image.Rect(0, 0, Width, 8), image.NewUniform(accent), image.Point{}, draw.Src)
+
+	right := Width - padding
+	y := padding + 56
+	drawText(img, titleFace, foreground, padding, y, fit(titleFace, card.Title, right-padding))
+
+	var meta []string
+	if card.Author != "" {
+		meta = append(meta, "by "+card.Author)
+	}
+	if card.Version != "" {
+		meta = append(meta, card.Version)
+	}
+	y += 48
+	drawText(img, textFace, muted, padding, y, fit(textFace, strings.Join(meta, "  ·  "), right-padding))
+
+	// 代码区域，超出宽度的部分由子图像裁剪
+	box := image.Rect(padding, y+32, right, Height-padding-56)
+	draw.Draw(img, box, image.NewUniform(panel), image.Point{}, draw.Src)
+	code := img.SubImage(box.Inset(20)).(*image.RGBA)
+	baseline := code.Bounds().Min.Y + 24
+	for _, line := range highlight.Lines(card.Code) {
+		if baseline > code.Bounds().Max.Y {
+			break
+		}
+		x := fixed.I(code.Bounds().Min.X)
+		col := 0
+		for _, span := range line {
+			text := expandTabs(span.Text, &col)
+			d := &font.Drawer{Dst: code, Src: image.NewUniform(palette[span.Kind]), Face: codeFace, Dot: fixed.Point26_6{X: x, Y: fixed.I(baseline)}}
+			d.DrawString(text)
+			x = d.Dot.X
+		}
+		baseline += lineHeight
+	}
+
+	y = Height - padding + 8
+	drawText(img, textFace, accent, padding, y, "Go Playground")
+	if card.Footer != "" {
+		footer := fit(textFace, card.Footer, right-padding-280)
+		drawText(img, textFace, muted, right-font.MeasureString(textFace, footer).Ceil(), y, footer)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, s string) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+// fit 截断超出宽度的单行文字并追加省略号
+func fit(face font.Face, s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	limit := fixed.I(width)
+	if font.MeasureString(face, s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		t := strings.TrimRight(string(runes), " ") + "…"
+		if font.MeasureString(face, t) <= limit {
+			return t
+		}
+	}
+	return ""
+}
+
+// expandTabs 将制表符展开为空格，col 记录当前所在的列
+func expandTabs(s string, col *int) string {
+	if !strings.Contains(s, "\t") {
+		*col += len([]rune(s))
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\t' {
+			n := tabWidth - *col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			*col += n
+			continue
+		}
+		b.WriteRune(r)
+		*col++
+	}
+	return b.String()
+}
@@ -0,0 +1,341 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认请求的 scope
+var DefaultScopes = []string{"openid", "profile", "email"}
+
+// 与身份提供方通信的超时时间
+const httpTimeout = 10 * time.Second
+
+// Config 代表 OpenID Connect 客户端配置
+type Config struct {
+	Issuer        string   // 身份提供方地址，用于发现配置并校验 ID Token 的 iss
+	ClientID      string   // 客户端 ID
+	ClientSecret  string   // 客户端密钥，公共客户端可为空（仅使用 PKCE）
+	RedirectURL   string   // 回调地址，需要在身份提供方登记
+	Scopes        []string // 请求的 scope，为空时使用 DefaultScopes
+	UsernameClaim string   // 用作用户名的声明，默认 preferred_username
+	GroupsClaim   string   // 用作用户组的声明，默认 groups
+}
+
+// Endpoints 代表身份提供方的发现文档中使用到的字段
+type Endpoints struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Identity 代表从 ID Token 中解析出的用户身份
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Username      string // 按 UsernameClaim 取得的用户名，可能为空
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+// Provider 是 OpenID Connect 授权码流程的客户端
+// 发现文档和签名公钥在首次使用时获取并缓存，身份提供方暂时不可用不影响服务启动
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *Endpoints
+	keys      *keySet
+}
+
+// New 创建 Provider
+func New(config Config) (*Provider, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer, client id and redirect url are required")
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+
+	p := &Provider{
+		config: config,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+	p.keys = newKeySet(p.client)
+	return p, nil
+}
+
+// Issuer 返回身份提供方地址
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// discover 获取并缓存发现文档
+func (p *Provider) discover(ctx context.Context) (*Endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	var ep Endpoints
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", &ep); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(ep.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: discovery returned %q", ep.Issuer)
+	}
+	if ep.AuthorizationEndpoint == "" || ep.TokenEndpoint == "" || ep.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing required endpoints")
+	}
+	p.keys.setURL(ep.JWKSURI)
+	p.endpoints = &ep
+	return p.endpoints, nil
+}
+
+// AuthRequest 代表一次授权请求的状态，需要在回调时原样取回
+type AuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string // PKCE code_verifier
+}
+
+// NewAuthRequest 生成随机的 state、nonce 和 PKCE verifier
+func NewAuthRequest() (*AuthRequest, error) {
+	values := make([]string, 3)
+	for i := range values {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	return &AuthRequest{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// AuthCodeURL 返回跳转到身份提供方登录页的地址
+func (p *Provider) AuthCodeURL(ctx context.Context, req *AuthRequest) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(req.Verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {req.State},
+		"nonce":                 {req.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(ep.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return ep.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// tokenResponse 代表令牌端点的响应
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange 使用授权码换取 ID Token，校验后返回用户身份
+func (p *Provider) Exchange(ctx context.Context, code string, req *AuthRequest) (*Identity, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {req.Verifier},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		httpReq.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tr tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return nil, fmt.Errorf("oidc: token endpoint returned %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	return p.Verify(ctx, tr.IDToken, req.Nonce)
+}
+
+// Verify 校验 ID Token 的签名、签发方、受众、有效期和 nonce，并映射为用户身份
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Identity, error) {
+	if _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims, err := p.keys.verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("oidc: unexpected issuer %q", iss)
+	}
+	if !audienceContains(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("oidc: token was not issued for this client")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != "" && azp != p.config.ClientID {
+		return nil, errors.New("oidc: unexpected authorized party")
+	}
+	now := time.Now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok || now.After(exp.Add(clockSkew)) {
+		return nil, errors.New("oidc: token is expired")
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok && iat.After(now.Add(clockSkew)) {
+		return nil, errors.New("oidc: token is issued in the future")
+	}
+	if got, _ := claims["nonce"].(string); nonce != "" && got != nonce {
+		return nil, errors.New("oidc: nonce mismatch")
+	}
+
+	id := &Identity{
+		Issuer:   p.config.Issuer,
+		Username: stringClaim(claims, p.config.UsernameClaim),
+		Email:    stringClaim(claims, "email"),
+		Name:     stringClaim(claims, "name"),
+		Groups:   listClaim(claims, p.config.GroupsClaim),
+	}
+	id.Subject, _ = claims["sub"].(string)
+	id.EmailVerified, _ = claims["email_verified"].(bool)
+	if id.Subject == "" {
+		return nil, errors.New("oidc: token has no subject")
+	}
+	return id, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, rawURL)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func audienceContains(aud any, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []any:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	v, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
+
+// stringClaim 读取字符串声明，支持以 "." 分隔的嵌套路径（例如 realm_access.roles）
+func stringClaim(claims map[string]any, path string) string {
+	s, _ := lookupClaim(claims, path).(string)
+	return s
+}
+
+// listClaim 读取字符串列表声明，单个字符串或以逗号分隔的字符串也视为列表
+func listClaim(claims map[string]any, path string) []string {
+	var groups []string
+	switch v := lookupClaim(claims, path).(type) {
+	case []any:
+		for _, g := range v {
+			if s, ok := g.(string); ok && s != "" {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				groups = append(groups, s)
+			}
+		}
+	}
+	return groups
+}
+
+func lookupClaim(claims map[string]any, path string) any {
+	if v, ok := claims[path]; ok {
+		return v
+	}
+	var cur any = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
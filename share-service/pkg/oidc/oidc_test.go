@@ -0,0 +1,359 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClientID = "share-service"
+
+// testIssuer 是测试用的身份提供方，提供发现文档、JWKS 和令牌端点
+type testIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu        sync.Mutex
+	keys      map[string]crypto.Signer
+	issuer    string // 发现文档中返回的 issuer，默认为服务地址
+	challenge string // 授权请求中的 code_challenge
+	idToken   string // 令牌端点返回的 ID Token
+	jwksHits  int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	ti := &testIssuer{t: t, keys: make(map[string]crypto.Signer)}
+	ti.addRSAKey("rsa-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		ti.mu.Lock()
+		issuer := ti.issuer
+		ti.mu.Unlock()
+		if issuer == "" {
+			issuer = ti.server.URL
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": ti.server.URL + "/authorize",
+			"token_endpoint":         ti.server.URL + "/token",
+			"jwks_uri":               ti.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		ti.mu.Lock()
+		defer ti.mu.Unlock()
+		ti.jwksHits++
+		var keys []map[string]string
+		for kid, signer := range ti.keys {
+			keys = append(keys, jwk(kid, signer.Public()))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		ti.mu.Lock()
+		defer ti.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != ti.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": ti.idToken})
+	})
+	ti.server = httptest.NewServer(mux)
+	t.Cleanup(ti.server.Close)
+	return ti
+}
+
+func (ti *testIssuer) addRSAKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		ti.t.Fatal(err)
+	}
+	ti.mu.Lock()
+	ti.keys[kid] = key
+	ti.mu.Unlock()
+}
+
+func (ti *testIssuer) addECKey(kid string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		ti.t.Fatal(err)
+	}
+	ti.mu.Lock()
+	ti.keys[kid] = key
+	ti.mu.Unlock()
+}
+
+func (ti *testIssuer) provider() *Provider {
+	ti.t.Helper()
+	p, err := New(Config{
+		Issuer:      ti.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://play.example.com/api/auth/oidc/callback",
+		GroupsClaim: "realm_access.roles",
+	})
+	if err != nil {
+		ti.t.Fatal(err)
+	}
+	return p
+}
+
+// claims 返回一组有效的声明
+func (ti *testIssuer) claims(nonce string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":                ti.server.URL,
+		"sub":                "user-123",
+		"aud":                testClientID,
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              nonce,
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"realm_access":       map[string]any{"roles": []string{"dev", "admin"}},
+	}
+}
+
+// sign 使用 kid 对应的密钥签名，alg 为空时按密钥类型选择
+func (ti *testIssuer) sign(kid, alg string, claims map[string]any) string {
+	ti.t.Helper()
+	ti.mu.Lock()
+	signer := ti.keys[kid]
+	ti.mu.Unlock()
+	if alg == "" {
+		alg = "RS256"
+		if _, ok := signer.(*ecdsa.PrivateKey); ok {
+			alg = "ES256"
+		}
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch key := signer.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			ti.t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			ti.t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwk(kid string, pub crypto.PublicKey) map[string]string {
+	enc := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kid": kid, "kty": "RSA", "use": "sig", "n": enc(key.N.Bytes()), "e": enc(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return map[string]string{"kid": kid, "kty": "EC", "use": "sig", "crv": "P-256", "x": enc(x), "y": enc(y)}
+	}
+	return nil
+}
+
+func TestExchange(t *testing.T) {
+	ti := newTestIssuer(t)
+	p := ti.provider()
+	ctx := context.Background()
+
+	req, err := NewAuthRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != req.State || q.Get("nonce") != req.Nonce || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	if strings.Contains(authURL, req.Verifier) {
+		t.Fatal("authorization request leaks the PKCE verifier")
+	}
+
+	idToken := ti.sign("rsa-1", "", ti.claims(req.Nonce))
+	ti.mu.Lock()
+	ti.challenge = q.Get("code_challenge")
+	ti.idToken = idToken
+	ti.mu.Unlock()
+
+	id, err := p.Exchange(ctx, "good-code", req)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if id.Issuer != ti.server.URL || id.Subject != "user-123" || id.Username != "alice" ||
+		id.Email != "alice@example.com" || !id.EmailVerified {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	if strings.Join(id.Groups, ",") != "dev,admin" {
+		t.Errorf("Groups = %v, want [dev admin]", id.Groups)
+	}
+
+	// 授权码只能配合对应的 verifier 使用
+	other, _ := NewAuthRequest()
+	other.Nonce = req.Nonce
+	if _, err := p.Exchange(ctx, "good-code", other); err == nil {
+		t.Error("Exchange with a different PKCE verifier succeeded")
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	ti := newTestIssuer(t)
+	ti.addRSAKey("attacker")
+	p := ti.provider()
+	ctx := context.Background()
+
+	valid := func(edit func(map[string]any)) map[string]any {
+		claims := ti.claims("n-1")
+		if edit != nil {
+			edit(claims)
+		}
+		return claims
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong issuer", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["iss"] = "https://evil.example.com" }))},
+		{"wrong audience", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["aud"] = "other-client" }))},
+		{"audience list without client", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["aud"] = []string{"a", "b"} }))},
+		{"wrong authorized party", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["azp"] = "other-client" }))},
+		{"expired", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["exp"] = now.Add(-2 * clockSkew).Unix() }))},
+		{"missing exp", ti.sign("rsa-1", "", valid(func(c map[string]any) { delete(c, "exp") }))},
+		{"issued in the future", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["iat"] = now.Add(2 * clockSkew).Unix() }))},
+		{"nonce mismatch", ti.sign("rsa-1", "", valid(func(c map[string]any) { c["nonce"] = "n-2" }))},
+		{"missing subject", ti.sign("rsa-1", "", valid(func(c map[string]any) { delete(c, "sub") }))},
+		{"unsupported algorithm", ti.sign("rsa-1", "PS256", valid(nil))},
+		{"malformed", "not.a-jwt"},
+	}
+
+	// 其他密钥签名但声称使用 rsa-1 的令牌
+	forged := ti.sign("attacker", "", valid(nil))
+	good := ti.sign("rsa-1", "", valid(nil))
+	tests = append(tests, struct {
+		name  string
+		token string
+	}{"bad signature", strings.Join(append(strings.Split(good, ".")[:2], strings.Split(forged, ".")[2]), ".")})
+	// alg=none 且没有签名
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa-1"}`))
+	tests = append(tests, struct {
+		name  string
+		token string
+	}{"alg none", header + "." + strings.Split(good, ".")[1] + "."})
+
+	for _, tt := range tests {
+		if _, err := p.Verify(ctx, tt.token, "n-1"); err == nil {
+			t.Errorf("%s: token was accepted", tt.name)
+		}
+	}
+
+	if _, err := p.Verify(ctx, good, "n-1"); err != nil {
+		t.Errorf("valid token was rejected: %v", err)
+	}
+}
+
+func TestVerifyES256(t *testing.T) {
+	ti := newTestIssuer(t)
+	ti.addECKey("ec-1")
+	p := ti.provider()
+
+	id, err := p.Verify(context.Background(), ti.sign("ec-1", "", ti.claims("")), "")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if id.Subject != "user-123" {
+		t.Errorf("Subject = %q, want user-123", id.Subject)
+	}
+
+	// RSA 密钥不能用于 ES256
+	if _, err := p.Verify(context.Background(), ti.sign("rsa-1", "ES256", ti.claims("")), ""); err == nil {
+		t.Error("ES256 token signed with an RSA key id was accepted")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ti := newTestIssuer(t)
+	p := ti.provider()
+	ctx := context.Background()
+
+	if _, err := p.Verify(ctx, ti.sign("rsa-1", "", ti.claims("")), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新密钥出现后，间隔内不会因未知 kid 反复拉取 JWKS
+	ti.addRSAKey("rsa-2")
+	token := ti.sign("rsa-2", "", ti.claims(""))
+	if _, err := p.Verify(ctx, token, ""); err == nil {
+		t.Fatal("unknown key was accepted before refresh")
+	}
+	if _, err := p.Verify(ctx, token, ""); err == nil {
+		t.Fatal("unknown key was accepted before refresh")
+	}
+	ti.mu.Lock()
+	hits := ti.jwksHits
+	ti.mu.Unlock()
+	if hits != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", hits)
+	}
+
+	// 超过最小间隔后重新拉取，轮换后的密钥生效
+	p.keys.mu.Lock()
+	p.keys.lastRefresh = time.Now().Add(-minKeyRefreshInterval)
+	p.keys.mu.Unlock()
+	if _, err := p.Verify(ctx, token, ""); err != nil {
+		t.Fatalf("rotated key was rejected: %v", err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	ti := newTestIssuer(t)
+	ti.mu.Lock()
+	ti.issuer = "https://evil.example.com"
+	ti.mu.Unlock()
+	p := ti.provider()
+
+	if _, err := p.AuthCodeURL(context.Background(), &AuthRequest{}); err == nil || !strings.Contains(err.Error(), "issuer mismatch") {
+		t.Fatalf("AuthCodeURL error = %v, want issuer mismatch", err)
+	}
+}
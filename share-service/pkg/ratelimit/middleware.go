@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playground/share-service/pkg/auth"
+	"github.com/playground/share-service/pkg/models"
+)
+
+// Class 表示路由的限流类别，同一类别的路由共享令牌桶
+type Class string
+
+const (
+	ClassCreate  Class = "create"  // 创建和修改数据、登录等写操作
+	ClassRead    Class = "read"    // 读取分享、列表等读操作
+	ClassExecute Class = "execute" // 需要后端运行代码的操作
+)
+
+// DefaultLimits 是各类别的默认限制
+var DefaultLimits = map[Class]string{
+	ClassCreate:  "30/m",
+	ClassRead:    "300/m",
+	ClassExecute: "20/m",
+}
+
+// Limiter 按客户端对请求限流
+type Limiter struct {
+	store  Store
+	limits map[Class]Limit
+	now    func() time.Time
+}
+
+// New 创建限流器，limits 中未配置或未启用的类别不限流
+func New(store Store, limits map[Class]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits, now: time.Now}
+}
+
+// Middleware 返回指定类别的限流中间件，需要放在认证中间件之后
+func (l *Limiter) Middleware(class Class) gin.HandlerFunc {
+	limit := l.limits[class]
+	if !limit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Window.Seconds()))
+	return func(c *gin.Context) {
+		res := l.store.Take(string(class)+"|"+clientKey(c), limit, l.now())
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", policy)
+
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			h.Set("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "rate limit exceeded",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey 返回限流使用的客户端标识：API Key 按令牌区分，登录会话按用户区分，匿名请求按 IP 区分
+func clientKey(c *gin.Context) string {
+	if token := auth.CurrentToken(c); token != nil {
+		if token.Kind == models.TokenAPIKey {
+			return "key:" + token.TokenID
+		}
+		return "user:" + token.UserID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
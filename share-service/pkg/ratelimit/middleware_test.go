@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(l *Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/create", l.Middleware(ClassCreate), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/read", l.Middleware(ClassRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func request(r *gin.Engine, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	l := New(NewMemoryStore(), map[Class]Limit{
+		ClassCreate: {Rate: 2.0 / 60, Burst: 2, Window: time.Minute},
+	})
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	r := newTestRouter(l)
+
+	w := request(r, "/create", "192.0.2.1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("RateLimit-Remaining = %q, want 1", got)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Errorf("RateLimit-Policy = %q, want 2;w=60", got)
+	}
+
+	request(r, "/create", "192.0.2.1")
+	w = request(r, "/create", "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit: status %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+
+	// 不同 IP 和未启用限流的类别不受影响
+	if w := request(r, "/create", "192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("other client: status %d", w.Code)
+	}
+	if w := request(r, "/read", "192.0.2.1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited class: status %d, headers %v", w.Code, w.Header())
+	}
+
+	now = now.Add(30 * time.Second)
+	if w := request(r, "/create", "192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("request after Retry-After: status %d", w.Code)
+	}
+}
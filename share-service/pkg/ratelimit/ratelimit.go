@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit 描述一个令牌桶：容量为 Burst，每秒补充 Rate 个令牌
+type Limit struct {
+	Rate   float64
+	Burst  int
+	Window time.Duration // 配置中的时间窗口，仅用于 RateLimit-Policy 响应头
+}
+
+// Enabled 判断该限制是否生效
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit 解析 "次数/时间窗口" 格式的限制，例如 "30/m"、"5/s"、"1000/h"、"100/10m"
+// 桶容量为次数，令牌在时间窗口内匀速补充；"off" 或 "0" 表示不限制
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	countStr, windowStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected format like 30/m", s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit count %q", countStr)
+	}
+
+	window, err := parseWindow(windowStr)
+	if err != nil {
+		return Limit{}, err
+	}
+	if count == 0 {
+		return Limit{}, nil
+	}
+	return Limit{Rate: float64(count) / window.Seconds(), Burst: count, Window: window}, nil
+}
+
+func parseWindow(s string) (time.Duration, error) {
+	switch s {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid rate limit window %q", s)
+	}
+	return d, nil
+}
+
+// Result 代表一次取令牌的结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	Reset      time.Duration // 令牌桶恢复满额所需时间
+	RetryAfter time.Duration // 被拒绝时，下一个令牌可用前需要等待的时间
+}
+
+// Store 保存各客户端的令牌桶状态
+type Store interface {
+	// Take 从 key 对应的令牌桶中取出一个令牌
+	Take(key string, limit Limit, now time.Time) Result
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time // 令牌桶恢复满额的时间，之后可以安全删除
+}
+
+// MemoryStore 是进程内的令牌桶存储，所有路由类别共享同一个存储
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// 清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内令牌桶存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take 实现 Store 接口
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	b.fullAt = now.Add(res.Reset)
+	return res
+}
+
+// sweep 定期删除已经恢复满额的令牌桶，避免内存随客户端数量无限增长
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "30/m", want: Limit{Rate: 0.5, Burst: 30, Window: time.Minute}},
+		{in: "5/s", want: Limit{Rate: 5, Burst: 5, Window: time.Second}},
+		{in: "3600/h", want: Limit{Rate: 1, Burst: 3600, Window: time.Hour}},
+		{in: " 100/10m ", want: Limit{Rate: 100.0 / 600, Burst: 100, Window: 10 * time.Minute}},
+		{in: "", want: Limit{}},
+		{in: "off", want: Limit{}},
+		{in: "0", want: Limit{}},
+		{in: "0/m", want: Limit{}},
+		{in: "30", wantErr: true},
+		{in: "x/m", wantErr: true},
+		{in: "-1/m", wantErr: true},
+		{in: "30/d", wantErr: true},
+		{in: "30/0s", wantErr: true},
+		{in: "30/-1m", wantErr: true},
+		{in: "0/x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLimit(%q) = %+v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLimit(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.Enabled() != (tt.want.Burst > 0) {
+			t.Errorf("ParseLimit(%q).Enabled() = %v", tt.in, got.Enabled())
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 3, Window: 3 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 2; i >= 0; i-- {
+		res := s.Take("a", limit, now)
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 {
+			t.Fatalf("take %d: got %+v", 3-i, res)
+		}
+	}
+	res := s.Take("a", limit, now)
+	if res.Allowed || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("take over burst: got %+v", res)
+	}
+
+	// 其他客户端的令牌桶互不影响
+	if res := s.Take("b", limit, now); !res.Allowed {
+		t.Fatalf("independent key was limited: %+v", res)
+	}
+
+	// 半秒后仍不足一个令牌，等待时间随之缩短
+	res = s.Take("a", limit, now.Add(500*time.Millisecond))
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("take after 500ms: got %+v", res)
+	}
+	if res := s.Take("a", limit, now.Add(time.Second)); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("take after 1s: got %+v", res)
+	}
+
+	// 补充的令牌不超过桶容量
+	res = s.Take("a", limit, now.Add(time.Hour))
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("take after an hour: got %+v", res)
+	}
+}
+
+func TestMemoryStoreClockGoingBackwards(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1, Window: time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	s.Take("a", limit, now)
+	if res := s.Take("a", limit, now.Add(-time.Hour)); res.Allowed {
+		t.Fatalf("an earlier timestamp refilled the bucket: %+v", res)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 10, Window: 10 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	s.Take("idle", limit, now)
+	// busy 在第 55 秒取完全部令牌，第 65 秒才恢复满额
+	for i := 0; i < 10; i++ {
+		s.Take("busy", limit, now.Add(55*time.Second))
+	}
+	if len(s.buckets) != 2 {
+		t.Fatalf("swept before the interval: %d buckets", len(s.buckets))
+	}
+
+	s.Take("other", limit, now.Add(sweepInterval))
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+
+	// 被清理的令牌桶重新创建时是满的
+	if res := s.Take("idle", limit, now.Add(sweepInterval)); !res.Allowed || res.Remaining != 9 {
+		t.Errorf("recreated bucket: got %+v", res)
+	}
+}
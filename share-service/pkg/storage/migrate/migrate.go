@@ -0,0 +1,260 @@
+// Package migrate 管理存储的结构版本。
+//
+// 每个迁移步骤有一个递增的版本号，已应用的步骤记录在数据库中。Runner 按版本顺序
+// 执行或回滚步骤，并在数据库版本高于程序已知的最新版本时拒绝运行，
+// 避免旧版本程序在新结构上写入数据。具体的步骤和记录方式由各存储驱动提供。
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrSchemaTooNew 表示数据库的结构版本高于程序已知的最新版本
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+	// ErrPending 表示还有未应用的迁移
+	ErrPending = errors.New("database schema has pending migrations")
+	// ErrIrreversible 表示迁移步骤不支持回滚
+	ErrIrreversible = errors.New("migration cannot be reverted")
+)
+
+// Step 代表一个迁移步骤，Up 和 Down 都应当可以安全地重复执行
+type Step struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+	Down        func(ctx context.Context) error // 为 nil 表示不支持回滚
+}
+
+// Noop 用于没有需要撤销内容的 Down，例如只补齐字段的数据迁移
+func Noop(context.Context) error { return nil }
+
+// Record 代表一条已应用的迁移记录
+type Record struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// Store 保存迁移记录，由存储驱动实现
+type Store interface {
+	// Applied 返回全部已应用的迁移记录
+	Applied(ctx context.Context) ([]Record, error)
+	// Insert 记录迁移已应用
+	Insert(ctx context.Context, record Record) error
+	// Delete 删除迁移记录
+	Delete(ctx context.Context, version int) error
+	// Lock 获取迁移锁，防止多个实例同时迁移，返回释放锁的函数
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// Status 代表一个迁移步骤的状态
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Unknown     bool       `json:"unknown,omitempty"` // 数据库中有记录但程序不认识的版本
+}
+
+// Runner 按顺序执行迁移步骤
+type Runner struct {
+	store Store
+	steps []Step
+}
+
+// New 创建迁移执行器，步骤必须按版本号严格递增且版本号为正
+func New(store Store, steps []Step) (*Runner, error) {
+	for i, s := range steps {
+		if s.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", s.Description, s.Version)
+		}
+		if i > 0 && s.Version <= steps[i-1].Version {
+			return nil, fmt.Errorf("migration %d is out of order", s.Version)
+		}
+		if s.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up step", s.Version)
+		}
+	}
+	return &Runner{store: store, steps: steps}, nil
+}
+
+// Latest 返回程序已知的最新版本
+func (r *Runner) Latest() int {
+	if len(r.steps) == 0 {
+		return 0
+	}
+	return r.steps[len(r.steps)-1].Version
+}
+
+// Current 返回数据库当前的结构版本，即已应用的最大版本号
+func (r *Runner) Current(ctx context.Context) (int, error) {
+	applied, err := r.store.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for _, rec := range applied {
+		if rec.Version > current {
+			current = rec.Version
+		}
+	}
+	return current, nil
+}
+
+// Status 返回全部迁移步骤的状态，包括数据库中存在但程序不认识的版本
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.appliedByVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.steps))
+	for _, s := range r.steps {
+		st := Status{Version: s.Version, Description: s.Description}
+		if rec, ok := applied[s.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = &rec.AppliedAt
+			delete(applied, s.Version)
+		}
+		statuses = append(statuses, st)
+	}
+	for _, rec := range applied {
+		appliedAt := rec.AppliedAt
+		statuses = append(statuses, Status{
+			Version:     rec.Version,
+			Description: rec.Description,
+			Applied:     true,
+			AppliedAt:   &appliedAt,
+			Unknown:     true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check 检查数据库结构是否与程序一致：版本过新时返回 ErrSchemaTooNew，有未应用的迁移时返回 ErrPending
+func (r *Runner) Check(ctx context.Context) error {
+	applied, err := r.appliedByVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.checkKnown(applied); err != nil {
+		return err
+	}
+	for _, s := range r.steps {
+		if _, ok := applied[s.Version]; !ok {
+			return fmt.Errorf("%w: version %d (%s) is not applied", ErrPending, s.Version, s.Description)
+		}
+	}
+	return nil
+}
+
+// Up 按顺序应用版本不超过 target 的未应用迁移，target 为 0 表示应用到最新版本
+// 返回本次应用的步骤
+func (r *Runner) Up(ctx context.Context, target int) ([]Step, error) {
+	if target == 0 {
+		target = r.Latest()
+	}
+
+	unlock, err := r.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.appliedByVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkKnown(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Step
+	for _, s := range r.steps {
+		if s.Version > target {
+			break
+		}
+		if _, ok := applied[s.Version]; ok {
+			continue
+		}
+		if err := s.Up(ctx); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", s.Version, s.Description, err)
+		}
+		if err := r.store.Insert(ctx, Record{Version: s.Version, Description: s.Description, AppliedAt: time.Now()}); err != nil {
+			return done, fmt.Errorf("migration %d (%s): failed to record: %w", s.Version, s.Description, err)
+		}
+		done = append(done, s)
+	}
+	return done, nil
+}
+
+// Down 按倒序回滚版本高于 target 的已应用迁移，返回本次回滚的步骤
+func (r *Runner) Down(ctx context.Context, target int) ([]Step, error) {
+	if target < 0 {
+		return nil, fmt.Errorf("invalid target version %d", target)
+	}
+
+	unlock, err := r.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.appliedByVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkKnown(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Step
+	for i := len(r.steps) - 1; i >= 0; i-- {
+		s := r.steps[i]
+		if s.Version <= target {
+			break
+		}
+		if _, ok := applied[s.Version]; !ok {
+			continue
+		}
+		if s.Down == nil {
+			return done, fmt.Errorf("migration %d (%s): %w", s.Version, s.Description, ErrIrreversible)
+		}
+		if err := s.Down(ctx); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", s.Version, s.Description, err)
+		}
+		if err := r.store.Delete(ctx, s.Version); err != nil {
+			return done, fmt.Errorf("migration %d (%s): failed to remove record: %w", s.Version, s.Description, err)
+		}
+		done = append(done, s)
+	}
+	return done, nil
+}
+
+func (r *Runner) appliedByVersion(ctx context.Context) (map[int]Record, error) {
+	records, err := r.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]Record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// checkKnown 确认数据库中没有程序不认识的版本
+func (r *Runner) checkKnown(applied map[int]Record) error {
+	for version := range applied {
+		if version > r.Latest() {
+			return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, r.Latest())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// memStore 在内存中保存迁移记录，并记录锁的获取和释放
+type memStore struct {
+	records map[int]Record
+	locked  bool
+	locks   int
+	lockErr error
+}
+
+func newMemStore(versions ...int) *memStore {
+	s := &memStore{records: make(map[int]Record)}
+	for _, v := range versions {
+		s.records[v] = Record{Version: v}
+	}
+	return s
+}
+
+func (s *memStore) Applied(context.Context) ([]Record, error) {
+	var records []Record
+	for _, rec := range s.records {
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (s *memStore) Insert(_ context.Context, record Record) error {
+	s.records[record.Version] = record
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, version int) error {
+	delete(s.records, version)
+	return nil
+}
+
+func (s *memStore) Lock(context.Context) (func(), error) {
+	if s.lockErr != nil {
+		return nil, s.lockErr
+	}
+	if s.locked {
+		return nil, errors.New("already locked")
+	}
+	s.locked = true
+	s.locks++
+	return func() { s.locked = false }, nil
+}
+
+// steps 生成按版本号递增的迁移步骤，执行顺序记录在 log 中
+func steps(log *[]int, versions ...int) []Step {
+	var result []Step
+	for _, v := range versions {
+		result = append(result, Step{
+			Version:     v,
+			Description: "step",
+			Up:          func(context.Context) error { *log = append(*log, v); return nil },
+			Down:        func(context.Context) error { *log = append(*log, -v); return nil },
+		})
+	}
+	return result
+}
+
+func versions(steps []Step) []int {
+	var result []int
+	for _, s := range steps {
+		result = append(result, s.Version)
+	}
+	return result
+}
+
+func TestNewValidatesSteps(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	tests := [][]Step{
+		{{Version: 0, Up: up}},
+		{{Version: 2, Up: up}, {Version: 1, Up: up}},
+		{{Version: 1, Up: up}, {Version: 1, Up: up}},
+		{{Version: 1}},
+	}
+	for _, tt := range tests {
+		if _, err := New(newMemStore(), tt); err == nil {
+			t.Errorf("New(%v) succeeded, want an error", versions(tt))
+		}
+	}
+}
+
+func TestUpAppliesInOrderAndSkipsApplied(t *testing.T) {
+	ctx := context.Background()
+	var log []int
+	store := newMemStore(2)
+	r, err := New(store, steps(&log, 1, 2, 3, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := r.Up(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(log, []int{1, 3}) || !reflect.DeepEqual(versions(done), []int{1, 3}) {
+		t.Fatalf("Up(3) ran %v and returned %v, want [1 3]", log, versions(done))
+	}
+	if err := r.Check(ctx); !errors.Is(err, ErrPending) {
+		t.Errorf("Check() = %v, want ErrPending", err)
+	}
+
+	// 重复执行只应用剩余的步骤
+	if done, err = r.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions(done), []int{5}) {
+		t.Errorf("Up(0) returned %v, want [5]", versions(done))
+	}
+	if done, err = r.Up(ctx, 0); err != nil || len(done) != 0 {
+		t.Errorf("Up(0) on an up-to-date database returned %v, %v", versions(done), err)
+	}
+	if err := r.Check(ctx); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+	if current, _ := r.Current(ctx); current != 5 {
+		t.Errorf("Current() = %d, want 5", current)
+	}
+	if store.locked || store.locks != 3 {
+		t.Errorf("lock taken %d times, still held: %v", store.locks, store.locked)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	var log []int
+	store := newMemStore()
+	s := steps(&log, 1, 2, 3)
+	s[1].Up = func(context.Context) error { return errors.New("boom") }
+	r, _ := New(store, s)
+
+	done, err := r.Up(ctx, 0)
+	if err == nil {
+		t.Fatal("Up succeeded, want the step error")
+	}
+	if !reflect.DeepEqual(versions(done), []int{1}) || !reflect.DeepEqual(log, []int{1}) {
+		t.Errorf("Up applied %v and ran %v, want only [1]", versions(done), log)
+	}
+	if _, ok := store.records[2]; ok {
+		t.Error("failed step was recorded as applied")
+	}
+	if store.locked {
+		t.Error("lock was not released after a failure")
+	}
+}
+
+func TestLockErrorStopsMigration(t *testing.T) {
+	var log []int
+	store := newMemStore()
+	store.lockErr = errors.New("locked by another instance")
+	r, _ := New(store, steps(&log, 1))
+
+	if _, err := r.Up(context.Background(), 0); !errors.Is(err, store.lockErr) {
+		t.Fatalf("Up() = %v, want the lock error", err)
+	}
+	if len(log) != 0 || len(store.records) != 0 {
+		t.Errorf("migration ran without the lock: %v", log)
+	}
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	var log []int
+	store := newMemStore(1, 2, 3)
+	r, _ := New(store, steps(&log, 1, 2, 3, 4))
+
+	done, err := r.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(log, []int{-3, -2}) || !reflect.DeepEqual(versions(done), []int{3, 2}) {
+		t.Fatalf("Down(1) ran %v and returned %v, want [3 2]", log, versions(done))
+	}
+	if current, _ := r.Current(ctx); current != 1 {
+		t.Errorf("Current() = %d, want 1", current)
+	}
+	if _, err := r.Down(ctx, -1); err == nil {
+		t.Error("Down(-1) succeeded")
+	}
+}
+
+func TestDownIrreversible(t *testing.T) {
+	var log []int
+	store := newMemStore(1, 2)
+	s := steps(&log, 1, 2)
+	s[0].Down = nil
+	r, _ := New(store, s)
+
+	done, err := r.Down(context.Background(), 0)
+	if !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("Down() = %v, want ErrIrreversible", err)
+	}
+	if !reflect.DeepEqual(versions(done), []int{2}) {
+		t.Errorf("Down() reverted %v, want [2]", versions(done))
+	}
+	if _, ok := store.records[1]; !ok {
+		t.Error("irreversible step record was removed")
+	}
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	ctx := context.Background()
+	var log []int
+	store := newMemStore(1, 2, 7)
+	r, _ := New(store, steps(&log, 1, 2, 3))
+
+	if err := r.Check(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Check() = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := r.Up(ctx, 0); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Up() = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := r.Down(ctx, 0); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Down() = %v, want ErrSchemaTooNew", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("steps ran against a newer schema: %v", log)
+	}
+
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, st := range statuses {
+		if st.Applied {
+			got = append(got, st.Version)
+		}
+	}
+	last := statuses[len(statuses)-1]
+	if !reflect.DeepEqual(got, []int{1, 2, 7}) || last.Version != 7 || !last.Unknown {
+		t.Errorf("Status() = %+v", statuses)
+	}
+}
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeleteSharesByAuthor 实现 Storage 接口
+func (s *MongoStorage) DeleteSharesByAuthor(ctx context.Context, author string) (int64, error) {
+	ids, err := s.collection.Distinct(ctx, "shareId", bson.M{"author": author})
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res, err := s.collection.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.views.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}}); err != nil {
+		return res.DeletedCount, err
+	}
+	if _, err := s.visitors.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}}); err != nil {
+		return res.DeletedCount, err
+	}
+	if _, err := s.reports.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}}); err != nil {
+		return res.DeletedCount, err
+	}
+	if _, err := s.comments.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}}); err != nil {
+		return res.DeletedCount, err
+	}
+	_, err = s.stars.DeleteMany(ctx, bson.M{"shareId": bson.M{"$in": ids}})
+	return res.DeletedCount, err
+}
+
+// SetShareExpiry 实现 Storage 接口
+func (s *MongoStorage) SetShareExpiry(ctx context.Context, shareId string, expiresAt *time.Time) error {
+	update := bson.M{"$unset": bson.M{"expires_at": ""}}
+	if expiresAt != nil {
+		update = bson.M{"$set": bson.M{"expires_at": *expiresAt}}
+	}
+
+	res, err := s.collection.UpdateOne(ctx, bson.M{"shareId": shareId}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ExportShares 实现 Storage 接口
+func (s *MongoStorage) ExportShares(ctx context.Context, query *models.ShareQuery, fn func(*models.Share) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.collection.Find(ctx, bson.M{"$and": shareQueryConds(query)}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	// 逐条解码，避免一次性加载全部分享
+	for cursor.Next(ctx) {
+		var share models.Share
+		if err := cursor.Decode(&share); err != nil {
+			return err
+		}
+		if err := fn(&share); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ImportShare 实现 Storage 接口
+func (s *MongoStorage) ImportShare(ctx context.Context, share *models.Share, replace bool) (bool, error) {
+	// 不沿用来源环境的 _id，已存在的文档保留自己的 _id
+	doc := *share
+	doc.ID = primitive.NilObjectID
+
+	if replace {
+		_, err := s.collection.ReplaceOne(ctx, bson.M{"shareId": doc.ShareID}, &doc, options.Replace().SetUpsert(true))
+		return err == nil, err
+	}
+
+	_, err := s.collection.InsertOne(ctx, &doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateCollection 实现 Storage 接口
+func (s *MongoStorage) CreateCollection(ctx context.Context, collection *models.Collection) error {
+	_, err := s.colls.InsertOne(ctx, collection)
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrDuplicateID
+	}
+	return err
+}
+
+// GetCollection 实现 Storage 接口
+func (s *MongoStorage) GetCollection(ctx context.Context, collectionId string) (*models.Collection, error) {
+	var collection models.Collection
+	err := s.colls.FindOne(ctx, bson.M{"collectionId": collectionId}).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// UpdateCollection 实现 Storage 接口
+func (s *MongoStorage) UpdateCollection(ctx context.Context, collection *models.Collection) error {
+	collection.UpdatedAt = time.Now()
+	res, err := s.colls.UpdateOne(ctx,
+		bson.M{"collectionId": collection.CollectionID},
+		bson.M{"$set": bson.M{
+			"title":       collection.Title,
+			"description": collection.Description,
+			"shares":      collection.ShareIDs,
+			"updated_at":  collection.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteCollection 实现 Storage 接口
+func (s *MongoStorage) DeleteCollection(ctx context.Context, collectionId string) error {
+	res, err := s.colls.DeleteOne(ctx, bson.M{"collectionId": collectionId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateComment 实现 Storage 接口
+func (s *MongoStorage) CreateComment(ctx context.Context, comment *models.Comment) error {
+	_, err := s.comments.InsertOne(ctx, comment)
+	return err
+}
+
+// GetComment 实现 Storage 接口
+func (s *MongoStorage) GetComment(ctx context.Context, commentId string) (*models.Comment, error) {
+	var comment models.Comment
+	err := s.comments.FindOne(ctx, bson.M{"commentId": commentId}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListComments 实现 Storage 接口
+func (s *MongoStorage) ListComments(ctx context.Context, shareId string) ([]*models.Comment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.comments.Find(ctx, bson.M{"shareId": shareId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountComments 实现 Storage 接口
+func (s *MongoStorage) CountComments(ctx context.Context, shareId string) (int64, error) {
+	return s.comments.CountDocuments(ctx, bson.M{"shareId": shareId})
+}
+
+// UpdateComment 实现 Storage 接口
+func (s *MongoStorage) UpdateComment(ctx context.Context, commentId, body string, at time.Time) error {
+	res, err := s.comments.UpdateOne(ctx,
+		bson.M{"commentId": commentId},
+		bson.M{"$set": bson.M{"body": body, "updated_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteComment 实现 Storage 接口
+func (s *MongoStorage) DeleteComment(ctx context.Context, commentId string) error {
+	res, err := s.comments.DeleteOne(ctx, bson.M{"commentId": commentId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+	_, err = s.comments.DeleteMany(ctx, bson.M{"parent_id": commentId})
+	return err
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// DefaultDatabase 是连接串未指定数据库时使用的数据库名
+const DefaultDatabase = "playground"
+
+func init() {
+	storage.Register("mongo", Open)
+}
+
+// Open 通过连接串打开存储，数据库名取自连接串路径（如 mongodb://localhost:27017/playground）
+func Open(ctx context.Context, dsn string) (storage.Storage, error) {
+	cs, err := connstring.ParseAndValidate(dsn)
+	if err != nil {
+		return nil, err
+	}
+	database := cs.Database
+	if database == "" {
+		database = DefaultDatabase
+	}
+	return NewMongoStorage(ctx, dsn, database, "shares")
+}
+
+// DSN 在连接串中补充数据库名，连接串已指定数据库或 database 为空时原样返回
+func DSN(uri, database string) string {
+	if database == "" {
+		return uri
+	}
+	if cs, err := connstring.Parse(uri); err == nil && cs.Database != "" {
+		return uri
+	}
+
+	base, query, hasQuery := strings.Cut(uri, "?")
+	// 跳过协议部分后，主机列表之后的第一个 / 为路径起点
+	scheme, hosts, _ := strings.Cut(base, "://")
+	hosts = strings.TrimSuffix(hosts, "/")
+	dsn := scheme + "://" + hosts + "/" + database
+	if hasQuery {
+		dsn += "?" + query
+	}
+	return dsn
+}
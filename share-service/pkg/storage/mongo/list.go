@@ -0,0 +1,159 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// listCursor 记录上一页最后一条记录的排序键
+type listCursor struct {
+	Sort      models.ShareSort `json:"s"`
+	Views     int64            `json:"v,omitempty"`
+	Score     float64          `json:"t,omitempty"`
+	CreatedAt time.Time        `json:"c,omitempty"`
+	ID        string           `json:"id"`
+}
+
+func encodeCursor(sort models.ShareSort, share *models.Share) string {
+	c := listCursor{Sort: sort, ID: share.ID.Hex()}
+	switch sort {
+	case models.SortViews:
+		c.Views = share.Views
+	case models.SortTrending:
+		c.Score = share.Trending
+	default:
+		c.CreatedAt = share.CreatedAt
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, sort models.ShareSort) (*listCursor, primitive.ObjectID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, primitive.NilObjectID, storage.ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort {
+		return nil, primitive.NilObjectID, storage.ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, primitive.NilObjectID, storage.ErrInvalidCursor
+	}
+	return &c, id, nil
+}
+
+// publicListFilter 返回仅匹配可公开列出的分享的过滤条件
+func publicListFilter(now time.Time) bson.A {
+	return bson.A{
+		bson.M{"visibility": bson.M{"$in": bson.A{nil, models.VisibilityPublic}}},
+		bson.M{"max_views": bson.M{"$exists": false}},
+		bson.M{"exhausted": bson.M{"$ne": true}},
+		bson.M{"hidden": bson.M{"$ne": true}},
+		bson.M{"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		}},
+	}
+}
+
+// ListShares 实现 Storage 接口
+func (s *MongoStorage) ListShares(ctx context.Context, query *models.ShareQuery) ([]*models.Share, string, error) {
+	sortKey := "created_at"
+	switch query.Sort {
+	case models.SortViews:
+		sortKey = "views"
+	case models.SortTrending:
+		sortKey = "trending_score"
+	}
+
+	conds := shareQueryConds(query)
+	if query.Sort == models.SortTrending {
+		// 热度由后台任务计算，没有近期访问或收藏的分享不出现在热门列表中
+		conds = append(conds, bson.M{"trending_score": bson.M{"$gt": 0}})
+	}
+
+	// 游标分页：取排序键严格小于上一页末尾的记录，排序键相同时按 _id 区分
+	if query.Cursor != "" {
+		cur, id, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, "", err
+		}
+		var last interface{} = cur.CreatedAt
+		switch query.Sort {
+		case models.SortViews:
+			last = cur.Views
+		case models.SortTrending:
+			last = cur.Score
+		}
+		conds = append(conds, bson.M{"$or": bson.A{
+			bson.M{sortKey: bson.M{"$lt": last}},
+			bson.M{sortKey: last, "_id": bson.M{"$lt": id}},
+		}})
+	}
+
+	// 多取一条用于判断是否还有下一页
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortKey, Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(query.Limit + 1)).
+		SetProjection(bson.M{"code": 0, "password_hash": 0, "owner_key_hash": 0, "last_run": 0})
+
+	cursor, err := s.collection.Find(ctx, bson.M{"$and": conds}, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []*models.Share
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(shares) > query.Limit {
+		shares = shares[:query.Limit]
+		next = encodeCursor(query.Sort, shares[len(shares)-1])
+	}
+	return shares, next, nil
+}
+
+// shareQueryConds 将查询条件转换为过滤条件，不包括分页游标
+func shareQueryConds(query *models.ShareQuery) bson.A {
+	// 列出用户自己的分享时包括非公开分享，管理查询不做限制
+	var conds bson.A
+	if query.OwnerID != "" {
+		conds = bson.A{bson.M{"owner_id": query.OwnerID}}
+	} else if query.All {
+		conds = bson.A{bson.M{}}
+	} else {
+		conds = publicListFilter(time.Now())
+	}
+	if query.Author != "" {
+		conds = append(conds, bson.M{"author": query.Author})
+	}
+	if query.Tag != "" {
+		conds = append(conds, bson.M{"tags": query.Tag})
+	}
+	if query.Version != "" {
+		conds = append(conds, bson.M{"version": query.Version})
+	}
+	if query.From != nil {
+		conds = append(conds, bson.M{"created_at": bson.M{"$gte": *query.From}})
+	}
+	if query.To != nil {
+		conds = append(conds, bson.M{"created_at": bson.M{"$lt": *query.To}})
+	}
+	if query.Search != "" {
+		conds = append(conds, bson.M{"$text": bson.M{"$search": query.Search}})
+	}
+	return conds
+}
@@ -0,0 +1,429 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage/migrate"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+	lockCollection       = "schema_lock"
+
+	// 持有时间超过 lockTimeout 的迁移锁视为进程异常退出遗留的锁
+	lockTimeout  = 10 * time.Minute
+	lockInterval = time.Second
+)
+
+// collectionIndexes 代表某个集合的一组索引
+type collectionIndexes struct {
+	collection string
+	indexes    []mongo.IndexModel
+}
+
+// Migrations 返回存储的迁移执行器
+func (s *MongoStorage) Migrations() *migrate.Runner {
+	shares := s.collection.Name()
+	steps := []migrate.Step{
+		s.indexStep(1, "create share indexes", collectionIndexes{shares, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "shareId", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+			{
+				Keys: bson.D{{Key: "content_hash", Value: 1}},
+			},
+			// 列表排序和过滤使用的索引
+			{
+				Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			},
+			{
+				Keys: bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: -1}},
+			},
+			{
+				Keys: bson.D{{Key: "author", Value: 1}, {Key: "created_at", Value: -1}},
+			},
+			{
+				Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}},
+			},
+			{
+				Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}},
+			},
+			// 全文搜索索引，每个集合只能有一个
+			{
+				Keys: bson.D{
+					{Key: "title", Value: "text"},
+					{Key: "description", Value: "text"},
+					{Key: "code", Value: "text"},
+				},
+				Options: options.Index().
+					SetName("share_text").
+					SetDefaultLanguage("none").
+					SetWeights(bson.D{
+						{Key: "title", Value: 10},
+						{Key: "description", Value: 5},
+						{Key: "code", Value: 1},
+					}),
+			},
+		}}),
+		s.indexStep(2, "create view statistics and collection indexes",
+			collectionIndexes{"share_views", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "shareId", Value: 1}, {Key: "day", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			}},
+			collectionIndexes{"collections", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "collectionId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			}},
+		),
+		s.indexStep(3, "create user and token indexes",
+			collectionIndexes{"users", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "userId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "username", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					// 同一个外部身份只能对应一个用户
+					Keys: bson.D{{Key: "identity.issuer", Value: 1}, {Key: "identity.subject", Value: 1}},
+					Options: options.Index().SetUnique(true).
+						SetPartialFilterExpression(bson.M{"identity": bson.M{"$exists": true}}),
+				},
+			}},
+			collectionIndexes{"tokens", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "hash", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "tokenId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "userId", Value: 1}, {Key: "kind", Value: 1}},
+				},
+				{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			}},
+		),
+		s.indexStep(4, "create report and ban indexes",
+			collectionIndexes{"reports", []mongo.IndexModel{
+				{
+					// 同一举报者对同一分享只保留一条举报
+					Keys:    bson.D{{Key: "shareId", Value: 1}, {Key: "reporter_key", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "resolved", Value: 1}, {Key: "shareId", Value: 1}},
+				},
+			}},
+			collectionIndexes{"bans", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "banId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "kind", Value: 1}, {Key: "value", Value: 1}},
+				},
+				{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				},
+			}},
+		),
+		{
+			Version:     5,
+			Description: "backfill content_hash for shares created before deduplication",
+			Up:          s.backfillContentHash,
+			Down:        migrate.Noop,
+		},
+		{
+			Version:     6,
+			Description: "backfill visibility for shares created before visibility settings",
+			Up: func(ctx context.Context) error {
+				_, err := s.collection.UpdateMany(ctx,
+					bson.M{"visibility": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"visibility": models.VisibilityPublic}},
+				)
+				return err
+			},
+			Down: migrate.Noop,
+		},
+		s.indexStep(7, "create comment indexes", collectionIndexes{"comments", []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "commentId", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{{Key: "shareId", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+			{
+				Keys: bson.D{{Key: "parent_id", Value: 1}},
+			},
+		}}),
+		s.indexStep(8, "create star and trending indexes",
+			collectionIndexes{"stars", []mongo.IndexModel{
+				{
+					// 同一用户对同一分享只保留一条收藏
+					Keys:    bson.D{{Key: "shareId", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "created_at", Value: 1}},
+				},
+			}},
+			collectionIndexes{"share_views", []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "day", Value: 1}},
+				},
+			}},
+			collectionIndexes{shares, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "trending_score", Value: -1}, {Key: "_id", Value: -1}},
+				},
+			}},
+		),
+		s.indexStep(9, "create webhook and delivery indexes",
+			collectionIndexes{"webhooks", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "webhookId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}},
+				},
+			}},
+			collectionIndexes{"webhook_deliveries", []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "deliveryId", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					// 同一订阅的同一事件只投递一次
+					Keys: bson.D{{Key: "dedupe_key", Value: 1}},
+					Options: options.Index().SetUnique(true).
+						SetPartialFilterExpression(bson.M{"dedupe_key": bson.M{"$exists": true}}),
+				},
+				{
+					Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+				},
+				{
+					Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "created_at", Value: -1}},
+				},
+				{
+					// 投递记录保留 30 天
+					Keys:    bson.D{{Key: "created_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(30 * 24 * 3600),
+				},
+			}},
+		),
+		s.visitorsStep(10),
+	}
+
+	runner, err := migrate.New(&migrationStore{db: s.db}, steps)
+	if err != nil {
+		// 步骤列表是固定的，出错说明代码有误
+		panic(err)
+	}
+	return runner
+}
+
+// indexStep 创建一个建立索引的迁移步骤，回滚时删除这些索引
+func (s *MongoStorage) indexStep(version int, description string, sets ...collectionIndexes) migrate.Step {
+	return migrate.Step{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context) error {
+			for _, set := range sets {
+				if _, err := s.db.Collection(set.collection).Indexes().CreateMany(ctx, set.indexes); err != nil {
+					return fmt.Errorf("%s: %w", set.collection, err)
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context) error {
+			for _, set := range sets {
+				for _, index := range set.indexes {
+					_, err := s.db.Collection(set.collection).Indexes().DropOne(ctx, indexName(index))
+					if err != nil && !isIndexNotFound(err) {
+						return fmt.Errorf("%s: %w", set.collection, err)
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// visitorsStep 将访客去重从每日统计文档中的 visitors 数组移到单独的集合，
+// 避免热门分享的统计文档超过 16MB 的文档大小限制。旧的 visitors 数组只用于当天去重，直接删除
+func (s *MongoStorage) visitorsStep(version int) migrate.Step {
+	step := s.indexStep(version, "move daily visitors to a separate collection",
+		collectionIndexes{"share_visitors", []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "shareId", Value: 1}, {Key: "day", Value: 1}, {Key: "visitor", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32(visitorTTL.Seconds())),
+			},
+		}},
+	)
+	createIndexes := step.Up
+	step.Up = func(ctx context.Context) error {
+		if err := createIndexes(ctx); err != nil {
+			return err
+		}
+		_, err := s.views.UpdateMany(ctx,
+			bson.M{"visitors": bson.M{"$exists": true}},
+			bson.M{"$unset": bson.M{"visitors": ""}},
+		)
+		return err
+	}
+	return step
+}
+
+// backfillContentHash 为缺少内容哈希的分享计算哈希
+func (s *MongoStorage) backfillContentHash(ctx context.Context) error {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"content_hash": bson.M{"$exists": false}},
+			bson.M{"content_hash": ""},
+		},
+		"exhausted": bson.M{"$ne": true},
+	}
+	opts := options.Find().SetProjection(bson.M{"code": 1, "version": 1, "language": 1})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var share models.Share
+		if err := cursor.Decode(&share); err != nil {
+			return err
+		}
+		language := share.Language
+		if language == "" {
+			language = "go"
+		}
+		hash := models.ContentHash(share.Code, share.Version, language)
+		if _, err := s.collection.UpdateByID(ctx, share.ID, bson.M{"$set": bson.M{"content_hash": hash}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// indexName 返回索引名称，未指定时与 MongoDB 的默认命名规则一致
+func indexName(index mongo.IndexModel) string {
+	if index.Options != nil && index.Options.Name != nil {
+		return *index.Options.Name
+	}
+	var parts []string
+	for _, key := range index.Keys.(bson.D) {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 27 || cmdErr.Code == 26 // IndexNotFound、NamespaceNotFound
+	}
+	return false
+}
+
+// migrationStore 将迁移记录保存在 schema_migrations 集合中
+type migrationStore struct {
+	db *mongo.Database
+}
+
+func (m *migrationStore) Applied(ctx context.Context) ([]migrate.Record, error) {
+	cursor, err := m.db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []migrate.Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (m *migrationStore) Insert(ctx context.Context, record migrate.Record) error {
+	_, err := m.db.Collection(migrationsCollection).ReplaceOne(ctx,
+		bson.M{"_id": record.Version}, record, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (m *migrationStore) Delete(ctx context.Context, version int) error {
+	_, err := m.db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+// Lock 通过插入固定 _id 的文档实现互斥，其他实例等待锁释放
+func (m *migrationStore) Lock(ctx context.Context) (func(), error) {
+	locks := m.db.Collection(lockCollection)
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())
+
+	for {
+		_, err := locks.InsertOne(ctx, bson.M{"_id": "migrations", "owner": owner, "locked_at": time.Now()})
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		// 清理遗留的过期锁后立即重试
+		res, err := locks.DeleteOne(ctx, bson.M{"_id": "migrations", "locked_at": bson.M{"$lt": time.Now().Add(-lockTimeout)}})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for migration lock: %w", ctx.Err())
+		case <-time.After(lockInterval):
+		}
+	}
+
+	return func() {
+		// 使用独立的上下文，确保调用方取消后仍能释放锁
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		locks.DeleteOne(ctx, bson.M{"_id": "migrations", "owner": owner})
+	}, nil
+}
@@ -0,0 +1,198 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetShareHidden 实现 Storage 接口
+func (s *MongoStorage) SetShareHidden(ctx context.Context, shareId string, hidden bool, reason string) error {
+	update := bson.M{"$unset": bson.M{"hidden": "", "hidden_reason": ""}}
+	if hidden {
+		set := bson.M{"hidden": true}
+		update = bson.M{"$set": set}
+		if reason != "" {
+			set["hidden_reason"] = reason
+		} else {
+			update["$unset"] = bson.M{"hidden_reason": ""}
+		}
+	}
+
+	res, err := s.collection.UpdateOne(ctx, bson.M{"shareId": shareId}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// HideSharesByAuthor 实现 Storage 接口
+func (s *MongoStorage) HideSharesByAuthor(ctx context.Context, author, reason string) (int64, error) {
+	set := bson.M{"hidden": true}
+	if reason != "" {
+		set["hidden_reason"] = reason
+	}
+	res, err := s.collection.UpdateMany(ctx, bson.M{"author": author, "hidden": bson.M{"$ne": true}}, bson.M{"$set": set})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// DeleteShare 实现 Storage 接口
+func (s *MongoStorage) DeleteShare(ctx context.Context, shareId string) error {
+	res, err := s.collection.DeleteOne(ctx, bson.M{"shareId": shareId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+
+	// 同时删除访问统计、举报记录、评论和收藏
+	if _, err := s.views.DeleteMany(ctx, bson.M{"shareId": shareId}); err != nil {
+		return err
+	}
+	if _, err := s.visitors.DeleteMany(ctx, bson.M{"shareId": shareId}); err != nil {
+		return err
+	}
+	if _, err := s.reports.DeleteMany(ctx, bson.M{"shareId": shareId}); err != nil {
+		return err
+	}
+	if _, err := s.comments.DeleteMany(ctx, bson.M{"shareId": shareId}); err != nil {
+		return err
+	}
+	_, err = s.stars.DeleteMany(ctx, bson.M{"shareId": shareId})
+	return err
+}
+
+// CreateReport 实现 Storage 接口
+func (s *MongoStorage) CreateReport(ctx context.Context, report *models.Report) error {
+	_, err := s.reports.InsertOne(ctx, report)
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrAlreadyReported
+	}
+	return err
+}
+
+// ListReportedShares 实现 Storage 接口
+func (s *MongoStorage) ListReportedShares(ctx context.Context, query *models.ReportQuery) ([]*models.ReportedShare, error) {
+	match := bson.M{}
+	if !query.IncludeResolved {
+		match["resolved"] = bson.M{"$ne": true}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              "$shareId",
+			"reports":          bson.M{"$sum": 1},
+			"reasons":          bson.M{"$addToSet": "$reason"},
+			"last_reported_at": bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "reports", Value: -1}, {Key: "last_reported_at", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$skip", Value: int64(query.Offset)}},
+		{{Key: "$limit", Value: int64(query.Limit)}},
+	}
+
+	cursor, err := s.reports.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []*models.ReportedShare
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// ListReports 实现 Storage 接口
+func (s *MongoStorage) ListReports(ctx context.Context, shareId string) ([]*models.Report, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.reports.Find(ctx, bson.M{"shareId": shareId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// ResolveReports 实现 Storage 接口
+func (s *MongoStorage) ResolveReports(ctx context.Context, shareId string) error {
+	_, err := s.reports.UpdateMany(ctx,
+		bson.M{"shareId": shareId, "resolved": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"resolved": true}},
+	)
+	return err
+}
+
+// CreateBan 实现 Storage 接口
+func (s *MongoStorage) CreateBan(ctx context.Context, ban *models.Ban) error {
+	_, err := s.bans.InsertOne(ctx, ban)
+	return err
+}
+
+// ListBans 实现 Storage 接口
+func (s *MongoStorage) ListBans(ctx context.Context) ([]*models.Ban, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.bans.Find(ctx, activeBanFilter(bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bans []*models.Ban
+	if err := cursor.All(ctx, &bans); err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// DeleteBan 实现 Storage 接口
+func (s *MongoStorage) DeleteBan(ctx context.Context, banId string) error {
+	res, err := s.bans.DeleteOne(ctx, bson.M{"banId": banId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// FindBan 实现 Storage 接口
+func (s *MongoStorage) FindBan(ctx context.Context, kind models.BanKind, value string) (*models.Ban, error) {
+	var ban models.Ban
+	err := s.bans.FindOne(ctx, activeBanFilter(bson.M{"kind": kind, "value": value})).Decode(&ban)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// activeBanFilter 排除已过期但尚未被 TTL 索引清理的封禁
+func activeBanFilter(filter bson.M) bson.M {
+	filter["$or"] = bson.A{
+		bson.M{"expires_at": bson.M{"$exists": false}},
+		bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+	}
+	return filter
+}
@@ -2,10 +2,10 @@ package mongo
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -13,10 +13,23 @@ import (
 
 type MongoStorage struct {
 	client     *mongo.Client
+	db         *mongo.Database
 	collection *mongo.Collection
+	views      *mongo.Collection // 按天聚合的访问统计
+	visitors   *mongo.Collection // 当天访客记录，用于独立访客去重
+	colls      *mongo.Collection // 分享合集
+	users      *mongo.Collection // 注册用户
+	tokens     *mongo.Collection // 会话令牌和 API Key
+	reports    *mongo.Collection // 分享举报
+	bans       *mongo.Collection // 封禁记录
+	comments   *mongo.Collection // 分享评论
+	stars      *mongo.Collection // 用户收藏
+	webhooks   *mongo.Collection // Webhook 订阅
+	deliveries *mongo.Collection // Webhook 投递记录
 }
 
 // NewMongoStorage 创建新的 MongoDB 存储实例
+// 索引由迁移创建，使用前需要通过 Migrations 应用迁移
 func NewMongoStorage(ctx context.Context, uri, database, collection string) (*MongoStorage, error) {
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
@@ -28,32 +41,31 @@ func NewMongoStorage(ctx context.Context, uri, database, collection string) (*Mo
 		return nil, err
 	}
 
-	// 创建索引
-	col := client.Database(database).Collection(collection)
-	indexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "shareId", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys:    bson.D{{Key: "expires_at", Value: 1}},
-			Options: options.Index().SetExpireAfterSeconds(0),
-		},
-	}
-
-	if _, err := col.Indexes().CreateMany(ctx, indexes); err != nil {
-		return nil, err
-	}
-
+	db := client.Database(database)
 	return &MongoStorage{
 		client:     client,
-		collection: col,
+		db:         db,
+		collection: db.Collection(collection),
+		views:      db.Collection("share_views"),
+		visitors:   db.Collection("share_visitors"),
+		colls:      db.Collection("collections"),
+		users:      db.Collection("users"),
+		tokens:     db.Collection("tokens"),
+		reports:    db.Collection("reports"),
+		bans:       db.Collection("bans"),
+		comments:   db.Collection("comments"),
+		stars:      db.Collection("stars"),
+		webhooks:   db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
 	}, nil
 }
 
 // CreateShare 实现 Storage 接口
 func (s *MongoStorage) CreateShare(ctx context.Context, share *models.Share) error {
 	_, err := s.collection.InsertOne(ctx, share)
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrDuplicateID
+	}
 	return err
 }
 
@@ -67,27 +79,77 @@ func (s *MongoStorage) GetShare(ctx context.Context, shareId string) (*models.Sh
 	return &share, err
 }
 
+// FindShareByHash 实现 Storage 接口
+func (s *MongoStorage) FindShareByHash(ctx context.Context, contentHash string) (*models.Share, error) {
+	filter := bson.M{
+		"content_hash": contentHash,
+		"author":       bson.M{"$exists": false},
+		"expires_at":   bson.M{"$exists": false},
+		"visibility":   bson.M{"$in": bson.A{nil, models.VisibilityPublic}},
+		"max_views":    bson.M{"$exists": false},
+		"hidden":       bson.M{"$ne": true},
+		// 与 api.canDedupe 的条件保持一致：带标签或导入来源的分享不参与去重
+		"tags":   bson.M{"$in": bson.A{nil, bson.A{}}},
+		"origin": bson.M{"$exists": false},
+	}
+
+	var share models.Share
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	err := s.collection.FindOne(ctx, filter, opts).Decode(&share)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
 // IncrementViews 实现 Storage 接口
 func (s *MongoStorage) IncrementViews(ctx context.Context, shareId string) (int64, error) {
-	update := bson.M{
-		"$inc": bson.M{"views": 1},
-		"$set": bson.M{"last_viewed": time.Now()},
+	// 仅匹配未锁定且未达到访问上限的分享
+	filter := bson.M{
+		"shareId":   shareId,
+		"exhausted": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"max_views": bson.M{"$exists": false}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$views", "$max_views"}}},
+		},
+	}
+
+	// 使用聚合管道更新，在同一操作中计数并在达到上限时锁定、清除内容
+	reached := bson.M{"$and": bson.A{
+		bson.M{"$gt": bson.A{"$max_views", 0}},
+		bson.M{"$gte": bson.A{"$views", "$max_views"}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"views":       bson.M{"$add": bson.A{"$views", 1}},
+			"last_viewed": time.Now(),
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"exhausted": bson.M{"$cond": bson.A{reached, true, "$$REMOVE"}},
+			"code":      bson.M{"$cond": bson.A{reached, "$$REMOVE", "$code"}},
+			"last_run":  bson.M{"$cond": bson.A{reached, "$$REMOVE", "$last_run"}},
+		}}},
 	}
 
 	// 使用 FindOneAndUpdate 操作，返回更新后的文档
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updatedShare models.Share
 
-	err := s.collection.FindOneAndUpdate(
-		ctx,
-		bson.M{"shareId": shareId},
-		update,
-		opts,
-	).Decode(&updatedShare)
-
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedShare)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return 0, fmt.Errorf("share not found: %s", shareId)
+			// 区分分享不存在和访问次数已用尽
+			count, countErr := s.collection.CountDocuments(ctx, bson.M{"shareId": shareId})
+			if countErr != nil {
+				return 0, countErr
+			}
+			if count == 0 {
+				return 0, storage.ErrNotFound
+			}
+			return 0, storage.ErrViewLimitReached
 		}
 		return 0, err
 	}
@@ -95,6 +157,58 @@ func (s *MongoStorage) IncrementViews(ctx context.Context, shareId string) (int6
 	return updatedShare.Views, nil
 }
 
+// GetSharesByIDs 实现 Storage 接口
+func (s *MongoStorage) GetSharesByIDs(ctx context.Context, shareIds []string) ([]*models.Share, error) {
+	if len(shareIds) == 0 {
+		return nil, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"code": 0, "password_hash": 0, "owner_key_hash": 0, "last_run": 0})
+	cursor, err := s.collection.Find(ctx, bson.M{"shareId": bson.M{"$in": shareIds}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []*models.Share
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// UpdateTags 实现 Storage 接口
+func (s *MongoStorage) UpdateTags(ctx context.Context, shareId string, tags []string) error {
+	update := bson.M{"$set": bson.M{"tags": tags}}
+	if len(tags) == 0 {
+		update = bson.M{"$unset": bson.M{"tags": ""}}
+	}
+
+	res, err := s.collection.UpdateOne(ctx, bson.M{"shareId": shareId}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// UpdateRunResult 实现 Storage 接口
+func (s *MongoStorage) UpdateRunResult(ctx context.Context, shareId string, result *models.RunResult) error {
+	res, err := s.collection.UpdateOne(ctx,
+		bson.M{"shareId": shareId},
+		bson.M{"$set": bson.M{"last_run": result}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
 // DeleteExpiredShares 实现 Storage 接口
 func (s *MongoStorage) DeleteExpiredShares(ctx context.Context) error {
 	_, err := s.collection.DeleteMany(ctx, bson.M{
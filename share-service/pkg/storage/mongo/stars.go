@@ -0,0 +1,185 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 保存热度时每批写入的分享数量
+const trendingBatchSize = 500
+
+// StarShare 实现 Storage 接口
+func (s *MongoStorage) StarShare(ctx context.Context, shareId, userId string) (int64, error) {
+	_, err := s.stars.InsertOne(ctx, &models.Star{
+		ShareID:   shareId,
+		UserID:    userId,
+		CreatedAt: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// 已收藏，返回当前的收藏数
+		return s.shareStars(ctx, shareId)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return s.incrementStars(ctx, shareId, 1)
+}
+
+// UnstarShare 实现 Storage 接口
+func (s *MongoStorage) UnstarShare(ctx context.Context, shareId, userId string) (int64, error) {
+	res, err := s.stars.DeleteOne(ctx, bson.M{"shareId": shareId, "user_id": userId})
+	if err != nil {
+		return 0, err
+	}
+	if res.DeletedCount == 0 {
+		return s.shareStars(ctx, shareId)
+	}
+	return s.incrementStars(ctx, shareId, -1)
+}
+
+// HasStarred 实现 Storage 接口
+func (s *MongoStorage) HasStarred(ctx context.Context, shareId, userId string) (bool, error) {
+	n, err := s.stars.CountDocuments(ctx, bson.M{"shareId": shareId, "user_id": userId}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// incrementStars 原子地调整分享的收藏数并返回调整后的值
+func (s *MongoStorage) incrementStars(ctx context.Context, shareId string, delta int64) (int64, error) {
+	var share models.Share
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"shareId": shareId},
+		bson.M{"$inc": bson.M{"stars": delta}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"stars": 1}),
+	).Decode(&share)
+	if err == mongo.ErrNoDocuments {
+		return 0, storage.ErrNotFound
+	}
+	return share.Stars, err
+}
+
+func (s *MongoStorage) shareStars(ctx context.Context, shareId string) (int64, error) {
+	var share models.Share
+	err := s.collection.FindOne(ctx,
+		bson.M{"shareId": shareId},
+		options.FindOne().SetProjection(bson.M{"stars": 1}),
+	).Decode(&share)
+	if err == mongo.ErrNoDocuments {
+		return 0, storage.ErrNotFound
+	}
+	return share.Stars, err
+}
+
+// ListActivity 实现 Storage 接口
+func (s *MongoStorage) ListActivity(ctx context.Context, since time.Time) ([]*models.ShareActivity, error) {
+	since = since.UTC().Truncate(24 * time.Hour)
+	byKey := make(map[[2]string]*models.ShareActivity)
+	var result []*models.ShareActivity
+	get := func(shareId, day string) *models.ShareActivity {
+		key := [2]string{shareId, day}
+		a, ok := byKey[key]
+		if !ok {
+			a = &models.ShareActivity{ShareID: shareId, Day: day}
+			byKey[key] = a
+			result = append(result, a)
+		}
+		return a
+	}
+
+	// 访问使用按天统计中的独立访客数，同一访客反复刷新不会抬高热度
+	cursor, err := s.views.Find(ctx,
+		bson.M{"day": bson.M{"$gte": since.Format(dayLayout)}, "unique": bson.M{"$gt": 0}},
+		options.Find().SetProjection(bson.M{"shareId": 1, "day": 1, "unique": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc viewDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		get(doc.ShareID, doc.Date).Views += doc.Unique
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"shareId": "$shareId",
+				"day":     bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at"}},
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	starCursor, err := s.stars.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer starCursor.Close(ctx)
+	for starCursor.Next(ctx) {
+		var doc struct {
+			ID struct {
+				ShareID string `bson:"shareId"`
+				Day     string `bson:"day"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := starCursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		get(doc.ID.ShareID, doc.ID.Day).Stars += doc.Count
+	}
+	return result, starCursor.Err()
+}
+
+// SetTrendingScores 实现 Storage 接口
+func (s *MongoStorage) SetTrendingScores(ctx context.Context, scores map[string]float64, at time.Time) error {
+	// 本次写入的分享记录计算时间，之后只清除更早计算的热度。多个实例的计算重叠时，
+	// 较早的计算既不会覆盖也不会清除较晚计算写入的热度
+	// MongoDB 的时间精度为毫秒，截断后保证比较一致
+	stamp := at.Truncate(time.Millisecond)
+	older := bson.A{
+		bson.M{"trending_at": bson.M{"$exists": false}},
+		bson.M{"trending_at": bson.M{"$lt": stamp}},
+	}
+	writes := make([]mongo.WriteModel, 0, trendingBatchSize)
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := s.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+	for shareId, score := range scores {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"shareId": shareId, "$or": older}).
+			SetUpdate(bson.M{"$set": bson.M{"trending_score": score, "trending_at": stamp}}))
+		if len(writes) == trendingBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	_, err := s.collection.UpdateMany(ctx,
+		bson.M{"trending_score": bson.M{"$exists": true}, "$or": older},
+		bson.M{"$unset": bson.M{"trending_score": "", "trending_at": ""}},
+	)
+	return err
+}
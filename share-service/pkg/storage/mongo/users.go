@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateUser 实现 Storage 接口
+func (s *MongoStorage) CreateUser(ctx context.Context, user *models.User) error {
+	_, err := s.users.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrUsernameTaken
+	}
+	return err
+}
+
+// GetUserByID 实现 Storage 接口
+func (s *MongoStorage) GetUserByID(ctx context.Context, userId string) (*models.User, error) {
+	return s.findUser(ctx, bson.M{"userId": userId})
+}
+
+// GetUserByUsername 实现 Storage 接口
+func (s *MongoStorage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.findUser(ctx, bson.M{"username": username})
+}
+
+// GetUserByIdentity 实现 Storage 接口
+func (s *MongoStorage) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error) {
+	return s.findUser(ctx, bson.M{"identity.issuer": issuer, "identity.subject": subject})
+}
+
+// UpdateUserIdentity 实现 Storage 接口
+func (s *MongoStorage) UpdateUserIdentity(ctx context.Context, userId string, identity *models.ExternalIdentity, groups []string) error {
+	set := bson.M{"identity": identity}
+	update := bson.M{"$set": set}
+	if len(groups) > 0 {
+		set["groups"] = groups
+	} else {
+		update["$unset"] = bson.M{"groups": ""}
+	}
+	_, err := s.users.UpdateOne(ctx, bson.M{"userId": userId}, update)
+	return err
+}
+
+func (s *MongoStorage) findUser(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	err := s.users.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// TouchUserLogin 实现 Storage 接口
+func (s *MongoStorage) TouchUserLogin(ctx context.Context, userId string, at time.Time) error {
+	_, err := s.users.UpdateOne(ctx, bson.M{"userId": userId}, bson.M{"$set": bson.M{"last_login_at": at}})
+	return err
+}
+
+// SetUserAdmin 实现 Storage 接口
+func (s *MongoStorage) SetUserAdmin(ctx context.Context, userId string, admin bool) error {
+	update := bson.M{"$set": bson.M{"admin": true}}
+	if !admin {
+		update = bson.M{"$unset": bson.M{"admin": ""}}
+	}
+	res, err := s.users.UpdateOne(ctx, bson.M{"userId": userId}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// CreateToken 实现 Storage 接口
+func (s *MongoStorage) CreateToken(ctx context.Context, token *models.Token) error {
+	_, err := s.tokens.InsertOne(ctx, token)
+	return err
+}
+
+// GetTokenByHash 实现 Storage 接口
+func (s *MongoStorage) GetTokenByHash(ctx context.Context, hash string) (*models.Token, error) {
+	// TTL 索引的清理存在延迟，这里再次检查过期时间
+	filter := bson.M{
+		"hash": hash,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	var token models.Token
+	err := s.tokens.FindOne(ctx, filter).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListTokens 实现 Storage 接口
+func (s *MongoStorage) ListTokens(ctx context.Context, userId string, kind models.TokenKind) ([]*models.Token, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := s.tokens.Find(ctx, bson.M{"userId": userId, "kind": kind}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.Token
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// TouchToken 实现 Storage 接口
+func (s *MongoStorage) TouchToken(ctx context.Context, tokenId string, at time.Time) error {
+	_, err := s.tokens.UpdateOne(ctx, bson.M{"tokenId": tokenId}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
+
+// DeleteToken 实现 Storage 接口
+func (s *MongoStorage) DeleteToken(ctx context.Context, userId, tokenId string, kind models.TokenKind) error {
+	res, err := s.tokens.DeleteOne(ctx, bson.M{"userId": userId, "tokenId": tokenId, "kind": kind})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package mongo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 按天统计使用的日期格式
+const dayLayout = "2006-01-02"
+
+// MongoDB 字段名不能包含 "." 和 "$"，来源域名需要转义后作为键
+var referrerEscaper = strings.NewReplacer("%", "%25", ".", "%2E", "$", "%24")
+var referrerUnescaper = strings.NewReplacer("%2E", ".", "%24", "$", "%25", "%")
+
+// viewDoc 是每日访问统计在 MongoDB 中的存储结构，访客记录保存在单独的集合中
+type viewDoc struct {
+	models.DailyViews `bson:",inline"`
+	ShareID           string `bson:"shareId"`
+}
+
+// 每天最多单独统计的来源域名数，超出的来源计入 otherReferrer，避免统计文档无限增长
+const (
+	maxReferrers  = 100
+	otherReferrer = "other"
+)
+
+// 访客记录只用于当天去重，保留到第二天结束后由 TTL 索引删除
+const visitorTTL = 48 * time.Hour
+
+// visitorDoc 记录某个访客在某天访问过某个分享，(shareId, day, visitor) 唯一
+type visitorDoc struct {
+	ShareID   string    `bson:"shareId"`
+	Day       string    `bson:"day"`
+	Visitor   string    `bson:"visitor"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// RecordView 实现 Storage 接口
+func (s *MongoStorage) RecordView(ctx context.Context, event *models.ViewEvent) (bool, error) {
+	day := event.Time.UTC().Format(dayLayout)
+
+	// 访客记录写入成功说明是当天的新访客，已存在时触发唯一索引冲突
+	_, err := s.visitors.InsertOne(ctx, &visitorDoc{
+		ShareID:   event.ShareID,
+		Day:       day,
+		Visitor:   event.VisitorKey,
+		CreatedAt: event.Time,
+	})
+	unique := err == nil
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	inc := bson.M{"total": 1}
+	if unique {
+		inc["unique"] = 1
+	}
+	if event.Embedded {
+		inc["embedded"] = 1
+	} else {
+		inc["direct"] = 1
+	}
+	if event.Referrer == "" {
+		_, err = s.views.UpdateOne(ctx, bson.M{"shareId": event.ShareID, "day": day}, bson.M{"$inc": inc}, options.Update().SetUpsert(true))
+		return unique, err
+	}
+
+	// 来源已经统计过或当天的来源数未达上限时单独计数；
+	// 当天文档已存在但来源数已满时过滤条件不匹配，upsert 触发唯一索引冲突，改为计入 otherReferrer
+	key := "referrers." + referrerEscaper.Replace(event.Referrer)
+	inc[key] = 1
+	filter := bson.M{
+		"shareId": event.ShareID,
+		"day":     day,
+		"$or": bson.A{
+			bson.M{key: bson.M{"$exists": true}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{
+				bson.M{"$size": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$referrers", bson.M{}}}}},
+				maxReferrers,
+			}}},
+		},
+	}
+	_, err = s.views.UpdateOne(ctx, filter, bson.M{"$inc": inc}, options.Update().SetUpsert(true))
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return unique, err
+	}
+	// 冲突也可能来自并发创建当天文档，不使用 upsert 再试一次
+	res, err := s.views.UpdateOne(ctx, filter, bson.M{"$inc": inc})
+	if err != nil || res.MatchedCount > 0 {
+		return unique, err
+	}
+
+	delete(inc, key)
+	inc["referrers."+otherReferrer] = 1
+	_, err = s.views.UpdateOne(ctx, bson.M{"shareId": event.ShareID, "day": day}, bson.M{"$inc": inc}, options.Update().SetUpsert(true))
+	return unique, err
+}
+
+// GetDailyViews 实现 Storage 接口
+func (s *MongoStorage) GetDailyViews(ctx context.Context, shareId string, from, to time.Time) ([]*models.DailyViews, error) {
+	filter := bson.M{
+		"shareId": shareId,
+		"day": bson.M{
+			"$gte": from.UTC().Format(dayLayout),
+			"$lte": to.UTC().Format(dayLayout),
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: 1}})
+
+	cursor, err := s.views.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.DailyViews
+	for cursor.Next(ctx) {
+		var daily models.DailyViews
+		if err := cursor.Decode(&daily); err != nil {
+			return nil, err
+		}
+		if len(daily.Referrers) > 0 {
+			referrers := make(map[string]int64, len(daily.Referrers))
+			for k, v := range daily.Referrers {
+				referrers[referrerUnescaper.Replace(k)] = v
+			}
+			daily.Referrers = referrers
+		}
+		result = append(result, &daily)
+	}
+	return result, cursor.Err()
+}
@@ -0,0 +1,211 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListExpiringShares 实现 Storage 接口
+func (s *MongoStorage) ListExpiringShares(ctx context.Context, from, to time.Time) ([]*models.Share, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetProjection(bson.M{"code": 0, "password_hash": 0, "owner_key_hash": 0, "last_run": 0})
+	cursor, err := s.collection.Find(ctx, bson.M{"expires_at": bson.M{"$gte": from, "$lt": to}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []*models.Share
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// CreateWebhook 实现 Storage 接口
+func (s *MongoStorage) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	_, err := s.webhooks.InsertOne(ctx, webhook)
+	return err
+}
+
+// GetWebhook 实现 Storage 接口
+func (s *MongoStorage) GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.webhooks.FindOne(ctx, bson.M{"webhookId": webhookId}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks 实现 Storage 接口
+func (s *MongoStorage) ListWebhooks(ctx context.Context, ownerId string) ([]*models.Webhook, error) {
+	return s.findWebhooks(ctx, bson.M{"owner_id": ownerId})
+}
+
+// ListActiveWebhooks 实现 Storage 接口
+func (s *MongoStorage) ListActiveWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return s.findWebhooks(ctx, bson.M{"active": true})
+}
+
+func (s *MongoStorage) findWebhooks(ctx context.Context, filter bson.M) ([]*models.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := s.webhooks.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []*models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook 实现 Storage 接口
+func (s *MongoStorage) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	res, err := s.webhooks.UpdateOne(ctx,
+		bson.M{"webhookId": webhook.WebhookID},
+		bson.M{"$set": bson.M{
+			"url":            webhook.URL,
+			"secret":         webhook.Secret,
+			"events":         webhook.Events,
+			"filter":         webhook.Filter,
+			"view_threshold": webhook.ViewThreshold,
+			"active":         webhook.Active,
+			"updated_at":     webhook.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWebhook 实现 Storage 接口
+func (s *MongoStorage) DeleteWebhook(ctx context.Context, webhookId string) error {
+	res, err := s.webhooks.DeleteOne(ctx, bson.M{"webhookId": webhookId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return storage.ErrNotFound
+	}
+	_, err = s.deliveries.DeleteMany(ctx, bson.M{"webhookId": webhookId})
+	return err
+}
+
+// CreateDelivery 实现 Storage 接口
+func (s *MongoStorage) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	_, err := s.deliveries.InsertOne(ctx, delivery)
+	if mongo.IsDuplicateKeyError(err) && delivery.DedupeKey != "" {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ClaimDeliveries 实现 Storage 接口
+func (s *MongoStorage) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	// 逐个原子地推迟下次尝试时间，其他实例在租期内不会再领取到同一个事件
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+	var claimed []*models.WebhookDelivery
+	for len(claimed) < limit {
+		var delivery models.WebhookDelivery
+		err := s.deliveries.FindOneAndUpdate(ctx,
+			bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+			opts,
+		).Decode(&delivery)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return claimed, err
+		}
+		claimed = append(claimed, &delivery)
+	}
+	return claimed, nil
+}
+
+// FinishDeliveryAttempt 实现 Storage 接口
+func (s *MongoStorage) FinishDeliveryAttempt(ctx context.Context, deliveryId string, attempt *models.DeliveryAttempt, status models.DeliveryStatus, next *time.Time) error {
+	update := bson.M{
+		"$push": bson.M{"attempts": attempt},
+		"$set":  bson.M{"status": status},
+	}
+	if next != nil {
+		update["$set"].(bson.M)["next_attempt_at"] = *next
+	} else {
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+	res, err := s.deliveries.UpdateOne(ctx, bson.M{"deliveryId": deliveryId}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// GetDelivery 实现 Storage 接口
+func (s *MongoStorage) GetDelivery(ctx context.Context, deliveryId string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.deliveries.FindOne(ctx, bson.M{"deliveryId": deliveryId}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries 实现 Storage 接口
+func (s *MongoStorage) ListDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.deliveries.Find(ctx, bson.M{"webhookId": webhookId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery 实现 Storage 接口
+func (s *MongoStorage) RetryDelivery(ctx context.Context, deliveryId string, at time.Time) error {
+	res, err := s.deliveries.UpdateOne(ctx,
+		bson.M{"deliveryId": deliveryId},
+		bson.M{"$set": bson.M{"status": models.DeliveryPending, "next_attempt_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
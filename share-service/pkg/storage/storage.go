@@ -2,24 +2,239 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage/migrate"
+)
+
+var (
+	// ErrDuplicateID 表示分享 ID 已被占用
+	ErrDuplicateID = errors.New("duplicate share id")
+	// ErrNotFound 表示要操作的记录不存在
+	ErrNotFound = errors.New("not found")
+	// ErrViewLimitReached 表示分享的访问次数已用尽
+	ErrViewLimitReached = errors.New("share view limit reached")
+	// ErrInvalidCursor 表示分页游标无法解析
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrUsernameTaken 表示用户名已被注册
+	ErrUsernameTaken = errors.New("username already taken")
+	// ErrAlreadyReported 表示同一举报者已经举报过该分享
+	ErrAlreadyReported = errors.New("share already reported")
 )
 
 // Storage 定义了存储层的接口
 type Storage interface {
-	// CreateShare 创建新的分享
+	// CreateShare 创建新的分享，ID 冲突时返回 ErrDuplicateID
 	CreateShare(ctx context.Context, share *models.Share) error
 
 	// GetShare 通过 shareId 获取分享
 	GetShare(ctx context.Context, shareId string) (*models.Share, error)
 
-	// IncrementViews 增加分享的访问次数，返回更新后的计数
+	// FindShareByHash 查找内容哈希相同、匿名且永不过期的分享，不存在时返回 nil
+	FindShareByHash(ctx context.Context, contentHash string) (*models.Share, error)
+
+	// ListShares 按条件列出公开分享，返回当前页和下一页游标（没有更多数据时为空）
+	// 结果不包含代码内容，非公开、已过期或限制访问次数的分享不会出现在列表中
+	ListShares(ctx context.Context, query *models.ShareQuery) ([]*models.Share, string, error)
+
+	// GetSharesByIDs 批量获取分享摘要（不包含代码内容），不存在的 ID 会被忽略
+	GetSharesByIDs(ctx context.Context, shareIds []string) ([]*models.Share, error)
+
+	// UpdateTags 替换分享的标签
+	UpdateTags(ctx context.Context, shareId string, tags []string) error
+
+	// IncrementViews 原子地增加分享的访问次数，返回更新后的计数
+	// 设置了 max_views 的分享在达到上限时被锁定并清除内容，之后返回 ErrViewLimitReached
 	IncrementViews(ctx context.Context, shareId string) (int64, error)
 
+	// RecordView 记录一次访问事件到按天聚合的统计中，返回该访客当天是否首次访问
+	RecordView(ctx context.Context, event *models.ViewEvent) (bool, error)
+
+	// GetDailyViews 获取分享在 [from, to] 日期区间内的每日访问统计
+	GetDailyViews(ctx context.Context, shareId string, from, to time.Time) ([]*models.DailyViews, error)
+
+	// UpdateRunResult 更新分享保存的运行结果快照
+	UpdateRunResult(ctx context.Context, shareId string, result *models.RunResult) error
+
+	// SetShareHidden 设置分享的隐藏状态，隐藏时记录原因
+	SetShareHidden(ctx context.Context, shareId string, hidden bool, reason string) error
+
+	// HideSharesByAuthor 隐藏指定作者的全部分享，返回受影响的数量
+	HideSharesByAuthor(ctx context.Context, author, reason string) (int64, error)
+
+	// DeleteShare 删除分享及其访问统计、举报记录、评论和收藏
+	DeleteShare(ctx context.Context, shareId string) error
+
+	// DeleteSharesByAuthor 删除指定作者的全部分享及其访问统计、举报记录、评论和收藏，返回删除的数量
+	DeleteSharesByAuthor(ctx context.Context, author string) (int64, error)
+
+	// SetShareExpiry 设置分享的过期时间，expiresAt 为 nil 表示永不过期
+	SetShareExpiry(ctx context.Context, shareId string, expiresAt *time.Time) error
+
+	// ExportShares 按创建时间顺序逐个读取符合条件的完整分享（包括代码），fn 返回错误时停止遍历
+	// 查询条件中的 Sort、Cursor 和 Limit 不生效
+	ExportShares(ctx context.Context, query *models.ShareQuery, fn func(*models.Share) error) error
+
+	// ImportShare 按 ShareID 导入分享，原样保留创建时间、过期时间和访问次数
+	// 分享已存在时，replace 为 true 则覆盖，否则跳过；返回是否写入
+	ImportShare(ctx context.Context, share *models.Share, replace bool) (bool, error)
+
 	// DeleteExpiredShares 删除过期的分享
 	DeleteExpiredShares(ctx context.Context) error
 
+	// CreateCollection 创建合集，ID 冲突时返回 ErrDuplicateID
+	CreateCollection(ctx context.Context, collection *models.Collection) error
+
+	// GetCollection 通过 collectionId 获取合集，不存在时返回 nil
+	GetCollection(ctx context.Context, collectionId string) (*models.Collection, error)
+
+	// UpdateCollection 更新合集的标题、描述和分享列表
+	UpdateCollection(ctx context.Context, collection *models.Collection) error
+
+	// DeleteCollection 删除合集
+	DeleteCollection(ctx context.Context, collectionId string) error
+
+	// CreateUser 创建用户，用户名已存在时返回 ErrUsernameTaken
+	CreateUser(ctx context.Context, user *models.User) error
+
+	// GetUserByID 通过 userId 获取用户，不存在时返回 nil
+	GetUserByID(ctx context.Context, userId string) (*models.User, error)
+
+	// GetUserByUsername 通过用户名获取用户，不存在时返回 nil
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+
+	// GetUserByIdentity 通过外部身份提供方的 issuer 和 subject 获取用户，不存在时返回 nil
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error)
+
+	// UpdateUserIdentity 更新用户的外部身份资料和用户组
+	UpdateUserIdentity(ctx context.Context, userId string, identity *models.ExternalIdentity, groups []string) error
+
+	// TouchUserLogin 更新用户的最后登录时间
+	TouchUserLogin(ctx context.Context, userId string, at time.Time) error
+
+	// SetUserAdmin 设置用户的管理员标记，用户不存在时返回 ErrNotFound
+	SetUserAdmin(ctx context.Context, userId string, admin bool) error
+
+	// CreateToken 保存访问令牌
+	CreateToken(ctx context.Context, token *models.Token) error
+
+	// GetTokenByHash 通过令牌哈希获取未过期的令牌，不存在时返回 nil
+	GetTokenByHash(ctx context.Context, hash string) (*models.Token, error)
+
+	// ListTokens 列出用户指定类型的令牌
+	ListTokens(ctx context.Context, userId string, kind models.TokenKind) ([]*models.Token, error)
+
+	// TouchToken 更新令牌的最后使用时间
+	TouchToken(ctx context.Context, tokenId string, at time.Time) error
+
+	// DeleteToken 删除用户指定类型的令牌，类型不符时视为不存在
+	DeleteToken(ctx context.Context, userId, tokenId string, kind models.TokenKind) error
+
+	// CreateReport 保存举报，同一举报者重复举报同一分享时返回 ErrAlreadyReported
+	CreateReport(ctx context.Context, report *models.Report) error
+
+	// ListReportedShares 按举报数从多到少列出被举报的分享
+	ListReportedShares(ctx context.Context, query *models.ReportQuery) ([]*models.ReportedShare, error)
+
+	// ListReports 列出分享收到的全部举报，按时间倒序
+	ListReports(ctx context.Context, shareId string) ([]*models.Report, error)
+
+	// ResolveReports 将分享的未处理举报标记为已处理
+	ResolveReports(ctx context.Context, shareId string) error
+
+	// CreateBan 保存封禁记录
+	CreateBan(ctx context.Context, ban *models.Ban) error
+
+	// ListBans 列出全部未过期的封禁
+	ListBans(ctx context.Context) ([]*models.Ban, error)
+
+	// DeleteBan 解除封禁
+	DeleteBan(ctx context.Context, banId string) error
+
+	// FindBan 查找匹配的未过期封禁，不存在时返回 nil
+	FindBan(ctx context.Context, kind models.BanKind, value string) (*models.Ban, error)
+
+	// CreateComment 保存评论
+	CreateComment(ctx context.Context, comment *models.Comment) error
+
+	// GetComment 通过 commentId 获取评论，不存在时返回 nil
+	GetComment(ctx context.Context, commentId string) (*models.Comment, error)
+
+	// ListComments 按发表时间顺序列出分享的全部评论，包括回复
+	ListComments(ctx context.Context, shareId string) ([]*models.Comment, error)
+
+	// CountComments 返回分享的评论总数，包括回复
+	CountComments(ctx context.Context, shareId string) (int64, error)
+
+	// UpdateComment 修改评论内容并记录编辑时间
+	UpdateComment(ctx context.Context, commentId, body string, at time.Time) error
+
+	// DeleteComment 删除评论，删除顶层评论时一并删除其回复
+	DeleteComment(ctx context.Context, commentId string) error
+
+	// StarShare 收藏分享并返回分享的收藏数，已收藏时不重复计数
+	StarShare(ctx context.Context, shareId, userId string) (int64, error)
+
+	// UnstarShare 取消收藏并返回分享的收藏数，未收藏时不做修改
+	UnstarShare(ctx context.Context, shareId, userId string) (int64, error)
+
+	// HasStarred 判断用户是否收藏了分享
+	HasStarred(ctx context.Context, shareId, userId string) (bool, error)
+
+	// ListActivity 返回 since 当天及之后每个分享每天的独立访客数和新增收藏数
+	ListActivity(ctx context.Context, since time.Time) ([]*models.ShareActivity, error)
+
+	// SetTrendingScores 保存在 at 时刻计算的热度，清除更早计算的其余热度；
+	// 不会覆盖更晚计算的结果，多个实例同时计算时以最新的一次为准
+	SetTrendingScores(ctx context.Context, scores map[string]float64, at time.Time) error
+
+	// ListExpiringShares 列出过期时间在 [from, to) 区间内的分享摘要（不包含代码内容），包括非公开和隐藏的分享
+	ListExpiringShares(ctx context.Context, from, to time.Time) ([]*models.Share, error)
+
+	// CreateWebhook 保存 Webhook 订阅
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// GetWebhook 通过 webhookId 获取订阅，不存在时返回 nil
+	GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error)
+
+	// ListWebhooks 列出用户的全部订阅，按创建时间顺序
+	ListWebhooks(ctx context.Context, ownerId string) ([]*models.Webhook, error)
+
+	// ListActiveWebhooks 列出全部启用的订阅
+	ListActiveWebhooks(ctx context.Context) ([]*models.Webhook, error)
+
+	// UpdateWebhook 更新订阅的地址、事件、过滤条件、密钥和启用状态
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// DeleteWebhook 删除订阅及其投递记录
+	DeleteWebhook(ctx context.Context, webhookId string) error
+
+	// CreateDelivery 保存待投递的事件，DedupeKey 相同的投递已存在时不保存并返回 false
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+
+	// ClaimDeliveries 领取最多 limit 个到期的待投递事件，并将其下次尝试时间推迟 lease
+	// 多个实例同时领取时每个事件只会被一个实例领到，领取后实例异常退出时到期可被重新领取
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+
+	// FinishDeliveryAttempt 记录一次投递尝试并更新状态，next 为下次重试时间，投递结束时为 nil
+	FinishDeliveryAttempt(ctx context.Context, deliveryId string, attempt *models.DeliveryAttempt, status models.DeliveryStatus, next *time.Time) error
+
+	// GetDelivery 通过 deliveryId 获取投递记录，不存在时返回 nil
+	GetDelivery(ctx context.Context, deliveryId string) (*models.WebhookDelivery, error)
+
+	// ListDeliveries 按创建时间倒序列出订阅最近的投递记录
+	ListDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error)
+
+	// RetryDelivery 将投递重新置为待投递状态，在 at 时刻重试
+	RetryDelivery(ctx context.Context, deliveryId string, at time.Time) error
+
 	// Close 关闭存储连接
 	Close(ctx context.Context) error
 }
+
+// Migratable 由需要结构迁移的存储实现，服务启动前应当应用全部迁移
+type Migratable interface {
+	Migrations() *migrate.Runner
+}
@@ -0,0 +1,142 @@
+// Package trending 根据近期的收藏和访问计算分享的热度。
+//
+// 热度是统计窗口内每天的独立访客数与新增收藏数的加权和，按天数指数衰减：
+//
+//	score = Σ (views + StarWeight × stars) × 0.5^(age / HalfLife)
+//
+// 其中 age 为该天中午距计算时刻的时长。计算结果由 Job 定期写回存储，
+// 热门列表查询时再按可见性、隐藏和过期状态过滤，因此隐藏或改为非公开的分享会立即从列表中消失。
+package trending
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	DefaultWindow     = 7 * 24 * time.Hour
+	DefaultHalfLife   = 24 * time.Hour
+	DefaultStarWeight = 10
+	DefaultInterval   = 10 * time.Minute
+)
+
+// 低于该值的热度视为 0，避免很久以前的零星访问让分享一直留在热门列表中
+const minScore = 0.01
+
+// Config 是热度计算的参数
+type Config struct {
+	Window     time.Duration // 统计最近多长时间内的访问和收藏
+	HalfLife   time.Duration // 热度衰减一半所需的时间
+	StarWeight float64       // 一次收藏相当于多少位独立访客
+}
+
+// DefaultConfig 返回默认的热度计算参数
+func DefaultConfig() Config {
+	return Config{
+		Window:     DefaultWindow,
+		HalfLife:   DefaultHalfLife,
+		StarWeight: DefaultStarWeight,
+	}
+}
+
+// Score 根据每日活动计算各分享在 now 时刻的热度，结果只包含热度大于 0 的分享
+func (cfg Config) Score(activity []*models.ShareActivity, now time.Time) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, a := range activity {
+		day, err := time.Parse("2006-01-02", a.Day)
+		if err != nil {
+			continue
+		}
+		age := now.Sub(day.Add(12 * time.Hour))
+		if age < 0 {
+			age = 0
+		}
+		if age > cfg.Window {
+			continue
+		}
+		weight := math.Pow(0.5, float64(age)/float64(cfg.HalfLife))
+		scores[a.ShareID] += (float64(a.Views) + cfg.StarWeight*float64(a.Stars)) * weight
+	}
+	for id, score := range scores {
+		if score < minScore {
+			delete(scores, id)
+		}
+	}
+	return scores
+}
+
+// Job 定期重新计算全部分享的热度
+type Job struct {
+	storage  storage.Storage
+	config   Config
+	interval time.Duration
+}
+
+// Option 用于配置 Job
+type Option func(*Job)
+
+// WithConfig 设置热度计算参数
+func WithConfig(cfg Config) Option {
+	return func(j *Job) {
+		j.config = cfg
+	}
+}
+
+// WithInterval 设置重新计算的间隔
+func WithInterval(d time.Duration) Option {
+	return func(j *Job) {
+		j.interval = d
+	}
+}
+
+// NewJob 创建热度计算任务
+func NewJob(s storage.Storage, opts ...Option) *Job {
+	j := &Job{
+		storage:  s,
+		config:   DefaultConfig(),
+		interval: DefaultInterval,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// Refresh 立即重新计算热度并保存，返回进入热门列表的分享数量
+func (j *Job) Refresh(ctx context.Context) (int, error) {
+	now := time.Now()
+	activity, err := j.storage.ListActivity(ctx, now.Add(-j.config.Window))
+	if err != nil {
+		return 0, fmt.Errorf("list activity: %w", err)
+	}
+	scores := j.config.Score(activity, now)
+	if err := j.storage.SetTrendingScores(ctx, scores, now); err != nil {
+		return 0, fmt.Errorf("save trending scores: %w", err)
+	}
+	return len(scores), nil
+}
+
+// Run 启动后立即计算一次，之后按间隔重复计算，直到 ctx 取消
+// 多个实例同时运行时，存储按计算时间只保留最新一次的结果，重叠的计算不会互相清除
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := j.Refresh(ctx); err != nil {
+			fmt.Printf("failed to refresh trending shares: %v\n", err)
+		} else {
+			fmt.Printf("refreshed trending shares: %d\n", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
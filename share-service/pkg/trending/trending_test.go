@@ -0,0 +1,85 @@
+package trending
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+)
+
+func TestScore(t *testing.T) {
+	cfg := DefaultConfig()
+	// 计算时刻为 3 月 10 日中午，当天的活动年龄为 0
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		activity []*models.ShareActivity
+		want     map[string]float64
+	}{
+		{
+			name:     "today counts in full",
+			activity: []*models.ShareActivity{{ShareID: "a", Day: "2025-03-10", Views: 3, Stars: 1}},
+			want:     map[string]float64{"a": 13},
+		},
+		{
+			name: "decays by half per half-life and sums across days",
+			activity: []*models.ShareActivity{
+				{ShareID: "a", Day: "2025-03-09", Views: 8},
+				{ShareID: "a", Day: "2025-03-08", Views: 8},
+			},
+			want: map[string]float64{"a": 4 + 2},
+		},
+		{
+			name:     "future days are not boosted",
+			activity: []*models.ShareActivity{{ShareID: "a", Day: "2025-03-11", Views: 5}},
+			want:     map[string]float64{"a": 5},
+		},
+		{
+			name: "days outside the window are ignored",
+			activity: []*models.ShareActivity{
+				{ShareID: "a", Day: "2025-03-03", Views: 1000},
+				{ShareID: "b", Day: "2025-03-02", Views: 1000},
+			},
+			want: map[string]float64{"a": 1000 * math.Pow(0.5, 7)},
+		},
+		{
+			name: "scores below the minimum are dropped",
+			activity: []*models.ShareActivity{
+				{ShareID: "a", Day: "2025-03-03", Views: 1},
+				{ShareID: "b", Day: "2025-03-10"},
+			},
+			want: map[string]float64{},
+		},
+		{
+			name:     "invalid days are skipped",
+			activity: []*models.ShareActivity{{ShareID: "a", Day: "yesterday", Views: 10}},
+			want:     map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		got := cfg.Score(tt.activity, now)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Score() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for id, want := range tt.want {
+			if math.Abs(got[id]-want) > 1e-9 {
+				t.Errorf("%s: score of %s = %v, want %v", tt.name, id, got[id], want)
+			}
+		}
+	}
+}
+
+func TestScoreUsesConfig(t *testing.T) {
+	cfg := Config{Window: 24 * time.Hour, HalfLife: 12 * time.Hour, StarWeight: 2}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	activity := []*models.ShareActivity{
+		{ShareID: "a", Day: "2025-03-09", Views: 4, Stars: 2},
+		{ShareID: "a", Day: "2025-03-08", Views: 100},
+	}
+	if got := cfg.Score(activity, now); math.Abs(got["a"]-2) > 1e-9 {
+		t.Errorf("Score() = %v, want a: 2", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Package txtar 实现 Go 工具链和 Go Playground 使用的 txtar 文本归档格式。
+//
+// 归档由一段可选的注释和若干文件组成，每个文件以单独一行的 "-- 文件名 --" 标记开头：
+//
+//	注释
+//	-- prog.go --
+//	package main
+//	-- go.mod --
+//	module play
+//
+// 格式与 golang.org/x/tools/txtar 兼容。
+package txtar
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Archive 代表一个 txtar 归档
+type Archive struct {
+	Comment []byte
+	Files   []File
+}
+
+// File 代表归档中的一个文件
+type File struct {
+	Name string
+	Data []byte
+}
+
+var (
+	newlineMarker = []byte("\n-- ")
+	marker        = []byte("-- ")
+	markerEnd     = []byte(" --")
+)
+
+// Format 将归档序列化为文本，注释和文件内容缺少结尾换行时会自动补上
+func Format(a *Archive) []byte {
+	var buf bytes.Buffer
+	buf.Write(fixNL(a.Comment))
+	for _, f := range a.Files {
+		buf.WriteString("-- " + f.Name + " --\n")
+		buf.Write(fixNL(f.Data))
+	}
+	return buf.Bytes()
+}
+
+// Parse 解析 txtar 文本，不会返回错误，没有文件标记的内容整体视为注释
+func Parse(data []byte) *Archive {
+	a := new(Archive)
+	var name string
+	a.Comment, name, data = findFileMarker(data)
+	for name != "" {
+		f := File{Name: name}
+		f.Data, name, data = findFileMarker(data)
+		a.Files = append(a.Files, f)
+	}
+	return a
+}
+
+// HasFiles 判断文本中是否包含文件标记
+func HasFiles(data []byte) bool {
+	_, name, _ := findFileMarker(data)
+	return name != ""
+}
+
+// findFileMarker 查找下一个文件标记，返回标记之前的内容、文件名和标记之后的内容
+func findFileMarker(data []byte) (before []byte, name string, after []byte) {
+	var i int
+	for {
+		if name, after = isMarker(data[i:]); name != "" {
+			return data[:i], name, after
+		}
+		j := bytes.Index(data[i:], newlineMarker)
+		if j < 0 {
+			return fixNL(data), "", nil
+		}
+		i += j + 1 // 指向标记行开头
+	}
+}
+
+// isMarker 判断 data 是否以文件标记行开头，是则返回文件名和标记行之后的内容
+func isMarker(data []byte) (name string, after []byte) {
+	if !bytes.HasPrefix(data, marker) {
+		return "", nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data, after = data[:i], data[i+1:]
+	}
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	if !(bytes.HasSuffix(data, markerEnd) && len(data) >= len(marker)+len(markerEnd)) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data[len(marker) : len(data)-len(markerEnd)])), after
+}
+
+// fixNL 为非空且缺少结尾换行的内容补上换行
+func fixNL(data []byte) []byte {
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return data
+	}
+	d := make([]byte, len(data)+1)
+	copy(d, data)
+	d[len(data)] = '\n'
+	return d
+}
@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	// 启用的订阅列表在内存中的缓存时间，其他实例修改订阅后最多延迟这么久生效
+	defaultCacheTTL = 30 * time.Second
+	// 异步保存投递记录的超时时间
+	publishTimeout = 10 * time.Second
+)
+
+// Dispatcher 将事件匹配到订阅并保存待投递记录
+type Dispatcher struct {
+	storage   storage.Storage
+	publicURL string
+	cacheTTL  time.Duration
+	// 是否允许投递到回环和内网地址，仅用于本地开发
+	allowPrivate bool
+
+	mu       sync.Mutex
+	cached   []*models.Webhook
+	loadedAt time.Time
+}
+
+// Option 用于配置 Dispatcher
+type Option func(*Dispatcher)
+
+// WithPublicURL 设置服务对外的根地址，用于生成事件中的分享链接
+func WithPublicURL(u string) Option {
+	return func(d *Dispatcher) {
+		d.publicURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithCacheTTL 设置启用的订阅列表的缓存时间，0 表示每次都从存储读取
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.cacheTTL = ttl
+	}
+}
+
+// WithPrivateAddresses 允许订阅和投递到回环、内网等非公网地址，仅用于本地开发和测试
+func WithPrivateAddresses() Option {
+	return func(d *Dispatcher) {
+		d.allowPrivate = true
+	}
+}
+
+// NewDispatcher 创建 Dispatcher
+func NewDispatcher(s storage.Storage, opts ...Option) *Dispatcher {
+	d := &Dispatcher{storage: s, cacheTTL: defaultCacheTTL}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Invalidate 清除订阅缓存，本实例修改订阅后调用
+func (d *Dispatcher) Invalidate() {
+	d.mu.Lock()
+	d.cached = nil
+	d.mu.Unlock()
+}
+
+// Publish 在后台为事件保存待投递记录，不阻塞调用方，失败时只记录日志
+func (d *Dispatcher) Publish(event models.WebhookEventType, share *models.Share) {
+	snapshot := *share
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+		if _, err := d.Enqueue(ctx, event, &snapshot, time.Now()); err != nil {
+			fmt.Printf("failed to enqueue webhook event %s for share %s: %v\n", event, snapshot.ShareID, err)
+		}
+	}()
+}
+
+// Enqueue 为匹配事件的每个订阅保存待投递记录，在 at 时刻开始投递，返回新保存的记录数
+func (d *Dispatcher) Enqueue(ctx context.Context, event models.WebhookEventType, share *models.Share, at time.Time) (int, error) {
+	webhooks, err := d.activeWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// 同一事件匹配多个订阅时，合集只读取一次
+	collections := make(map[string]*models.Collection)
+	n := 0
+	for _, w := range webhooks {
+		ok, err := d.matches(ctx, w, event, share, collections)
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			continue
+		}
+		created, err := d.save(ctx, w, event, share, at)
+		if err != nil {
+			return n, err
+		}
+		if created {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Ping 为订阅保存一条测试事件，立即投递
+func (d *Dispatcher) Ping(ctx context.Context, w *models.Webhook) (*models.WebhookDelivery, error) {
+	delivery, err := d.newDelivery(w, models.EventPing, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.storage.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d *Dispatcher) activeWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cached != nil && time.Since(d.loadedAt) < d.cacheTTL {
+		return d.cached, nil
+	}
+	webhooks, err := d.storage.ListActiveWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []*models.Webhook{}
+	}
+	d.cached, d.loadedAt = webhooks, time.Now()
+	return webhooks, nil
+}
+
+// matches 判断订阅是否应当收到该分享的事件
+func (d *Dispatcher) matches(ctx context.Context, w *models.Webhook, event models.WebhookEventType, share *models.Share, collections map[string]*models.Collection) (bool, error) {
+	if !w.Active || !w.Subscribes(event) || share.Hidden {
+		return false, nil
+	}
+	// 访问次数每次加一，恰好等于阈值时即为越过阈值的那一次访问
+	if event == models.EventShareViewed && (w.ViewThreshold <= 0 || share.Views != w.ViewThreshold) {
+		return false, nil
+	}
+	// 公开分享的事件可以发给任何订阅，其他分享只发给分享所有者自己的订阅，避免泄露不公开的链接
+	if share.EffectiveVisibility() != models.VisibilityPublic && (share.OwnerID == "" || share.OwnerID != w.OwnerID) {
+		return false, nil
+	}
+
+	if len(w.Filter.Authors) > 0 {
+		found := false
+		for _, author := range w.Filter.Authors {
+			if author == share.Author {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if id := w.Filter.Collection; id != "" {
+		collection, ok := collections[id]
+		if !ok {
+			var err error
+			collection, err = d.storage.GetCollection(ctx, id)
+			if err != nil {
+				return false, err
+			}
+			collections[id] = collection
+		}
+		if collection == nil {
+			return false, nil
+		}
+		found := false
+		for _, shareId := range collection.ShareIDs {
+			if shareId == share.ShareID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *Dispatcher) save(ctx context.Context, w *models.Webhook, event models.WebhookEventType, share *models.Share, at time.Time) (bool, error) {
+	delivery, err := d.newDelivery(w, event, share, at)
+	if err != nil {
+		return false, err
+	}
+	return d.storage.CreateDelivery(ctx, delivery)
+}
+
+// newDelivery 生成投递记录，请求体在此时确定，之后分享被修改或删除也不影响投递内容
+func (d *Dispatcher) newDelivery(w *models.Webhook, event models.WebhookEventType, share *models.Share, at time.Time) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		DeliveryID:    uuid.New().String(),
+		WebhookID:     w.WebhookID,
+		Event:         event,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &at,
+		CreatedAt:     time.Now(),
+	}
+	payload := &models.WebhookPayload{
+		ID:        delivery.DeliveryID,
+		WebhookID: w.WebhookID,
+		Event:     event,
+		CreatedAt: at,
+	}
+
+	if share != nil {
+		delivery.ShareID = share.ShareID
+		summary := models.NewShareSummary(share)
+		payload.Share = &summary
+		payload.URL = d.publicURL + "/share/" + url.PathEscape(share.ShareID)
+
+		// 创建、访问阈值和过期事件对同一订阅只投递一次，重复调用不会重复投递
+		switch event {
+		case models.EventShareCreated:
+			delivery.DedupeKey = w.WebhookID + ":" + string(event) + ":" + share.ShareID
+		case models.EventShareViewed:
+			delivery.DedupeKey = w.WebhookID + ":" + string(event) + ":" + share.ShareID + ":" + strconv.FormatInt(w.ViewThreshold, 10)
+		case models.EventShareExpired:
+			if share.ExpiresAt != nil {
+				delivery.DedupeKey = w.WebhookID + ":" + string(event) + ":" + share.ShareID + ":" + strconv.FormatInt(share.ExpiresAt.Unix(), 10)
+			}
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+	return delivery, nil
+}
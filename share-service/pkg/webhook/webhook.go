@@ -0,0 +1,98 @@
+// Package webhook 实现分享生命周期事件的 Webhook 投递。
+//
+// Dispatcher 在事件发生时找出匹配的订阅，为每个订阅保存一条待投递记录；
+// Worker 定期领取到期的记录，向订阅地址发送带 HMAC 签名的 POST 请求，失败时按指数退避重试，
+// 每次尝试的结果都记录在投递记录中。投递记录保存在存储中，服务重启或多实例部署时不会丢失或重复投递。
+//
+// 请求头 X-Playground-Signature 的格式为 t=<unix 秒>,v1=<签名>，
+// 签名是以订阅密钥为 key 对 "<t>.<请求体>" 计算的 HMAC-SHA256 十六进制值，接收方可以使用 Verify 校验。
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 投递请求携带的请求头
+const (
+	HeaderEvent     = "X-Playground-Event"
+	HeaderDelivery  = "X-Playground-Delivery"
+	HeaderSignature = "X-Playground-Signature"
+)
+
+// DefaultTolerance 是 Verify 允许的签名时间与当前时间的最大偏差，用于防止重放
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature 表示签名格式错误或与请求体不匹配
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired 表示签名时间超出允许的偏差
+	ErrSignatureExpired = errors.New("webhook signature timestamp is outside the tolerance")
+)
+
+// NewSecret 生成随机的签名密钥
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign 计算 at 时刻发送 body 时的签名请求头
+func Sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+// Verify 校验签名请求头，签名时间与 now 相差超过 tolerance 时返回 ErrSignatureExpired
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := signature(secret, ts, body)
+	valid := false
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: signed at %s", ErrSignatureExpired, time.Unix(unix, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
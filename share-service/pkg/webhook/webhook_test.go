@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"share.created"}`)
+	at := time.Unix(1700000000, 0)
+	header := Sign("whsec_test", at, body)
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("unexpected header %q", header)
+	}
+
+	if err := Verify("whsec_test", header, body, DefaultTolerance, at.Add(time.Minute)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	// 轮换密钥期间接收方可能收到多个签名，任意一个匹配即可
+	multi := header + ",v1=" + strings.Repeat("0", 64)
+	if err := Verify("whsec_test", multi, body, DefaultTolerance, at); err != nil {
+		t.Fatalf("Verify with several signatures: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+	}{
+		{"wrong secret", "whsec_other", header, string(body)},
+		{"modified body", "whsec_test", header, `{"event":"share.updated"}`},
+		{"missing timestamp", "whsec_test", strings.Split(header, ",")[1], string(body)},
+		{"missing signature", "whsec_test", strings.Split(header, ",")[0], string(body)},
+		{"modified timestamp", "whsec_test", strings.Replace(header, "t=1700000000", "t=1700000001", 1), string(body)},
+		{"empty", "whsec_test", "", string(body)},
+	}
+	for _, tt := range tests {
+		if err := Verify(tt.secret, tt.header, []byte(tt.body), DefaultTolerance, at); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify error = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	body := []byte("{}")
+	at := time.Unix(1700000000, 0)
+	header := Sign("s", at, body)
+
+	for _, offset := range []time.Duration{0, DefaultTolerance, -DefaultTolerance} {
+		if err := Verify("s", header, body, DefaultTolerance, at.Add(offset)); err != nil {
+			t.Errorf("offset %s: Verify: %v", offset, err)
+		}
+	}
+	for _, offset := range []time.Duration{DefaultTolerance + time.Second, -DefaultTolerance - time.Second} {
+		if err := Verify("s", header, body, DefaultTolerance, at.Add(offset)); !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("offset %s: Verify error = %v, want ErrSignatureExpired", offset, err)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSecret()
+	if a == b || !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+48 {
+		t.Errorf("unexpected secrets %q and %q", a, b)
+	}
+	if _, err := strconv.ParseUint(a[len("whsec_"):len("whsec_")+8], 16, 64); err != nil {
+		t.Errorf("secret is not hex: %q", a)
+	}
+}
@@ -0,0 +1,253 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+const (
+	DefaultMaxAttempts  = 10
+	DefaultPollInterval = 5 * time.Second
+	DefaultTimeout      = 10 * time.Second
+
+	// 第一次重试的等待时间，之后每次翻倍，最长 maxBackoff
+	baseBackoff = time.Minute
+	maxBackoff  = 6 * time.Hour
+	// 每轮最多领取的投递数量
+	claimBatch = 20
+	// 过期事件提前排期的时间，记录在分享到期时才投递
+	expiryLookahead = 5 * time.Minute
+	// 开启 WithResponseBodies 时投递记录中保存的响应体长度
+	maxResponseBytes = 512
+)
+
+// Worker 领取到期的投递记录并发送，失败时按指数退避安排重试
+type Worker struct {
+	storage      storage.Storage
+	dispatcher   *Dispatcher
+	client       *http.Client
+	maxAttempts  int
+	pollInterval time.Duration
+	timeout      time.Duration
+	// 是否在投递记录中保存响应体
+	saveResponses bool
+
+	// 上次扫描即将过期的分享的时间
+	scannedAt time.Time
+}
+
+// WorkerOption 用于配置 Worker
+type WorkerOption func(*Worker)
+
+// WithClient 设置发送请求使用的 HTTP 客户端
+func WithClient(client *http.Client) WorkerOption {
+	return func(w *Worker) {
+		w.client = client
+	}
+}
+
+// WithMaxAttempts 设置每个投递的最大尝试次数
+func WithMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		w.maxAttempts = n
+	}
+}
+
+// WithPollInterval 设置领取到期投递的间隔
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.pollInterval = d
+	}
+}
+
+// WithTimeout 设置单次请求的超时时间
+func WithTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.timeout = d
+	}
+}
+
+// WithResponseBodies 在投递记录中保存响应体的开头部分，便于排查。
+// 响应体可能包含接收方的内部信息，并且会展示给订阅的所有者，默认不保存
+func WithResponseBodies() WorkerOption {
+	return func(w *Worker) {
+		w.saveResponses = true
+	}
+}
+
+// NewWorker 创建 Worker，过期事件通过 dispatcher 匹配订阅
+func NewWorker(s storage.Storage, dispatcher *Dispatcher, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		storage:      s,
+		dispatcher:   dispatcher,
+		maxAttempts:  DefaultMaxAttempts,
+		pollInterval: DefaultPollInterval,
+		timeout:      DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.client == nil {
+		w.client = newClient(dispatcher.allowPrivate)
+	}
+	return w
+}
+
+// Run 按间隔扫描即将过期的分享并投递到期的事件，直到 ctx 取消
+// 多个实例可以同时运行，每个投递只会被一个实例领取
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("webhook worker: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一轮扫描和投递，投递全部完成后返回
+func (w *Worker) RunOnce(ctx context.Context) error {
+	now := time.Now()
+	if now.Sub(w.scannedAt) >= time.Minute {
+		if err := w.scanExpiring(ctx, now); err != nil {
+			return fmt.Errorf("scan expiring shares: %w", err)
+		}
+		w.scannedAt = now
+	}
+
+	for {
+		// 租期覆盖一轮投递的最长耗时，实例在投递中途退出时租期结束后由其他实例重新领取
+		deliveries, err := w.storage.ClaimDeliveries(ctx, time.Now(), 2*w.timeout+time.Minute, claimBatch)
+		if err != nil {
+			return fmt.Errorf("claim deliveries: %w", err)
+		}
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			wg.Add(1)
+			go func(d *models.WebhookDelivery) {
+				defer wg.Done()
+				if err := w.deliver(ctx, d); err != nil {
+					fmt.Printf("webhook worker: delivery %s: %v\n", d.DeliveryID, err)
+				}
+			}(d)
+		}
+		wg.Wait()
+		if len(deliveries) < claimBatch {
+			return nil
+		}
+	}
+}
+
+// scanExpiring 为即将过期的分享提前保存过期事件，事件在分享的过期时间投递
+// 分享过期后会被存储自动删除，因此需要在过期前排期；扫描区间相互重叠，重复扫描不会重复保存
+func (w *Worker) scanExpiring(ctx context.Context, now time.Time) error {
+	shares, err := w.storage.ListExpiringShares(ctx, now.Add(-2*time.Minute), now.Add(expiryLookahead))
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if _, err := w.dispatcher.Enqueue(ctx, models.EventShareExpired, share, *share.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver 发送一次投递并记录结果
+func (w *Worker) deliver(ctx context.Context, d *models.WebhookDelivery) error {
+	webhook, err := w.storage.GetWebhook(ctx, d.WebhookID)
+	if err != nil {
+		return err
+	}
+	attempt := &models.DeliveryAttempt{At: time.Now()}
+	if webhook == nil || !webhook.Active {
+		attempt.Error = "webhook was deleted or disabled"
+		return w.storage.FinishDeliveryAttempt(ctx, d.DeliveryID, attempt, models.DeliveryFailed, nil)
+	}
+
+	// 过期事件提前排期，投递前确认分享确实已经过期，过期时间被延长或取消时不再投递
+	if d.Event == models.EventShareExpired {
+		share, err := w.storage.GetShare(ctx, d.ShareID)
+		if err != nil {
+			return err
+		}
+		if share != nil && (share.ExpiresAt == nil || share.ExpiresAt.After(attempt.At)) {
+			attempt.Error = "share expiry was extended"
+			return w.storage.FinishDeliveryAttempt(ctx, d.DeliveryID, attempt, models.DeliveryCancelled, nil)
+		}
+	}
+
+	w.send(ctx, webhook, d, attempt)
+
+	if attempt.Error == "" {
+		return w.storage.FinishDeliveryAttempt(ctx, d.DeliveryID, attempt, models.DeliverySucceeded, nil)
+	}
+	if len(d.Attempts)+1 >= w.maxAttempts {
+		return w.storage.FinishDeliveryAttempt(ctx, d.DeliveryID, attempt, models.DeliveryFailed, nil)
+	}
+	next := attempt.At.Add(Backoff(len(d.Attempts) + 1))
+	return w.storage.FinishDeliveryAttempt(ctx, d.DeliveryID, attempt, models.DeliveryPending, &next)
+}
+
+// send 发送签名的请求并将结果写入 attempt，非 2xx 响应和网络错误都记录为失败
+func (w *Worker) send(ctx context.Context, webhook *models.Webhook, d *models.WebhookDelivery, attempt *models.DeliveryAttempt) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	defer func() {
+		attempt.DurationMS = time.Since(attempt.At).Milliseconds()
+	}()
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-playground-share-service")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderDelivery, d.DeliveryID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, attempt.At, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if w.saveResponses {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+		attempt.Response = string(data)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "unexpected status " + resp.Status
+	}
+}
+
+// Backoff 返回第 n 次失败后到下次重试的等待时间：1 分钟起每次翻倍，最长 6 小时
+func Backoff(n int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,288 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/playground/share-service/pkg/models"
+	"github.com/playground/share-service/pkg/storage"
+)
+
+// fakeStorage 在内存中保存订阅和投递记录，只实现 Worker 使用的方法
+type fakeStorage struct {
+	storage.Storage
+
+	mu         sync.Mutex
+	webhooks   map[string]*models.Webhook
+	deliveries map[string]*models.WebhookDelivery
+}
+
+func newFakeStorage(webhooks ...*models.Webhook) *fakeStorage {
+	s := &fakeStorage{webhooks: make(map[string]*models.Webhook), deliveries: make(map[string]*models.WebhookDelivery)}
+	for _, w := range webhooks {
+		s.webhooks[w.WebhookID] = w
+	}
+	return s
+}
+
+func (s *fakeStorage) ListExpiringShares(context.Context, time.Time, time.Time) ([]*models.Share, error) {
+	return nil, nil
+}
+
+func (s *fakeStorage) GetWebhook(_ context.Context, id string) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhooks[id], nil
+}
+
+func (s *fakeStorage) CreateDelivery(_ context.Context, d *models.WebhookDelivery) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.DeliveryID] = d
+	return true, nil
+}
+
+func (s *fakeStorage) ClaimDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []*models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status != models.DeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		next := now.Add(lease)
+		d.NextAttemptAt = &next
+		copied := *d
+		copied.Attempts = append([]models.DeliveryAttempt(nil), d.Attempts...)
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (s *fakeStorage) FinishDeliveryAttempt(_ context.Context, id string, attempt *models.DeliveryAttempt, status models.DeliveryStatus, next *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	d.Attempts = append(d.Attempts, *attempt)
+	d.Status = status
+	d.NextAttemptAt = next
+	return nil
+}
+
+// delivery 返回投递记录的副本
+func (s *fakeStorage) delivery(id string) models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[id]
+}
+
+// makeDue 将待重试的投递提前到当前时间，模拟等待退避时间结束
+func (s *fakeStorage) makeDue(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.deliveries[id]; d.NextAttemptAt != nil {
+		now := time.Now()
+		d.NextAttemptAt = &now
+	}
+}
+
+func newTestWebhook(url string) *models.Webhook {
+	return &models.Webhook{WebhookID: "wh-1", URL: url, Secret: "whsec_test", Active: true, Events: []models.WebhookEventType{models.EventShareCreated}}
+}
+
+func enqueueTestDelivery(t *testing.T, d *Dispatcher, w *models.Webhook) *models.WebhookDelivery {
+	t.Helper()
+	delivery, err := d.Ping(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.n); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerRetriesUntilFailed(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("internal details"))
+	}))
+	defer receiver.Close()
+
+	webhook := newTestWebhook(receiver.URL)
+	store := newFakeStorage(webhook)
+	dispatcher := NewDispatcher(store, WithPrivateAddresses())
+	worker := NewWorker(store, dispatcher, WithMaxAttempts(3))
+	delivery := enqueueTestDelivery(t, dispatcher, webhook)
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		before := time.Now()
+		if err := worker.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		d := store.delivery(delivery.DeliveryID)
+		if len(d.Attempts) != i {
+			t.Fatalf("after run %d: %d attempts, want %d", i, len(d.Attempts), i)
+		}
+		attempt := d.Attempts[i-1]
+		if attempt.StatusCode != http.StatusInternalServerError || attempt.Error == "" {
+			t.Errorf("attempt %d = %+v, want a recorded 500 failure", i, attempt)
+		}
+		if attempt.Response != "" {
+			t.Errorf("attempt %d stored the response body %q", i, attempt.Response)
+		}
+
+		if i < 3 {
+			if d.Status != models.DeliveryPending || d.NextAttemptAt == nil {
+				t.Fatalf("after run %d: status %s, want pending with a retry time", i, d.Status)
+			}
+			if wait := d.NextAttemptAt.Sub(before); wait < Backoff(i) || wait > Backoff(i)+time.Minute {
+				t.Errorf("after run %d: retry in %s, want about %s", i, wait, Backoff(i))
+			}
+			// 退避时间未到时不会再次投递
+			if err := worker.RunOnce(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(store.delivery(delivery.DeliveryID).Attempts); n != i {
+				t.Fatalf("delivery was retried before its backoff elapsed")
+			}
+			store.makeDue(delivery.DeliveryID)
+		} else if d.Status != models.DeliveryFailed || d.NextAttemptAt != nil {
+			t.Fatalf("after run %d: status %s, want failed without a retry time", i, d.Status)
+		}
+	}
+
+	if n := calls.Load(); n != 3 {
+		t.Errorf("receiver called %d times, want 3", n)
+	}
+}
+
+func TestWorkerSignsAndSucceeds(t *testing.T) {
+	var header http.Header
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	webhook := newTestWebhook(receiver.URL)
+	store := newFakeStorage(webhook)
+	dispatcher := NewDispatcher(store, WithPrivateAddresses())
+	worker := NewWorker(store, dispatcher, WithResponseBodies())
+	delivery := enqueueTestDelivery(t, dispatcher, webhook)
+
+	if err := worker.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d := store.delivery(delivery.DeliveryID)
+	if d.Status != models.DeliverySucceeded || len(d.Attempts) != 1 || d.Attempts[0].Response != "ok" {
+		t.Fatalf("unexpected delivery %+v", d)
+	}
+	if header.Get(HeaderDelivery) != delivery.DeliveryID || header.Get(HeaderEvent) != string(models.EventPing) {
+		t.Errorf("unexpected headers %v", header)
+	}
+	if err := Verify(webhook.Secret, header.Get(HeaderSignature), body, DefaultTolerance, time.Now()); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestWorkerRejectsPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer receiver.Close()
+
+	// 订阅地址绕过 CheckURL 时，连接阶段同样拒绝回环地址
+	webhook := newTestWebhook(receiver.URL)
+	store := newFakeStorage(webhook)
+	dispatcher := NewDispatcher(store)
+	worker := NewWorker(store, dispatcher, WithMaxAttempts(1))
+	delivery := enqueueTestDelivery(t, dispatcher, webhook)
+
+	if err := worker.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d := store.delivery(delivery.DeliveryID)
+	if d.Status != models.DeliveryFailed || !strings.Contains(d.Attempts[0].Error, ErrPrivateAddress.Error()) {
+		t.Fatalf("unexpected delivery %+v", d)
+	}
+	if calls.Load() != 0 {
+		t.Error("receiver on a loopback address was called")
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	d := NewDispatcher(newFakeStorage())
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/x", true},
+		{"https://93.184.216.34/", true},
+		{"http://localhost:8080/", false},
+		{"http://api.localhost/", false},
+		{"http://127.0.0.1/", false},
+		{"http://[::1]/", false},
+		{"http://10.1.2.3/", false},
+		{"http://192.168.0.1/", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.100.100.200/", false},
+		{"http://0.0.0.0/", false},
+		{"http://[::ffff:127.0.0.1]/", false},
+		{"http://[fe80::1]/", false},
+		{"http://[fd00::1]/", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		err := d.CheckURL(u)
+		if tt.ok && err != nil {
+			t.Errorf("CheckURL(%s) = %v, want nil", tt.url, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckURL(%s) = %v, want ErrPrivateAddress", tt.url, err)
+		}
+	}
+
+	u, _ := url.Parse("http://127.0.0.1:3005/")
+	if err := NewDispatcher(newFakeStorage(), WithPrivateAddresses()).CheckURL(u); err != nil {
+		t.Errorf("CheckURL with private addresses allowed = %v", err)
+	}
+}